@@ -1,9 +1,13 @@
 package parser
 
 import (
+	"fmt"
+
 	"github.com/Edsuns/sql-parser/analyzer"
 	"github.com/Edsuns/sql-parser/internal/hive"
+	"github.com/Edsuns/sql-parser/internal/mysql"
 	"github.com/Edsuns/sql-parser/internal/spark"
+	"github.com/Edsuns/sql-parser/internal/starrocks"
 	"github.com/Edsuns/sql-parser/internal/tidb"
 )
 
@@ -18,3 +22,40 @@ func NewSparkDependencyAnalyzer() analyzer.DependencyAnalyzer {
 func NewTiDBDependencyAnalyzer() analyzer.DependencyAnalyzer {
 	return tidb.NewDependencyAnalyzer()
 }
+
+func NewMySQLDependencyAnalyzer() analyzer.DependencyAnalyzer {
+	return mysql.NewDependencyAnalyzer()
+}
+
+func NewStarRocksDependencyAnalyzer() analyzer.DependencyAnalyzer {
+	return starrocks.NewDependencyAnalyzer()
+}
+
+// NewDependencyAnalyzer 根据引擎类型创建依赖分析器，可作为 job.AnalyzerFactory 使用
+func NewDependencyAnalyzer(engine analyzer.EngineType) (analyzer.DependencyAnalyzer, error) {
+	switch engine {
+	case analyzer.EngineHive:
+		return NewHiveDependencyAnalyzer(), nil
+	case analyzer.EngineSpark:
+		return NewSparkDependencyAnalyzer(), nil
+	case analyzer.EngineTiDB:
+		return NewTiDBDependencyAnalyzer(), nil
+	case analyzer.EngineMySQL:
+		return NewMySQLDependencyAnalyzer(), nil
+	case analyzer.EngineStarRocks:
+		return NewStarRocksDependencyAnalyzer(), nil
+	}
+	return nil, fmt.Errorf("unsupported engine type: %s", engine)
+}
+
+func NewMySQLDDLExtractor() analyzer.DDLExtractor {
+	return mysql.NewDDLExtractor()
+}
+
+func NewStarRocksDDLExtractor() analyzer.DDLExtractor {
+	return starrocks.NewDDLExtractor()
+}
+
+func NewTiDBDDLExtractor() analyzer.DDLExtractor {
+	return tidb.NewDDLExtractor()
+}
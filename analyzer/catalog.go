@@ -0,0 +1,268 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+type TableKind string
+
+const (
+	TableKindTable TableKind = "TABLE"
+	TableKindView  TableKind = "VIEW"
+
+	TableKindMaterializedView TableKind = "MATERIALIZED_VIEW" // 物化视图，ViewDefinition 为其定义，读取时不展开
+	TableKindDictionary       TableKind = "DICTIONARY"        // 缓存源表数据的字典，例如 StarRocks 的 DICTIONARY
+)
+
+type (
+	CatalogColumn struct {
+		Name    string `json:"name" yaml:"name"`
+		Type    string `json:"type" yaml:"type"`
+		Comment string `json:"comment,omitempty" yaml:"comment,omitempty"`
+	}
+	CatalogTable struct {
+		Cluster        string           `json:"cluster,omitempty" yaml:"cluster,omitempty"` // 为空时匹配任意集群
+		Database       string           `json:"database" yaml:"database"`
+		Table          string           `json:"table" yaml:"table"`
+		Kind           TableKind        `json:"kind,omitempty" yaml:"kind,omitempty"` // 为空时视为 TableKindTable
+		Columns        []*CatalogColumn `json:"columns,omitempty" yaml:"columns,omitempty"`
+		PartitionKeys  []string         `json:"partitionKeys,omitempty" yaml:"partitionKeys,omitempty"`   // 分区键，即 PARTITION BY 的列，用于从WHERE条件推断读取的分区
+		Partitions     []string         `json:"partitions,omitempty" yaml:"partitions,omitempty"`         // 已有分区的名称
+		ViewDefinition string           `json:"viewDefinition,omitempty" yaml:"viewDefinition,omitempty"` // 视图的 CREATE VIEW 语句或物化视图的 CREATE MATERIALIZED VIEW 语句
+	}
+)
+
+// Catalog 元数据目录，提供表结构信息。分析器在提供了Catalog时用它确定表的类型、展开视图、
+// 补充物化视图刷新读取的基表以及推断读取的分区；分析器不展开 SELECT * 也不判断未限定的列属于哪张表，
+// ListColumns 供列级血缘、校验等调用方使用
+type Catalog interface {
+	// LookupTable 查找表，不存在时返回nil
+	LookupTable(cluster, database, table string) (*CatalogTable, error)
+	// ListColumns 列出表的列及类型
+	ListColumns(cluster, database, table string) ([]*CatalogColumn, error)
+	// GetViewDefinition 获取视图定义，不是视图时返回空字符串
+	GetViewDefinition(cluster, database, table string) (string, error)
+	// TableExists 检查表或视图是否存在
+	TableExists(cluster, database, table string) (bool, error)
+}
+
+// MemoryCatalog 基于内存的 Catalog 实现，表名不区分大小写
+type MemoryCatalog struct {
+	mu       sync.RWMutex
+	tables   map[string]*CatalogTable
+	order    []string
+	routines map[string]string // 存储过程的定义
+}
+
+// NewMemoryCatalog 创建一个新的 MemoryCatalog 实例
+func NewMemoryCatalog(tables ...*CatalogTable) *MemoryCatalog {
+	c := &MemoryCatalog{
+		tables:   make(map[string]*CatalogTable),
+		routines: make(map[string]string),
+	}
+	for _, t := range tables {
+		c.AddTable(t)
+	}
+	return c
+}
+
+// AddTable 添加或替换表
+func (c *MemoryCatalog) AddTable(t *CatalogTable) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := catalogKey(t.Cluster, t.Database, t.Table)
+	if _, ok := c.tables[key]; !ok {
+		c.order = append(c.order, key)
+	}
+	c.tables[key] = t
+}
+
+// RemoveTable 删除表，返回表是否存在
+func (c *MemoryCatalog) RemoveTable(cluster, database, table string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := catalogKey(cluster, database, table)
+	if _, ok := c.tables[key]; !ok {
+		return false
+	}
+	delete(c.tables, key)
+	for i, k := range c.order {
+		if k == key {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// Tables 按添加顺序返回所有表
+func (c *MemoryCatalog) Tables() []*CatalogTable {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	tables := make([]*CatalogTable, 0, len(c.order))
+	for _, key := range c.order {
+		tables = append(tables, c.tables[key])
+	}
+	return tables
+}
+
+func (c *MemoryCatalog) LookupTable(cluster, database, table string) (*CatalogTable, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if t, ok := c.tables[catalogKey(cluster, database, table)]; ok {
+		return t, nil
+	}
+	// 没有指定集群的表匹配任意集群
+	if t, ok := c.tables[catalogKey("", database, table)]; ok {
+		return t, nil
+	}
+	return nil, nil
+}
+
+// lookupExact 按集群、库名和表名精确查找表，没有指定集群的表不匹配其他集群
+func (c *MemoryCatalog) lookupExact(cluster, database, table string) *CatalogTable {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tables[catalogKey(cluster, database, table)]
+}
+
+func (c *MemoryCatalog) ListColumns(cluster, database, table string) ([]*CatalogColumn, error) {
+	t, err := c.LookupTable(cluster, database, table)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, fmt.Errorf("table %s.%s not found", database, table)
+	}
+	return t.Columns, nil
+}
+
+func (c *MemoryCatalog) GetViewDefinition(cluster, database, table string) (string, error) {
+	t, err := c.LookupTable(cluster, database, table)
+	if err != nil || t == nil || t.Kind != TableKindView {
+		return "", err
+	}
+	return t.ViewDefinition, nil
+}
+
+// AddRoutine 添加或替换存储过程的 CREATE PROCEDURE 语句，cluster 为空时匹配任意集群
+func (c *MemoryCatalog) AddRoutine(cluster, database, name, definition string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.routines[catalogKey(cluster, database, name)] = definition
+}
+
+func (c *MemoryCatalog) GetRoutineDefinition(cluster, database, name string) (string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if def, ok := c.routines[catalogKey(cluster, database, name)]; ok {
+		return def, nil
+	}
+	return c.routines[catalogKey("", database, name)], nil
+}
+
+func (c *MemoryCatalog) TableExists(cluster, database, table string) (bool, error) {
+	t, err := c.LookupTable(cluster, database, table)
+	return t != nil, err
+}
+
+// MarshalJSON 以schema文件格式导出
+func (c *MemoryCatalog) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&catalogFile{Tables: c.Tables()})
+}
+
+// catalogFile schema文件格式
+type catalogFile struct {
+	Tables []*CatalogTable `json:"tables" yaml:"tables"`
+}
+
+// LoadCatalog 从JSON或YAML格式的schema中加载 MemoryCatalog，format为 json 或 yaml
+func LoadCatalog(r io.Reader, format string) (*MemoryCatalog, error) {
+	var file catalogFile
+	switch strings.ToLower(format) {
+	case "json":
+		if err := json.NewDecoder(r).Decode(&file); err != nil {
+			return nil, err
+		}
+	case "yaml", "yml":
+		if err := yaml.NewDecoder(r).Decode(&file); err != nil && err != io.EOF {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported catalog format: %s", format)
+	}
+	for i, t := range file.Tables {
+		if t.Database == "" || t.Table == "" {
+			return nil, fmt.Errorf("tables[%d]: database and table are required", i)
+		}
+		if t.Kind == "" {
+			t.Kind = TableKindTable
+		}
+	}
+	return NewMemoryCatalog(file.Tables...), nil
+}
+
+// LoadCatalogFile 从schema文件加载 MemoryCatalog，根据扩展名判断格式
+func LoadCatalogFile(path string) (*MemoryCatalog, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return LoadCatalog(f, strings.TrimPrefix(filepath.Ext(path), "."))
+}
+
+// PostProcess 提供了 Catalog 时补充物化视图刷新的读表和表的类型，req.ExpandViews 时再展开读取的视图，
+// 各分析器在 Analyze 的最后调用
+func PostProcess(a DependencyAnalyzer, results []*DependencyResult, req *DependencyAnalyzeReq) error {
+	if req.Catalog == nil {
+		return nil
+	}
+	if err := AddRefreshReads(a, results, req.Catalog); err != nil {
+		return err
+	}
+	if err := ApplyCatalog(results, req.Catalog); err != nil {
+		return err
+	}
+	if req.ExpandViews {
+		return ExpandViews(a, results, req.Catalog)
+	}
+	return nil
+}
+
+// ApplyCatalog 根据 Catalog 补充分析结果及其子语句中表的类型
+func ApplyCatalog(results []*DependencyResult, catalog Catalog) error {
+	for _, result := range results {
+		if err := ApplyCatalog(result.Children, catalog); err != nil {
+			return err
+		}
+		for _, tables := range [][]*DependencyTable{result.Read, result.Write} {
+			for _, t := range tables {
+				ct, err := catalog.LookupTable(t.Cluster, t.Database, t.Table)
+				if err != nil {
+					return err
+				}
+				if ct == nil {
+					continue
+				}
+				t.Kind = ct.Kind
+				if t.Kind == "" {
+					t.Kind = TableKindTable
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func catalogKey(cluster, database, table string) string {
+	return strings.ToLower(cluster + "." + database + "." + table)
+}
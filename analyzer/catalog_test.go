@@ -0,0 +1,83 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadCatalogFile(t *testing.T) {
+	for _, path := range []string{"testdata/catalog.yaml", "testdata/catalog.json"} {
+		t.Run(path, func(t *testing.T) {
+			c, err := LoadCatalogFile(path)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			orders, err := c.LookupTable("c1", "ODS", "Orders")
+			assert.NoError(t, err)
+			if assert.NotNil(t, orders) {
+				assert.Equal(t, TableKindTable, orders.Kind)
+			}
+
+			columns, err := c.ListColumns("c1", "ods", "orders")
+			assert.NoError(t, err)
+			assert.Equal(t, []*CatalogColumn{
+				{Name: "id", Type: "bigint"},
+				{Name: "amount", Type: "decimal(10,2)", Comment: "订单金额"},
+			}, columns)
+
+			// 没有指定集群的表匹配任意集群
+			def, err := c.GetViewDefinition("any_cluster", "ads", "report_v")
+			assert.NoError(t, err)
+			assert.Equal(t, "CREATE VIEW ads.report_v AS SELECT id FROM ods.orders", def)
+
+			def, err = c.GetViewDefinition("c1", "ods", "orders")
+			assert.NoError(t, err)
+			assert.Empty(t, def)
+
+			exists, err := c.TableExists("c2", "ods", "orders")
+			assert.NoError(t, err)
+			assert.False(t, exists)
+
+			_, err = c.ListColumns("c2", "ods", "orders")
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoadCatalog_Invalid(t *testing.T) {
+	_, err := LoadCatalog(strings.NewReader(`{"tables": [{"table": "t"}]}`), "json")
+	assert.ErrorContains(t, err, "database and table are required")
+
+	_, err = LoadCatalog(strings.NewReader(``), "toml")
+	assert.ErrorContains(t, err, "unsupported catalog format")
+}
+
+func TestMemoryCatalog(t *testing.T) {
+	c := NewMemoryCatalog(
+		&CatalogTable{Database: "db", Table: "t1"},
+		&CatalogTable{Database: "db", Table: "v1", Kind: TableKindView},
+	)
+	c.AddTable(&CatalogTable{Database: "db", Table: "t2"})
+	assert.True(t, c.RemoveTable("", "db", "t1"))
+	assert.False(t, c.RemoveTable("", "db", "t1"))
+
+	data, err := json.Marshal(c)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"tables":[{"database":"db","table":"v1","kind":"VIEW"},{"database":"db","table":"t2"}]}`, string(data))
+
+	results := []*DependencyResult{
+		{
+			StmtType: StmtTypeInsert,
+			Read:     []*DependencyTable{{Cluster: "c", Database: "db", Table: "v1"}, {Cluster: "c", Database: "db", Table: "unknown"}},
+			Write:    []*DependencyTable{{Cluster: "c", Database: "db", Table: "t2"}},
+		},
+	}
+	assert.NoError(t, ApplyCatalog(results, c))
+	assert.Equal(t, TableKindView, results[0].Read[0].Kind)
+	assert.Equal(t, TableKind(""), results[0].Read[1].Kind)
+	assert.Equal(t, TableKindTable, results[0].Write[0].Kind)
+}
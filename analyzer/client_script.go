@@ -0,0 +1,162 @@
+package analyzer
+
+import (
+	"strings"
+)
+
+// SplitClientScript 按 mysql 命令行客户端的规则预处理 mysqldump 输出、迁移脚本等客户端脚本：
+//   - DELIMITER（或 \d）修改语句分隔符，以自定义分隔符结束的语句单独返回，并去掉分隔符
+//   - \G、\g 结束当前语句，并去掉该命令
+//   - source 和 \. 引用其他脚本，不读取引用的文件，直接忽略
+//
+// 使用默认分隔符 ; 的连续语句作为一段返回并保留分号，由各引擎按语法继续拆分，
+// 这样没有修改分隔符时 BEGIN ... END 程序体中的分号也不会被拆开。
+// 客户端命令只在语句开头识别，引号和注释中的内容不识别分隔符，只包含空白和注释的段被忽略
+func SplitClientScript(sql string) []string {
+	s := &clientScanner{sql: sql, delimiter: ";"}
+	for s.pos < len(sql) {
+		s.scan()
+	}
+	s.flush(len(sql), len(sql))
+	return s.pieces
+}
+
+// clientScanner 逐个字符扫描客户端脚本
+type clientScanner struct {
+	sql       string
+	delimiter string
+	pieces    []string
+	pos       int
+	start     int  // 当前段的起始位置
+	stmt      bool // 当前语句是否已有注释以外的内容
+	piece     bool // 当前段是否已有注释以外的内容
+}
+
+// scan 读取当前位置的一个字符、字符串、注释或客户端命令
+func (s *clientScanner) scan() {
+	sql, i := s.sql, s.pos
+	c := sql[i]
+	switch {
+	case !s.stmt && s.command():
+	case c == '\'' || c == '"' || c == '`':
+		s.content()
+		s.pos = skipQuoted(sql, i)
+	case c == '#' || isDashComment(sql, i):
+		s.pos = skipLine(sql, i)
+	case strings.HasPrefix(sql[i:], "/*!"):
+		// 可执行注释中的内容是语句的一部分
+		s.content()
+		s.pos = skipBlockComment(sql, i)
+	case strings.HasPrefix(sql[i:], "/*"):
+		s.pos = skipBlockComment(sql, i)
+	case strings.HasPrefix(sql[i:], `\G`) || strings.HasPrefix(sql[i:], `\g`):
+		s.flush(i, i+2)
+	case strings.HasPrefix(sql[i:], s.delimiter):
+		if s.delimiter != ";" {
+			s.flush(i, i+len(s.delimiter))
+			return
+		}
+		// 默认分隔符只结束语句，不结束当前段
+		s.stmt = false
+		s.pos++
+	default:
+		if !isSpace(c) {
+			s.content()
+		}
+		s.pos++
+	}
+}
+
+// command 识别语句开头的 DELIMITER 和 source 命令，命令占据到行尾
+func (s *clientScanner) command() bool {
+	rest := s.sql[s.pos:]
+	var arg string
+	var ok, isDelimiter bool
+	if arg, ok = commandArg(rest, "delimiter", `\d`); ok {
+		isDelimiter = true
+	} else if _, ok = commandArg(rest, "source", `\.`); !ok {
+		return false
+	}
+	end := skipLine(s.sql, s.pos)
+	s.flush(s.pos, end)
+	if isDelimiter && arg != "" {
+		s.delimiter = arg
+	}
+	return true
+}
+
+// commandArg 判断文本是否以指定的客户端命令开头，返回命令第一个参数，参数两侧的引号会去掉
+func commandArg(text, name, short string) (string, bool) {
+	var line string
+	switch {
+	case len(text) > len(name) && strings.EqualFold(text[:len(name)], name) && isSpace(text[len(name)]):
+		line = text[len(name):]
+	case strings.HasPrefix(text, short) && (len(text) == len(short) || isSpace(text[len(short)])):
+		line = text[len(short):]
+	default:
+		return "", false
+	}
+	if i := strings.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", true
+	}
+	arg := fields[0]
+	if len(arg) >= 2 && (arg[0] == '\'' || arg[0] == '"' || arg[0] == '`') && arg[len(arg)-1] == arg[0] {
+		arg = arg[1 : len(arg)-1]
+	}
+	return arg, true
+}
+
+// content 标记当前语句和当前段包含注释以外的内容
+func (s *clientScanner) content() {
+	s.stmt = true
+	s.piece = true
+}
+
+// flush 结束当前段，end 为当前段的结束位置，next 为下一段的起始位置
+func (s *clientScanner) flush(end, next int) {
+	if s.piece {
+		s.pieces = append(s.pieces, strings.TrimSpace(s.sql[s.start:end]))
+	}
+	s.start, s.pos = next, next
+	s.stmt, s.piece = false, false
+}
+
+// skipQuoted 跳过从 i 开始的字符串或反引号标识符，返回结束引号之后的位置，未闭合时返回文本长度
+func skipQuoted(sql string, i int) int {
+	if end, ok := quoteEnd(sql, i, sql[i] != '`'); ok {
+		return end
+	}
+	return len(sql)
+}
+
+// skipLine 跳过到行尾，返回换行符之后的位置
+func skipLine(sql string, i int) int {
+	if j := strings.IndexByte(sql[i:], '\n'); j >= 0 {
+		return i + j + 1
+	}
+	return len(sql)
+}
+
+// skipBlockComment 跳过从 i 开始的块注释，返回注释之后的位置
+func skipBlockComment(sql string, i int) int {
+	if end, ok := blockCommentEnd(sql, i, false); ok {
+		return end
+	}
+	return len(sql)
+}
+
+// isDashComment 判断 i 处是否为 -- 注释，MySQL 要求 -- 之后是空白或行尾
+func isDashComment(sql string, i int) bool {
+	if !strings.HasPrefix(sql[i:], "--") {
+		return false
+	}
+	return i+2 == len(sql) || isSpace(sql[i+2])
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
@@ -0,0 +1,80 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitClientScript(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		expected []string
+	}{
+		{
+			name:     "default delimiter keeps statements together",
+			sql:      "SELECT 1;\nSELECT 2;\n",
+			expected: []string{"SELECT 1;\nSELECT 2;"},
+		},
+		{
+			name: "custom delimiter is stripped",
+			sql: "DROP PROCEDURE IF EXISTS p;\n" +
+				"DELIMITER $$\n" +
+				"CREATE PROCEDURE p()\nBEGIN\n  INSERT INTO t SELECT * FROM s;\n  DELETE FROM s;\nEND$$\n" +
+				"DELIMITER ;\n" +
+				"CALL p();",
+			expected: []string{
+				"DROP PROCEDURE IF EXISTS p;",
+				"CREATE PROCEDURE p()\nBEGIN\n  INSERT INTO t SELECT * FROM s;\n  DELETE FROM s;\nEND",
+				"CALL p();",
+			},
+		},
+		{
+			name: "mysqldump trigger with executable comments",
+			sql: "/*!50003 SET @saved_sql_mode = @@sql_mode */ ;\n" +
+				"DELIMITER ;;\n" +
+				"/*!50003 CREATE*/ /*!50003 TRIGGER tr AFTER INSERT ON t FOR EACH ROW BEGIN INSERT INTO log VALUES (NEW.id); END */;;\n" +
+				"delimiter ;\n",
+			expected: []string{
+				"/*!50003 SET @saved_sql_mode = @@sql_mode */ ;",
+				"/*!50003 CREATE*/ /*!50003 TRIGGER tr AFTER INSERT ON t FOR EACH ROW BEGIN INSERT INTO log VALUES (NEW.id); END */",
+			},
+		},
+		{
+			name:     "delimiter in strings and comments is ignored",
+			sql:      "DELIMITER //\nSELECT '//', `a//b` -- //\nFROM t /* // */ //\nDELIMITER ;",
+			expected: []string{"SELECT '//', `a//b` -- //\nFROM t /* // */"},
+		},
+		{
+			name:     "semicolon in a string with a custom delimiter",
+			sql:      "DELIMITER //\nINSERT INTO t1 SELECT * FROM t2 WHERE c = ';'//\nDELIMITER ;\nSELECT * FROM t1\\G",
+			expected: []string{"INSERT INTO t1 SELECT * FROM t2 WHERE c = ';'", "SELECT * FROM t1"},
+		},
+		{
+			name:     "\\G ends a statement",
+			sql:      "SHOW CREATE TABLE t\\G\nSELECT * FROM t\\g",
+			expected: []string{"SHOW CREATE TABLE t", "SELECT * FROM t"},
+		},
+		{
+			name:     "source commands are skipped",
+			sql:      "SELECT 1;\nsource /tmp/a.sql\n\\. /tmp/b.sql\nSELECT 2;",
+			expected: []string{"SELECT 1;", "SELECT 2;"},
+		},
+		{
+			name:     "commands are only recognized at statement start",
+			sql:      "CREATE TABLE t (\ndelimiter INT\n);",
+			expected: []string{"CREATE TABLE t (\ndelimiter INT\n);"},
+		},
+		{
+			name:     "comment only script",
+			sql:      "-- header\n/* comment */\n",
+			expected: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, SplitClientScript(tt.sql))
+		})
+	}
+}
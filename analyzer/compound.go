@@ -0,0 +1,64 @@
+package analyzer
+
+import (
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// NewCompoundResult 创建没有读写表的结果，用于复合语句和存储过程体
+func NewCompoundResult(ctx antlr.ParserRuleContext, stmtType StmtType) *DependencyResult {
+	return &DependencyResult{
+		Stmt:     OriginalText(ctx),
+		StmtType: stmtType,
+		Read:     []*DependencyTable{},
+		Write:    []*DependencyTable{},
+	}
+}
+
+// AddChild 添加子语句并把子语句的读写表合并到父语句
+func AddChild(parent, child *DependencyResult) {
+	parent.Children = append(parent.Children, child)
+	MergeTables(parent, child)
+}
+
+// MergeTables 把 src 的读写表和函数合并到 dst，去掉重复的表
+func MergeTables(dst, src *DependencyResult) {
+	dst.Read = AppendTables(dst.Read, src.Read)
+	dst.Write = AppendTables(dst.Write, src.Write)
+	dst.ExternalRead = append(dst.ExternalRead, src.ExternalRead...)
+	dst.ExternalWrite = append(dst.ExternalWrite, src.ExternalWrite...)
+	for _, f := range src.FunctionRead {
+		dst.FunctionRead = AppendFunction(dst.FunctionRead, f)
+	}
+	dst.FunctionWrite = append(dst.FunctionWrite, src.FunctionWrite...)
+}
+
+// AppendTables 追加 src 中 dst 还没有的表，表名不区分大小写
+func AppendTables(dst, src []*DependencyTable) []*DependencyTable {
+	for _, t := range src {
+		exists := false
+		for _, d := range dst {
+			if strings.EqualFold(d.String(), t.String()) {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			dst = append(dst, t)
+		}
+	}
+	return dst
+}
+
+// OriginalText 获取语法节点对应的原始SQL文本，保留空格和大小写
+func OriginalText(ctx antlr.ParserRuleContext) string {
+	start, stop := ctx.GetStart(), ctx.GetStop()
+	if start == nil || stop == nil {
+		return ctx.GetText()
+	}
+	if stop.GetTokenIndex() < start.GetTokenIndex() {
+		return ""
+	}
+	return start.GetInputStream().GetTextFromInterval(antlr.NewInterval(start.GetStart(), stop.GetStop()))
+}
@@ -4,31 +4,162 @@ import "fmt"
 
 type (
 	DependencyAnalyzeReq struct {
-		DefaultCluster  string     `json:"defaultCluster"`
-		DefaultDatabase string     `json:"defaultDatabase"`
-		Type            EngineType `json:"type"`
-		SQL             string     `json:"sql"`
+		DefaultCluster  string            `json:"defaultCluster"`
+		DefaultDatabase string            `json:"defaultDatabase"`
+		Type            EngineType        `json:"type"`
+		SQL             string            `json:"sql"`
+		Catalog         Catalog           `json:"-"`                     // 可选，提供时用于补充表的元数据
+		ExpandViews     bool              `json:"expandViews,omitempty"` // 是否通过 Catalog 中的视图定义展开读取的视图
+		Variables       map[string]string `json:"variables,omitempty"`   // 脚本变量，用于替换 ${var}、{{ var }} 等占位符，仅 Hive 和 Spark 支持
 	}
 )
 
 type (
 	DependencyTable struct {
-		Cluster  string `json:"cluster"`
-		Database string `json:"database"`
-		Table    string `json:"table"`
+		Cluster    string           `json:"cluster"`
+		Database   string           `json:"database"`
+		Table      string           `json:"table"`
+		Temporary  bool             `json:"temporary,omitempty"`  // 是否为临时表或临时视图，仅对写表有意义
+		Kind       TableKind        `json:"kind,omitempty"`       // 表或视图，仅在提供 Catalog 时填充；字典由语句确定，总是填充
+		ViewPath   []string         `json:"viewPath,omitempty"`   // 展开视图得到的读表经过的视图路径，最后一项为该表本身
+		Location   string           `json:"location,omitempty"`   // 建表或修改表时显式指定的存储位置
+		AsOf       *TimeTravel      `json:"asOf,omitempty"`       // 时间旅行读取或恢复到的快照
+		Role       TableRole        `json:"role,omitempty"`       // 表在语句中的角色，仅在交换分区、重命名等涉及多张表的语句中填充
+		Partitions []*PartitionSpec `json:"partitions,omitempty"` // 写入、删除或读取的分区，为空表示整张表；读表的分区需要 Catalog 提供 PartitionKeys
+	}
+	// TimeTravel 表的版本或时间戳限定，例如 VERSION AS OF 12
+	TimeTravel struct {
+		Kind  TimeTravelKind `json:"kind"`
+		Value string         `json:"value"` // 版本号、分支或标签名、时间戳表达式
+	}
+	// DependencyExternal 表以外的外部数据依赖，例如直接读写的文件路径
+	DependencyExternal struct {
+		Type       ExternalType      `json:"type"`
+		Format     string            `json:"format,omitempty"` // 数据格式，例如 parquet、csv
+		Location   string            `json:"location"`
+		Properties map[string]string `json:"properties,omitempty"`
+		AsOf       *TimeTravel       `json:"asOf,omitempty"` // 时间旅行读取的快照，例如 delta.`/path` VERSION AS OF 1
+	}
+	// DependencyFunction 调用、创建或删除的函数
+	DependencyFunction struct {
+		Database  string                `json:"database,omitempty"` // 函数名中显式指定的数据库
+		Name      string                `json:"name"`
+		Builtin   bool                  `json:"builtin,omitempty"`   // 是否为引擎内置函数，仅对调用的函数有意义
+		Temporary bool                  `json:"temporary,omitempty"` // 是否为临时函数，仅对创建和删除的函数有意义
+		Procedure bool                  `json:"procedure,omitempty"` // 是否为存储过程，调用、创建和删除存储过程时为true
+		ClassName string                `json:"className,omitempty"` // 创建函数时指定的实现类或符号
+		Resources []*DependencyExternal `json:"resources,omitempty"` // 创建函数时引用的JAR、文件等资源
 	}
 	DependencyResult struct {
-		Stmt     string             `json:"stmt"`
-		StmtType StmtType           `json:"stmtType"`
-		Read     []*DependencyTable `json:"read"`
-		Write    []*DependencyTable `json:"write"`
+		Stmt          string                `json:"stmt"`
+		StmtType      StmtType              `json:"stmtType"`
+		Read          []*DependencyTable    `json:"read"`
+		Write         []*DependencyTable    `json:"write"`
+		ExternalRead  []*DependencyExternal `json:"externalRead,omitempty"`
+		ExternalWrite []*DependencyExternal `json:"externalWrite,omitempty"`
+		FunctionRead  []*DependencyFunction `json:"functionRead,omitempty"`  // 调用的函数，按名称去重
+		FunctionWrite []*DependencyFunction `json:"functionWrite,omitempty"` // 创建或删除的函数
+		Children      []*DependencyResult   `json:"children,omitempty"`      // 复合语句内的子语句，父语句的读写表包含所有子语句的读写表
+		Schedule      *Schedule             `json:"schedule,omitempty"`      // 定时执行的语句的调度信息
+		Label         string                `json:"label,omitempty"`         // 导入导出作业的标签或名称
+
+		ExternalCatalog *ExternalCatalog `json:"externalCatalog,omitempty"` // 创建、修改、删除或切换到的外部数据目录
+
+		MaterializedView *MaterializedView `json:"materializedView,omitempty"` // 创建或修改物化视图时指定的刷新方式和分区表达式
+
+		Trigger *Trigger `json:"trigger,omitempty"` // 创建或删除的触发器
+
+		Span *StatementSpan `json:"span,omitempty"` // 语句在脚本中的位置，只有 Analyze 拆分出的语句填充
+	}
+	// Trigger 触发器及其触发时机，创建触发器时 Table 为触发的表，同时是语句的读表
+	Trigger struct {
+		Name   string           `json:"name"`
+		Timing string           `json:"timing,omitempty"` // BEFORE 或 AFTER
+		Event  string           `json:"event,omitempty"`  // INSERT、UPDATE 或 DELETE
+		Table  *DependencyTable `json:"table,omitempty"`
+	}
+	// ExternalCatalog 外部数据目录，目录中的表以目录名作为 Cluster
+	ExternalCatalog struct {
+		Name       string            `json:"name"`
+		Type       string            `json:"type,omitempty"`       // 目录类型，例如 hive、iceberg、hudi、jdbc
+		Properties map[string]string `json:"properties,omitempty"` // 目录属性，不包括密码、密钥等认证信息
+	}
+	// MaterializedView 物化视图的刷新方式和分区表达式，语句中未指定的项为空
+	MaterializedView struct {
+		RefreshMode   RefreshMode `json:"refreshMode,omitempty"`
+		RefreshMoment string      `json:"refreshMoment,omitempty"` // 创建后立即刷新或延迟刷新，IMMEDIATE 或 DEFERRED
+		RefreshStart  string      `json:"refreshStart,omitempty"`  // 定时刷新的起始时间
+		RefreshEvery  string      `json:"refreshEvery,omitempty"`  // 定时刷新的间隔，例如 1 DAY
+		PartitionBy   []string    `json:"partitionBy,omitempty"`   // 分区表达式，例如 date_trunc('day', dt)
+	}
+	// Schedule 定时执行的任务及其调度信息，例如 Hive 的 SCHEDULED QUERY、StarRocks 的 TASK、MySQL 的 EVENT
+	Schedule struct {
+		Name       string `json:"name"`
+		Cron       string `json:"cron,omitempty"`       // CRON 表达式
+		Every      string `json:"every,omitempty"`      // 固定的执行间隔，例如 2 HOUR
+		Offset     string `json:"offset,omitempty"`     // 按间隔执行的起始时间
+		Ends       string `json:"ends,omitempty"`       // 按间隔执行的结束时间
+		At         string `json:"at,omitempty"`         // 只执行一次的任务的执行时间
+		ExecutedAs string `json:"executedAs,omitempty"` // 执行任务的用户
+		Enabled    *bool  `json:"enabled,omitempty"`    // 是否启用，语句中未指定时为nil
 	}
 )
 
+type ExternalType string
+
+const (
+	ExternalTypePath       ExternalType = "PATH"       // 文件或目录路径
+	ExternalTypeJar        ExternalType = "JAR"        // 函数依赖的JAR包
+	ExternalTypeFile       ExternalType = "FILE"       // ADD FILE 等添加的资源文件
+	ExternalTypeArchive    ExternalType = "ARCHIVE"    // ADD ARCHIVE 等添加的压缩包
+	ExternalTypeKafka      ExternalType = "KAFKA"      // Kafka 主题，Location 为主题名
+	ExternalTypeRepository ExternalType = "REPOSITORY" // 备份仓库，Location 为仓库名
+)
+
+// TableRole 表在语句中的角色
+type TableRole string
+
+const (
+	TableRoleSource     TableRole = "SOURCE"      // 数据来源表，例如交换分区时分区被移出的表
+	TableRoleTarget     TableRole = "TARGET"      // 数据目标表，例如交换分区时分区被移入的表
+	TableRoleRenameFrom TableRole = "RENAME_FROM" // 重命名前的表名
+	TableRoleRenameTo   TableRole = "RENAME_TO"   // 重命名后的表名
+)
+
+// RefreshMode 物化视图的刷新方式
+type RefreshMode string
+
+const (
+	RefreshModeAsync       RefreshMode = "ASYNC"       // 基表变化后或按间隔定时异步刷新
+	RefreshModeManual      RefreshMode = "MANUAL"      // 只在执行 REFRESH 语句时刷新
+	RefreshModeIncremental RefreshMode = "INCREMENTAL" // 导入基表时同步增量刷新
+)
+
+type TimeTravelKind string
+
+const (
+	TimeTravelVersion   TimeTravelKind = "VERSION"
+	TimeTravelTimestamp TimeTravelKind = "TIMESTAMP"
+)
+
 func (d *DependencyTable) String() string {
 	return fmt.Sprintf("%s.%s.%s", d.Cluster, d.Database, d.Table)
 }
 
+func (d *DependencyFunction) String() string {
+	if d.Database == "" {
+		return d.Name
+	}
+	return d.Database + "." + d.Name
+}
+
+func (d *DependencyExternal) String() string {
+	if d.Format == "" {
+		return d.Location
+	}
+	return fmt.Sprintf("%s.`%s`", d.Format, d.Location)
+}
+
 type DependencyAnalyzer interface {
 	// Analyze 分析SQL读写表和语句类型 StmtType
 	Analyze(req *DependencyAnalyzeReq) ([]*DependencyResult, error)
@@ -0,0 +1,65 @@
+package analyzer
+
+import (
+	"regexp"
+	"strings"
+)
+
+// addResourcePattern 形如 ADD JAR path [path ...] 的资源命令
+var addResourcePattern = regexp.MustCompile(`(?is)^\s*ADD\s+(JARS?|FILES?|ARCHIVES?)\s+(.+?)\s*;?\s*$`)
+
+// NewNameSet 根据空白分隔的名称构建小写的名称集合，用于各方言的内置函数列表
+func NewNameSet(names string) map[string]bool {
+	set := make(map[string]bool)
+	for _, name := range strings.Fields(names) {
+		set[strings.ToLower(name)] = true
+	}
+	return set
+}
+
+// NewFunction 根据函数名的各部分创建调用的函数，只有不带数据库的函数才可能是内置函数
+func NewFunction(parts []string, builtins map[string]bool) *DependencyFunction {
+	f := &DependencyFunction{Name: parts[len(parts)-1]}
+	if len(parts) > 1 {
+		f.Database = strings.Join(parts[:len(parts)-1], ".")
+	} else {
+		f.Builtin = builtins[strings.ToLower(f.Name)]
+	}
+	return f
+}
+
+// AppendFunction 添加调用的函数，函数名不区分大小写去重
+func AppendFunction(functions []*DependencyFunction, f *DependencyFunction) []*DependencyFunction {
+	for _, existing := range functions {
+		if strings.EqualFold(existing.String(), f.String()) {
+			return functions
+		}
+	}
+	return append(functions, f)
+}
+
+// ParseAddResource 解析 ADD JAR/FILE/ARCHIVE 资源命令，多个路径以空白分隔
+func ParseAddResource(stmt string) ([]*DependencyExternal, bool) {
+	m := addResourcePattern.FindStringSubmatch(stmt)
+	if m == nil {
+		return nil, false
+	}
+	resourceType := ResourceType(m[1])
+	var result []*DependencyExternal
+	for _, path := range strings.Fields(m[2]) {
+		result = append(result, &DependencyExternal{Type: resourceType, Location: strings.Trim(path, `'"`)})
+	}
+	return result, len(result) > 0
+}
+
+// ResourceType 根据 JAR、FILE、ARCHIVE 关键字获取资源类型，不区分大小写和单复数
+func ResourceType(keyword string) ExternalType {
+	switch strings.TrimSuffix(strings.ToUpper(keyword), "S") {
+	case "JAR":
+		return ExternalTypeJar
+	case "ARCHIVE":
+		return ExternalTypeArchive
+	default:
+		return ExternalTypeFile
+	}
+}
@@ -0,0 +1,52 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAddResource(t *testing.T) {
+	tests := []struct {
+		name     string
+		stmt     string
+		expected []*DependencyExternal
+	}{
+		{
+			name:     "single jar",
+			stmt:     "ADD JAR hdfs:///libs/udf.jar;",
+			expected: []*DependencyExternal{{Type: ExternalTypeJar, Location: "hdfs:///libs/udf.jar"}},
+		},
+		{
+			name: "multiple quoted files",
+			stmt: "add files '/conf/a.yaml' \"/conf/b.yaml\"",
+			expected: []*DependencyExternal{
+				{Type: ExternalTypeFile, Location: "/conf/a.yaml"},
+				{Type: ExternalTypeFile, Location: "/conf/b.yaml"},
+			},
+		},
+		{
+			name:     "archive",
+			stmt:     "ADD ARCHIVE /libs/env.tar.gz",
+			expected: []*DependencyExternal{{Type: ExternalTypeArchive, Location: "/libs/env.tar.gz"}},
+		},
+		{
+			name: "not a resource command",
+			stmt: "ALTER TABLE t ADD COLUMNS (a INT)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resources, ok := ParseAddResource(tt.stmt)
+			assert.Equal(t, tt.expected != nil, ok)
+			assert.Equal(t, tt.expected, resources)
+		})
+	}
+}
+
+func TestNewFunction(t *testing.T) {
+	builtins := NewNameSet("upper COUNT")
+	assert.Equal(t, &DependencyFunction{Name: "Upper", Builtin: true}, NewFunction([]string{"Upper"}, builtins))
+	assert.Equal(t, &DependencyFunction{Database: "udfs", Name: "upper"}, NewFunction([]string{"udfs", "upper"}, builtins))
+	assert.Equal(t, &DependencyFunction{Name: "my_udf"}, NewFunction([]string{"my_udf"}, builtins))
+}
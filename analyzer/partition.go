@@ -0,0 +1,309 @@
+package analyzer
+
+import (
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+)
+
+type (
+	// PartitionSpec 一个分区限定，例如 PARTITION (dt='2024-01-01', hr) 或 StarRocks 的 PARTITION p20240101
+	PartitionSpec struct {
+		Name   string            `json:"name,omitempty"`   // 按名称指定的分区
+		Values []*PartitionValue `json:"values,omitempty"` // 按分区键指定的分区
+	}
+	// PartitionValue 分区键及其取值
+	PartitionValue struct {
+		Key     string `json:"key"`
+		Value   string `json:"value,omitempty"`   // 静态分区的值
+		Dynamic bool   `json:"dynamic,omitempty"` // 是否为动态分区，值由写入的数据决定
+	}
+	// ColumnFilter WHERE 中列的字面量条件，列的取值为 Values 之一
+	ColumnFilter struct {
+		Qualifier string // 列名前的表名或别名
+		Column    string
+		Values    []string
+	}
+)
+
+// String 以 Hive 分区路径的形式返回分区，动态分区只有键，例如 dt=2024-01-01/hr
+func (s *PartitionSpec) String() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	parts := make([]string, 0, len(s.Values))
+	for _, v := range s.Values {
+		if v.Dynamic {
+			parts = append(parts, v.Key)
+		} else {
+			parts = append(parts, v.Key+"="+v.Value)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// ContextTokens 返回语法树节点在默认通道上的token
+func ContextTokens(stream antlr.TokenStream, ctx antlr.ParserRuleContext) []antlr.Token {
+	var tokens []antlr.Token
+	if ctx == nil || ctx.GetStart() == nil || ctx.GetStop() == nil {
+		return tokens
+	}
+	for i := ctx.GetStart().GetTokenIndex(); i <= ctx.GetStop().GetTokenIndex(); i++ {
+		if token := stream.Get(i); token.GetChannel() == antlr.TokenDefaultChannel {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// ParseFilters 从 WHERE 条件中提取顶层 AND 连接的 col = 字面量 和 col IN (字面量, ...) 条件，
+// 顶层出现 OR 时条件无法限定取值，返回nil
+func ParseFilters(tokens []antlr.Token) []*ColumnFilter {
+	texts := make([]string, len(tokens))
+	for i, token := range tokens {
+		texts[i] = token.GetText()
+	}
+	filters, _ := parseFilters(texts)
+	return filters
+}
+
+func parseFilters(tokens []string) ([]*ColumnFilter, bool) {
+	tokens = trimParens(tokens)
+	var conjuncts [][]string
+	depth, start, between := 0, 0, false
+	for i, token := range tokens {
+		switch strings.ToUpper(token) {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		case "BETWEEN":
+			between = depth == 0
+		case "OR", "||":
+			if depth == 0 {
+				return nil, false
+			}
+		case "AND", "&&":
+			if depth != 0 {
+				continue
+			}
+			if between {
+				// BETWEEN ... AND ... 中的 AND 不是连接词
+				between = false
+				continue
+			}
+			conjuncts = append(conjuncts, tokens[start:i])
+			start = i + 1
+		}
+	}
+	conjuncts = append(conjuncts, tokens[start:])
+
+	var filters []*ColumnFilter
+	for _, conjunct := range conjuncts {
+		if inner := trimParens(conjunct); len(inner) != len(conjunct) {
+			// 括号内的条件整体成立，其中 AND 连接的条件同样成立
+			if nested, ok := parseFilters(inner); ok {
+				for _, f := range nested {
+					filters = mergeFilter(filters, f)
+				}
+			}
+			continue
+		}
+		if f := parseFilter(conjunct); f != nil {
+			filters = mergeFilter(filters, f)
+		}
+	}
+	return filters, true
+}
+
+// parseFilter 识别单个条件，不是字面量条件时返回nil
+func parseFilter(tokens []string) *ColumnFilter {
+	// col = 'v' 或 'v' = col
+	for i, token := range tokens {
+		if token != "=" && token != "==" {
+			continue
+		}
+		if f := columnRef(tokens[:i]); f != nil {
+			if v, ok := literal(tokens[i+1:]); ok {
+				f.Values = []string{v}
+				return f
+			}
+		}
+		if f := columnRef(tokens[i+1:]); f != nil {
+			if v, ok := literal(tokens[:i]); ok {
+				f.Values = []string{v}
+				return f
+			}
+		}
+		return nil
+	}
+	// col IN ('a', 'b')
+	for i, token := range tokens {
+		if !strings.EqualFold(token, "IN") {
+			continue
+		}
+		f := columnRef(tokens[:i])
+		list := tokens[i+1:]
+		if f == nil || len(list) < 3 || list[0] != "(" || list[len(list)-1] != ")" {
+			return nil
+		}
+		start := 1
+		for j := 1; j < len(list); j++ {
+			if list[j] != "," && j != len(list)-1 {
+				continue
+			}
+			v, ok := literal(list[start:j])
+			if !ok {
+				return nil
+			}
+			f.Values = append(f.Values, v)
+			start = j + 1
+		}
+		return f
+	}
+	return nil
+}
+
+// columnRef 识别 col、t.col 形式的列引用
+func columnRef(tokens []string) *ColumnFilter {
+	if len(tokens) == 0 || len(tokens)%2 == 0 {
+		return nil
+	}
+	parts := make([]string, 0, len(tokens)/2+1)
+	for i, token := range tokens {
+		if i%2 == 1 {
+			if token != "." {
+				return nil
+			}
+			continue
+		}
+		if !isIdentifier(token) {
+			return nil
+		}
+		parts = append(parts, strings.Trim(token, "`"))
+	}
+	return &ColumnFilter{
+		Qualifier: strings.Join(parts[:len(parts)-1], "."),
+		Column:    parts[len(parts)-1],
+	}
+}
+
+// literal 识别字符串或数字字面量，支持 DATE '2024-01-01' 等带类型的字面量
+func literal(tokens []string) (string, bool) {
+	if len(tokens) == 2 && (strings.EqualFold(tokens[0], "DATE") || strings.EqualFold(tokens[0], "TIMESTAMP")) {
+		tokens = tokens[1:]
+	}
+	if len(tokens) != 1 || tokens[0] == "" {
+		return "", false
+	}
+	token := tokens[0]
+	switch {
+	case len(token) >= 2 && (token[0] == '\'' || token[0] == '"') && token[len(token)-1] == token[0]:
+		return token[1 : len(token)-1], true
+	case token[0] >= '0' && token[0] <= '9':
+		return token, true
+	}
+	return "", false
+}
+
+func isIdentifier(token string) bool {
+	if strings.HasPrefix(token, "`") {
+		return len(token) >= 2 && strings.HasSuffix(token, "`")
+	}
+	for i, r := range token {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return token != ""
+}
+
+// trimParens 去掉包围整个条件的括号
+func trimParens(tokens []string) []string {
+	for len(tokens) >= 2 && tokens[0] == "(" && tokens[len(tokens)-1] == ")" {
+		depth := 0
+		for i, token := range tokens {
+			if token == "(" {
+				depth++
+			} else if token == ")" {
+				depth--
+			}
+			if depth == 0 && i != len(tokens)-1 {
+				// 第一个括号在结尾之前闭合，例如 (a) = (b)
+				return tokens
+			}
+		}
+		tokens = tokens[1 : len(tokens)-1]
+	}
+	return tokens
+}
+
+// mergeFilter 合并同一列的条件，取值取交集
+func mergeFilter(filters []*ColumnFilter, f *ColumnFilter) []*ColumnFilter {
+	for _, existing := range filters {
+		if !strings.EqualFold(existing.Qualifier, f.Qualifier) || !strings.EqualFold(existing.Column, f.Column) {
+			continue
+		}
+		var values []string
+		for _, v := range existing.Values {
+			for _, other := range f.Values {
+				if v == other {
+					values = append(values, v)
+					break
+				}
+			}
+		}
+		existing.Values = values
+		return filters
+	}
+	return append(filters, f)
+}
+
+// matches 判断条件是否作用于指定的表，没有限定表名的条件作用于所有表
+func (f *ColumnFilter) matches(t *DependencyTable, alias string) bool {
+	if f.Qualifier == "" {
+		return true
+	}
+	if alias != "" {
+		return strings.EqualFold(f.Qualifier, alias)
+	}
+	return strings.EqualFold(f.Qualifier, t.Table) || strings.EqualFold(f.Qualifier, t.Database+"."+t.Table)
+}
+
+// ApplyFilters 根据 Catalog 中表的分区键，将读表的字面量条件转换为读取的分区，
+// alias 为表在查询中的别名，条件没有限定任何分区键或查找表失败时不填充分区
+func ApplyFilters(catalog Catalog, t *DependencyTable, alias string, filters []*ColumnFilter) {
+	if catalog == nil || len(filters) == 0 || len(t.Partitions) > 0 {
+		return
+	}
+	ct, err := catalog.LookupTable(t.Cluster, t.Database, t.Table)
+	if err != nil || ct == nil {
+		return
+	}
+	specs := []*PartitionSpec{{}}
+	for _, key := range ct.PartitionKeys {
+		var values []string
+		for _, f := range filters {
+			if strings.EqualFold(f.Column, key) && f.matches(t, alias) {
+				values = f.Values
+				break
+			}
+		}
+		if values == nil {
+			continue
+		}
+		// 多个分区键的取值组合成多个分区
+		next := make([]*PartitionSpec, 0, len(specs)*len(values))
+		for _, spec := range specs {
+			for _, v := range values {
+				combined := append(append([]*PartitionValue(nil), spec.Values...), &PartitionValue{Key: key, Value: v})
+				next = append(next, &PartitionSpec{Values: combined})
+			}
+		}
+		specs = next
+	}
+	if len(specs) == 1 && len(specs[0].Values) == 0 {
+		return
+	}
+	t.Partitions = specs
+}
@@ -0,0 +1,102 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFilters(t *testing.T) {
+	tests := []struct {
+		name     string
+		tokens   string
+		expected []*ColumnFilter
+	}{
+		{
+			name:   "equality and IN",
+			tokens: "a . dt = '2024-01-01' AND hr IN ( 1 , 2 ) AND status = 'paid'",
+			expected: []*ColumnFilter{
+				{Qualifier: "a", Column: "dt", Values: []string{"2024-01-01"}},
+				{Column: "hr", Values: []string{"1", "2"}},
+				{Column: "status", Values: []string{"paid"}},
+			},
+		},
+		{
+			name:   "literal on the left, typed literal and BETWEEN",
+			tokens: "'2024-01-01' = `dt` and x between 1 and 2 and ds = DATE '2024-01-02'",
+			expected: []*ColumnFilter{
+				{Column: "dt", Values: []string{"2024-01-01"}},
+				{Column: "ds", Values: []string{"2024-01-02"}},
+			},
+		},
+		{
+			name:   "parenthesized conjunction and repeated column",
+			tokens: "( dt IN ( 'a' , 'b' ) AND ( x > 1 ) ) AND dt = 'b'",
+			expected: []*ColumnFilter{
+				{Column: "dt", Values: []string{"b"}},
+			},
+		},
+		{
+			name:   "top level OR",
+			tokens: "dt = 'a' OR dt = 'b'",
+		},
+		{
+			name:   "non literal comparisons",
+			tokens: "dt = other . dt AND hr = f ( 1 ) AND ( dt = 'a' OR dt = 'b' )",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filters, _ := parseFilters(strings.Fields(tt.tokens))
+			assert.Equal(t, tt.expected, filters)
+		})
+	}
+}
+
+func TestApplyFilters(t *testing.T) {
+	catalog := NewMemoryCatalog(&CatalogTable{Database: "ods", Table: "events", PartitionKeys: []string{"dt", "hr"}})
+	filters := []*ColumnFilter{
+		{Qualifier: "e", Column: "HR", Values: []string{"1", "2"}},
+		{Column: "dt", Values: []string{"2024-01-01"}},
+		{Qualifier: "other", Column: "dt", Values: []string{"2024-01-02"}},
+	}
+
+	table := &DependencyTable{Cluster: "c", Database: "ods", Table: "events"}
+	ApplyFilters(catalog, table, "e", filters)
+	if assert.Len(t, table.Partitions, 2) {
+		assert.Equal(t, "dt=2024-01-01/hr=1", table.Partitions[0].String())
+		assert.Equal(t, "dt=2024-01-01/hr=2", table.Partitions[1].String())
+	}
+
+	// 限定了其他别名的条件不作用于该表
+	table = &DependencyTable{Cluster: "c", Database: "ods", Table: "events"}
+	ApplyFilters(catalog, table, "x", filters[:1])
+	assert.Nil(t, table.Partitions)
+
+	// 不在 Catalog 中的表不填充分区
+	table = &DependencyTable{Cluster: "c", Database: "ods", Table: "unknown"}
+	ApplyFilters(catalog, table, "", filters)
+	assert.Nil(t, table.Partitions)
+}
+
+func TestApplyFilters_SchemaModel(t *testing.T) {
+	// 重放DDL得到的分区名不是分区键
+	m := NewSchemaModel()
+	m.Apply(&DDLEvent{
+		Op:            DDLOpCreateTable,
+		Table:         &DependencyTable{Database: "ods", Table: "orders"},
+		PartitionKeys: []string{"dt"},
+		Partitions:    []string{"p20240101"},
+	})
+	m.Apply(&DDLEvent{Op: DDLOpCreateLike, Table: &DependencyTable{Database: "ods", Table: "orders_bak"}, Source: &DependencyTable{Database: "ods", Table: "orders"}})
+	filters := []*ColumnFilter{{Column: "dt", Values: []string{"2024-01-01"}}}
+
+	for _, name := range []string{"orders", "orders_bak"} {
+		table := &DependencyTable{Database: "ods", Table: name}
+		ApplyFilters(m, table, "", filters)
+		if assert.Len(t, table.Partitions, 1) {
+			assert.Equal(t, "dt=2024-01-01", table.Partitions[0].String())
+		}
+	}
+}
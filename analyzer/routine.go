@@ -0,0 +1,118 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RoutineCatalog 提供存储过程定义的 Catalog，Catalog 实现了该接口时，
+// 调用的存储过程可以展开为其定义中的读写表
+type RoutineCatalog interface {
+	// GetRoutineDefinition 获取存储过程的 CREATE PROCEDURE 语句，不存在时返回空字符串
+	GetRoutineDefinition(cluster, database, name string) (string, error)
+}
+
+// ResolveCalls 将调用存储过程的语句展开为过程体的读写表，复合语句中的调用同样展开，
+// 展开后的读写表合并到父语句。过程的定义取自同一批语句中之前的 CREATE PROCEDURE，
+// 找不到时取自实现了 RoutineCatalog 的 Catalog，找不到定义的调用保持不变
+func ResolveCalls(a DependencyAnalyzer, req *DependencyAnalyzeReq, results []*DependencyResult) error {
+	r := &callResolver{
+		analyzer:  a,
+		catalog:   req.Catalog,
+		defined:   make(map[string]*DependencyResult),
+		resolving: make(map[string]bool),
+	}
+	for _, result := range results {
+		if err := r.resolve(result, req.DefaultCluster, req.DefaultDatabase); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// callResolver 记录已知的存储过程定义
+type callResolver struct {
+	analyzer  DependencyAnalyzer
+	catalog   Catalog
+	defined   map[string]*DependencyResult // 存储过程到其 CREATE PROCEDURE 分析结果
+	resolving map[string]bool              // 正在从 Catalog 展开的存储过程，用于避免循环调用
+}
+
+// resolve 展开语句及其子语句中的调用，未限定库名的存储过程属于 database
+func (r *callResolver) resolve(result *DependencyResult, cluster, database string) error {
+	for _, child := range result.Children {
+		if err := r.resolve(child, cluster, database); err != nil {
+			return err
+		}
+		result.Read = AppendTables(result.Read, child.Read)
+		result.Write = AppendTables(result.Write, child.Write)
+	}
+	switch result.StmtType {
+	case StmtTypeCreateProcedure:
+		for _, f := range result.FunctionWrite {
+			if f.Procedure {
+				r.defined[routineKey(cluster, database, f)] = result
+			}
+		}
+	case StmtTypeCall:
+		for _, f := range result.FunctionRead {
+			if !f.Procedure {
+				continue
+			}
+			def, err := r.definition(cluster, database, f)
+			if err != nil {
+				return err
+			}
+			if def != nil {
+				result.Read = AppendTables(result.Read, def.Read)
+				result.Write = AppendTables(result.Write, def.Write)
+			}
+		}
+	}
+	return nil
+}
+
+// definition 查找存储过程的定义，找不到时返回nil
+func (r *callResolver) definition(cluster, database string, f *DependencyFunction) (*DependencyResult, error) {
+	key := routineKey(cluster, database, f)
+	if def, ok := r.defined[key]; ok {
+		return def, nil
+	}
+	catalog, ok := r.catalog.(RoutineCatalog)
+	if !ok || r.resolving[key] {
+		return nil, nil
+	}
+	if f.Database != "" {
+		database = f.Database
+	}
+	sql, err := catalog.GetRoutineDefinition(cluster, database, f.Name)
+	if err != nil || sql == "" {
+		return nil, err
+	}
+
+	// 定义中未限定库名的表和存储过程相对于存储过程所在的库解析
+	r.resolving[key] = true
+	defer delete(r.resolving, key)
+	results, err := r.analyzer.Analyze(&DependencyAnalyzeReq{
+		DefaultCluster:  cluster,
+		DefaultDatabase: database,
+		SQL:             sql,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("procedure %s: %w", f, err)
+	}
+	for _, result := range results {
+		if err := r.resolve(result, cluster, database); err != nil {
+			return nil, err
+		}
+	}
+	return r.defined[key], nil
+}
+
+// routineKey 存储过程的唯一标识，不区分大小写
+func routineKey(cluster, database string, f *DependencyFunction) string {
+	if f.Database != "" {
+		database = f.Database
+	}
+	return strings.ToLower(cluster + "." + database + "." + f.Name)
+}
@@ -0,0 +1,280 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type DDLOpType string
+
+const (
+	DDLOpCreateTable    DDLOpType = "CREATE_TABLE"
+	DDLOpCreateLike     DDLOpType = "CREATE_LIKE"
+	DDLOpCreateView     DDLOpType = "CREATE_VIEW"
+	DDLOpDropTable      DDLOpType = "DROP_TABLE"
+	DDLOpDropView       DDLOpType = "DROP_VIEW"
+	DDLOpRenameTable    DDLOpType = "RENAME_TABLE"
+	DDLOpAddColumn      DDLOpType = "ADD_COLUMN"
+	DDLOpDropColumn     DDLOpType = "DROP_COLUMN"
+	DDLOpModifyColumn   DDLOpType = "MODIFY_COLUMN"
+	DDLOpRenameColumn   DDLOpType = "RENAME_COLUMN"
+	DDLOpAddPartition   DDLOpType = "ADD_PARTITION"
+	DDLOpDropPartition  DDLOpType = "DROP_PARTITION"
+	DDLOpReplaceColumns DDLOpType = "REPLACE_COLUMNS"
+)
+
+type (
+	// DDLEvent 从DDL语句中提取出的一次结构变更
+	DDLEvent struct {
+		Op             DDLOpType        `json:"op"`
+		Stmt           string           `json:"stmt"`
+		Table          *DependencyTable `json:"table"`
+		Source         *DependencyTable `json:"source,omitempty"` // CREATE TABLE LIKE 的来源表
+		NewTable       *DependencyTable `json:"newTable,omitempty"`
+		Columns        []*CatalogColumn `json:"columns,omitempty"`
+		Column         string           `json:"column,omitempty"`        // 删除、修改、重命名的列
+		NewColumn      string           `json:"newColumn,omitempty"`     // 重命名后的列名
+		PartitionKeys  []string         `json:"partitionKeys,omitempty"` // 建表语句 PARTITION BY 的列
+		Partitions     []string         `json:"partitions,omitempty"`    // 建表、增加或删除的分区名
+		ViewDefinition string           `json:"viewDefinition,omitempty"`
+		IfExists       bool             `json:"ifExists,omitempty"`
+		IfNotExists    bool             `json:"ifNotExists,omitempty"`
+		OrReplace      bool             `json:"orReplace,omitempty"`
+	}
+	// SchemaConflict 重放DDL时遇到的冲突，例如修改不存在的表
+	SchemaConflict struct {
+		Stmt    string    `json:"stmt"`
+		Op      DDLOpType `json:"op"`
+		Table   string    `json:"table"`
+		Message string    `json:"message"`
+	}
+)
+
+// DDLExtractor 从SQL中提取结构变更，由各引擎实现
+type DDLExtractor interface {
+	// ExtractDDL 提取SQL中的结构变更，非DDL语句被忽略
+	ExtractDDL(sql, defaultCluster, defaultDatabase string) ([]*DDLEvent, error)
+}
+
+// SchemaModel 通过重放DDL构建的内存表结构模型，实现了 Catalog 接口
+type SchemaModel struct {
+	*MemoryCatalog
+	Conflicts []*SchemaConflict
+}
+
+// NewSchemaModel 创建一个新的 SchemaModel 实例
+func NewSchemaModel() *SchemaModel {
+	return &SchemaModel{
+		MemoryCatalog: NewMemoryCatalog(),
+		Conflicts:     []*SchemaConflict{},
+	}
+}
+
+// Replay 使用引擎的 DDLExtractor 提取脚本中的结构变更并依次应用
+func (m *SchemaModel) Replay(extractor DDLExtractor, req *DependencyAnalyzeReq) error {
+	events, err := extractor.ExtractDDL(req.SQL, req.DefaultCluster, req.DefaultDatabase)
+	if err != nil {
+		return err
+	}
+	for _, e := range events {
+		m.Apply(e)
+	}
+	return nil
+}
+
+// Apply 应用一次结构变更，无法应用时记录冲突。变更的表按集群精确匹配，
+// 指定了集群的DDL不会修改或删除没有指定集群的表
+func (m *SchemaModel) Apply(e *DDLEvent) {
+	t := m.lookupExact(e.Table.Cluster, e.Table.Database, e.Table.Table)
+	switch e.Op {
+	case DDLOpCreateTable, DDLOpCreateView:
+		kind := TableKindTable
+		if e.Op == DDLOpCreateView {
+			kind = TableKindView
+		}
+		if t != nil && !e.OrReplace {
+			if !e.IfNotExists {
+				m.conflict(e, "table already exists")
+			}
+			return
+		}
+		m.AddTable(&CatalogTable{
+			Cluster:        e.Table.Cluster,
+			Database:       e.Table.Database,
+			Table:          e.Table.Table,
+			Kind:           kind,
+			Columns:        copyColumns(e.Columns),
+			PartitionKeys:  append([]string(nil), e.PartitionKeys...),
+			Partitions:     append([]string(nil), e.Partitions...),
+			ViewDefinition: e.ViewDefinition,
+		})
+	case DDLOpCreateLike:
+		if t != nil {
+			if !e.IfNotExists {
+				m.conflict(e, "table already exists")
+			}
+			return
+		}
+		source, _ := m.LookupTable(e.Source.Cluster, e.Source.Database, e.Source.Table)
+		if source == nil {
+			m.conflict(e, fmt.Sprintf("source table %s not found", e.Source))
+			return
+		}
+		m.AddTable(&CatalogTable{
+			Cluster:       e.Table.Cluster,
+			Database:      e.Table.Database,
+			Table:         e.Table.Table,
+			Kind:          TableKindTable,
+			Columns:       copyColumns(source.Columns),
+			PartitionKeys: append([]string(nil), source.PartitionKeys...),
+		})
+	case DDLOpDropTable, DDLOpDropView:
+		if t == nil {
+			if !e.IfExists {
+				m.conflict(e, "table not found")
+			}
+			return
+		}
+		if e.Op == DDLOpDropView && t.Kind != TableKindView || e.Op == DDLOpDropTable && t.Kind == TableKindView {
+			m.conflict(e, fmt.Sprintf("%s is a %s", e.Table.Table, strings.ToLower(string(t.Kind))))
+			return
+		}
+		m.RemoveTable(t.Cluster, t.Database, t.Table)
+	case DDLOpRenameTable:
+		if t == nil {
+			m.conflict(e, "table not found")
+			return
+		}
+		if m.lookupExact(e.NewTable.Cluster, e.NewTable.Database, e.NewTable.Table) != nil {
+			m.conflict(e, fmt.Sprintf("table %s already exists", e.NewTable))
+			return
+		}
+		m.RemoveTable(t.Cluster, t.Database, t.Table)
+		t.Cluster, t.Database, t.Table = e.NewTable.Cluster, e.NewTable.Database, e.NewTable.Table
+		m.AddTable(t)
+	default:
+		if t == nil {
+			m.conflict(e, "table not found")
+			return
+		}
+		m.alterTable(t, e)
+	}
+}
+
+// alterTable 修改已存在表的列和分区
+func (m *SchemaModel) alterTable(t *CatalogTable, e *DDLEvent) {
+	switch e.Op {
+	case DDLOpAddColumn:
+		for _, c := range e.Columns {
+			if columnIndex(t, c.Name) >= 0 {
+				m.conflict(e, fmt.Sprintf("column %s already exists", c.Name))
+				continue
+			}
+			t.Columns = append(t.Columns, &CatalogColumn{Name: c.Name, Type: c.Type, Comment: c.Comment})
+		}
+	case DDLOpReplaceColumns:
+		t.Columns = copyColumns(e.Columns)
+	case DDLOpDropColumn:
+		i := columnIndex(t, e.Column)
+		if i < 0 {
+			if !e.IfExists {
+				m.conflict(e, fmt.Sprintf("column %s not found", e.Column))
+			}
+			return
+		}
+		t.Columns = append(t.Columns[:i], t.Columns[i+1:]...)
+	case DDLOpModifyColumn:
+		for _, c := range e.Columns {
+			// CHANGE COLUMN 可能同时重命名列
+			name := c.Name
+			if e.Column != "" {
+				name = e.Column
+			}
+			i := columnIndex(t, name)
+			if i < 0 {
+				m.conflict(e, fmt.Sprintf("column %s not found", name))
+				continue
+			}
+			t.Columns[i] = &CatalogColumn{Name: c.Name, Type: c.Type, Comment: c.Comment}
+		}
+	case DDLOpRenameColumn:
+		i := columnIndex(t, e.Column)
+		if i < 0 {
+			m.conflict(e, fmt.Sprintf("column %s not found", e.Column))
+			return
+		}
+		if columnIndex(t, e.NewColumn) >= 0 {
+			m.conflict(e, fmt.Sprintf("column %s already exists", e.NewColumn))
+			return
+		}
+		t.Columns[i].Name = e.NewColumn
+	case DDLOpAddPartition:
+		for _, p := range e.Partitions {
+			if partitionIndex(t, p) >= 0 {
+				if !e.IfNotExists {
+					m.conflict(e, fmt.Sprintf("partition %s already exists", p))
+				}
+				continue
+			}
+			t.Partitions = append(t.Partitions, p)
+		}
+	case DDLOpDropPartition:
+		for _, p := range e.Partitions {
+			i := partitionIndex(t, p)
+			if i < 0 {
+				if !e.IfExists {
+					m.conflict(e, fmt.Sprintf("partition %s not found", p))
+				}
+				continue
+			}
+			t.Partitions = append(t.Partitions[:i], t.Partitions[i+1:]...)
+		}
+	}
+}
+
+func (m *SchemaModel) conflict(e *DDLEvent, msg string) {
+	m.Conflicts = append(m.Conflicts, &SchemaConflict{
+		Stmt:    e.Stmt,
+		Op:      e.Op,
+		Table:   e.Table.String(),
+		Message: msg,
+	})
+}
+
+// MarshalJSON 导出表结构和冲突
+func (m *SchemaModel) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Tables    []*CatalogTable   `json:"tables"`
+		Conflicts []*SchemaConflict `json:"conflicts"`
+	}{
+		Tables:    m.Tables(),
+		Conflicts: m.Conflicts,
+	})
+}
+
+func columnIndex(t *CatalogTable, name string) int {
+	for i, c := range t.Columns {
+		if strings.EqualFold(c.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+func partitionIndex(t *CatalogTable, name string) int {
+	for i, p := range t.Partitions {
+		if strings.EqualFold(p, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+func copyColumns(columns []*CatalogColumn) []*CatalogColumn {
+	result := make([]*CatalogColumn, 0, len(columns))
+	for _, c := range columns {
+		result = append(result, &CatalogColumn{Name: c.Name, Type: c.Type, Comment: c.Comment})
+	}
+	return result
+}
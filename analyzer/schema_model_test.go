@@ -0,0 +1,130 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemaModel_Apply(t *testing.T) {
+	orders := &DependencyTable{Cluster: "c", Database: "db", Table: "orders"}
+	tests := []struct {
+		name      string
+		events    []*DDLEvent
+		columns   []string
+		conflicts []string
+	}{
+		{
+			name: "create and alter columns",
+			events: []*DDLEvent{
+				{Op: DDLOpCreateTable, Table: orders, Columns: []*CatalogColumn{{Name: "id", Type: "int"}, {Name: "amount", Type: "int"}}},
+				{Op: DDLOpAddColumn, Table: orders, Columns: []*CatalogColumn{{Name: "status", Type: "int"}}},
+				{Op: DDLOpModifyColumn, Table: orders, Column: "amount", Columns: []*CatalogColumn{{Name: "total", Type: "decimal(10,2)"}}},
+				{Op: DDLOpRenameColumn, Table: orders, Column: "status", NewColumn: "state"},
+				{Op: DDLOpDropColumn, Table: orders, Column: "id"},
+			},
+			columns: []string{"total", "state"},
+		},
+		{
+			name: "replace columns",
+			events: []*DDLEvent{
+				{Op: DDLOpCreateTable, Table: orders, Columns: []*CatalogColumn{{Name: "id", Type: "int"}}},
+				{Op: DDLOpReplaceColumns, Table: orders, Columns: []*CatalogColumn{{Name: "a", Type: "int"}, {Name: "b", Type: "int"}}},
+			},
+			columns: []string{"a", "b"},
+		},
+		{
+			name: "conflicts",
+			events: []*DDLEvent{
+				{Op: DDLOpAddColumn, Table: orders, Columns: []*CatalogColumn{{Name: "id", Type: "int"}}},
+				{Op: DDLOpCreateTable, Table: orders, Columns: []*CatalogColumn{{Name: "id", Type: "int"}}},
+				{Op: DDLOpCreateTable, Table: orders},
+				{Op: DDLOpCreateTable, Table: orders, IfNotExists: true},
+				{Op: DDLOpAddColumn, Table: orders, Columns: []*CatalogColumn{{Name: "ID", Type: "int"}}},
+				{Op: DDLOpDropColumn, Table: orders, Column: "missing"},
+				{Op: DDLOpDropPartition, Table: orders, Partitions: []string{"p1"}},
+				{Op: DDLOpDropView, Table: orders},
+			},
+			columns: []string{"id"},
+			conflicts: []string{
+				"table not found",
+				"table already exists",
+				"column ID already exists",
+				"column missing not found",
+				"partition p1 not found",
+				"orders is a table",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewSchemaModel()
+			for _, e := range tt.events {
+				m.Apply(e)
+			}
+			columns, err := m.ListColumns("c", "db", "orders")
+			assert.NoError(t, err)
+			var names []string
+			for _, c := range columns {
+				names = append(names, c.Name)
+			}
+			assert.Equal(t, tt.columns, names)
+			var conflicts []string
+			for _, c := range m.Conflicts {
+				conflicts = append(conflicts, c.Message)
+			}
+			assert.Equal(t, tt.conflicts, conflicts)
+		})
+	}
+}
+
+func TestSchemaModel_RenameAndDrop(t *testing.T) {
+	m := NewSchemaModel()
+	m.Apply(&DDLEvent{Op: DDLOpCreateTable, Table: &DependencyTable{Database: "db", Table: "t1"}, Partitions: []string{"p1"}})
+	m.Apply(&DDLEvent{Op: DDLOpAddPartition, Table: &DependencyTable{Database: "db", Table: "t1"}, Partitions: []string{"p2"}})
+	m.Apply(&DDLEvent{Op: DDLOpCreateLike, Table: &DependencyTable{Database: "db", Table: "t2"}, Source: &DependencyTable{Database: "db", Table: "t1"}})
+	m.Apply(&DDLEvent{Op: DDLOpRenameTable, Table: &DependencyTable{Database: "db", Table: "t1"}, NewTable: &DependencyTable{Database: "db", Table: "t3"}})
+	m.Apply(&DDLEvent{Op: DDLOpDropTable, Table: &DependencyTable{Database: "db", Table: "t2"}})
+	m.Apply(&DDLEvent{Op: DDLOpDropTable, Table: &DependencyTable{Database: "db", Table: "t2"}, IfExists: true})
+
+	data, err := json.Marshal(m)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"tables": [{"database": "db", "table": "t3", "kind": "TABLE", "partitions": ["p1", "p2"]}],
+		"conflicts": []
+	}`, string(data))
+}
+
+func TestSchemaModel_ApplyWithCluster(t *testing.T) {
+	m := NewSchemaModel()
+	create := func(cluster string, columns ...string) *DDLEvent {
+		e := &DDLEvent{Op: DDLOpCreateTable, OrReplace: true, Table: &DependencyTable{Cluster: cluster, Database: "db", Table: "t"}}
+		for _, c := range columns {
+			e.Columns = append(e.Columns, &CatalogColumn{Name: c, Type: "int"})
+		}
+		return e
+	}
+	// 同一条DDL分别不带集群和带集群重放，带集群的变更不会修改没有指定集群的表
+	m.Apply(create("", "id"))
+	m.Apply(create("c1", "id", "amount"))
+	m.Apply(create("c1", "id", "total"))
+	m.Apply(&DDLEvent{Op: DDLOpRenameTable, Table: &DependencyTable{Cluster: "c1", Database: "db", Table: "t"}, NewTable: &DependencyTable{Cluster: "c1", Database: "db", Table: "t2"}})
+	m.Apply(&DDLEvent{Op: DDLOpDropTable, Table: &DependencyTable{Cluster: "c2", Database: "db", Table: "t"}})
+
+	data, err := json.Marshal(m)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"tables": [
+			{"database": "db", "table": "t", "kind": "TABLE", "columns": [{"name": "id", "type": "int"}]},
+			{"cluster": "c1", "database": "db", "table": "t2", "kind": "TABLE", "columns": [{"name": "id", "type": "int"}, {"name": "total", "type": "int"}]}
+		],
+		"conflicts": [{"stmt": "", "op": "DROP_TABLE", "table": "c2.db.t", "message": "table not found"}]
+	}`, string(data))
+
+	// 查询时没有指定集群的表仍然匹配任意集群
+	ct, err := m.LookupTable("c2", "db", "t")
+	if assert.NoError(t, err) && assert.NotNil(t, ct) {
+		assert.Equal(t, "", ct.Cluster)
+	}
+}
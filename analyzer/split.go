@@ -2,6 +2,7 @@ package analyzer
 
 import (
 	"strings"
+	"unicode"
 
 	"github.com/antlr4-go/antlr/v4"
 )
@@ -31,3 +32,83 @@ func SplitSQL[T antlr.Lexer](lexer T) []string {
 
 	return result
 }
+
+// StatementSpan 拆分出的一条语句及其在脚本中的位置。
+// 语句前单独成行的注释属于该语句，与上一条语句结尾同一行的注释属于上一条语句
+type StatementSpan struct {
+	Start    int      `json:"start"`              // Raw 在脚本中的起始字节偏移，找不到语句时为 -1
+	End      int      `json:"end"`                // Raw 在脚本中的结束字节偏移，不包含该位置
+	Line     int      `json:"line"`               // Raw 起始位置的行号，从1开始
+	Column   int      `json:"column"`             // Raw 起始位置的列号，从0开始，按字符计
+	Raw      string   `json:"-"`                  // 语句原文，包含前导注释
+	Text     string   `json:"-"`                  // 去掉前导注释的语句，只有注释时为空
+	Comments []string `json:"comments,omitempty"` // 语句前的注释和语句结尾同一行的注释
+}
+
+// LocateSpans 计算按顺序拆分出的语句在脚本中的位置，语句必须是脚本的子串
+func LocateSpans(sql string, statements []string) []*StatementSpan {
+	spans := make([]*StatementSpan, len(statements))
+	cursor := 0
+	var prev *StatementSpan
+	for i, stmt := range statements {
+		span := &StatementSpan{Start: -1, End: -1, Raw: stmt, Text: stmt}
+		spans[i] = span
+		idx := strings.Index(sql[cursor:], stmt)
+		if idx < 0 {
+			continue
+		}
+		base := cursor + idx
+		start, text, end := -1, base, base+len(stmt)
+		cursor = end
+		for _, c := range leadingComments(stmt) {
+			comment := stmt[c[0]:c[1]]
+			if start < 0 && prev != nil && !strings.Contains(sql[prev.End:base+c[0]], "\n") {
+				// 与上一条语句结尾在同一行的注释属于上一条语句
+				prev.Comments = append(prev.Comments, comment)
+			} else {
+				span.Comments = append(span.Comments, comment)
+				if start < 0 {
+					start = base + c[0]
+				}
+			}
+			text = base + c[1]
+		}
+		for text < end && isSpace(sql[text]) {
+			text++
+		}
+		if start < 0 {
+			start = text
+		}
+		span.Start, span.End = start, end
+		span.Raw, span.Text = sql[start:end], sql[text:end]
+		span.Line, span.Column = positionOf(sql, start)
+		prev = span
+	}
+	return spans
+}
+
+// leadingComments 返回语句开头各个注释的范围，MySQL 的 /*! 可执行注释不是注释
+func leadingComments(stmt string) [][2]int {
+	var result [][2]int
+	i := 0
+	for {
+		for i < len(stmt) && isSpace(stmt[i]) {
+			i++
+		}
+		rest := stmt[i:]
+		var end int
+		switch {
+		case strings.HasPrefix(rest, "--") || strings.HasPrefix(rest, "#"):
+			end = len(rest)
+			if j := strings.IndexByte(rest, '\n'); j >= 0 {
+				end = j
+			}
+		case strings.HasPrefix(rest, "/*") && !strings.HasPrefix(rest, "/*!"):
+			end = skipBlockComment(rest, 0)
+		default:
+			return result
+		}
+		result = append(result, [2]int{i, i + len(strings.TrimRightFunc(rest[:end], unicode.IsSpace))})
+		i += end
+	}
+}
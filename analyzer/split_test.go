@@ -0,0 +1,60 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocateSpans(t *testing.T) {
+	sql := "SELECT 1; -- about select 1\n" +
+		"-- load orders\n/* daily */\n  INSERT INTO t SELECT * FROM s;\n" +
+		"/*!40101 SET NAMES utf8 */;\n" +
+		"-- trailing"
+	statements := []string{
+		"SELECT 1;",
+		"-- about select 1\n-- load orders\n/* daily */\n  INSERT INTO t SELECT * FROM s;",
+		"/*!40101 SET NAMES utf8 */;",
+		"-- trailing",
+		"SELECT 2",
+	}
+	spans := LocateSpans(sql, statements)
+	assert.Equal(t, []*StatementSpan{
+		{
+			Start: 0, End: 9, Line: 1, Column: 0,
+			Raw: "SELECT 1;", Text: "SELECT 1;",
+			Comments: []string{"-- about select 1"},
+		},
+		{
+			Start: 28, End: 87, Line: 2, Column: 0,
+			Raw:      "-- load orders\n/* daily */\n  INSERT INTO t SELECT * FROM s;",
+			Text:     "INSERT INTO t SELECT * FROM s;",
+			Comments: []string{"-- load orders", "/* daily */"},
+		},
+		{
+			Start: 88, End: 115, Line: 5, Column: 0,
+			Raw: "/*!40101 SET NAMES utf8 */;", Text: "/*!40101 SET NAMES utf8 */;",
+		},
+		{
+			Start: 116, End: 127, Line: 6, Column: 0,
+			Raw: "-- trailing", Text: "",
+			Comments: []string{"-- trailing"},
+		},
+		// 找不到的语句没有位置
+		{Start: -1, End: -1, Raw: "SELECT 2", Text: "SELECT 2"},
+	}, spans)
+}
+
+func TestTemplateMapSpan(t *testing.T) {
+	tpl := NewTemplate("SELECT '${dt}';\n  SELECT * FROM t WHERE dt = '${dt}';", map[string]string{"dt": "2024-01-01"})
+	spans := LocateSpans(tpl.SQL, []string{"SELECT '2024-01-01';", "SELECT * FROM t WHERE dt = '2024-01-01';"})
+	for _, span := range spans {
+		tpl.MapSpan(span)
+	}
+	assert.Equal(t, 0, spans[0].Start)
+	assert.Equal(t, 15, spans[0].End)
+	assert.Equal(t, 18, spans[1].Start)
+	assert.Equal(t, 53, spans[1].End)
+	assert.Equal(t, 2, spans[1].Line)
+	assert.Equal(t, 2, spans[1].Column)
+}
@@ -3,18 +3,64 @@ package analyzer
 type StmtType string
 
 const (
-	StmtTypeSelect       StmtType = "SELECT"
-	StmtTypeInsert       StmtType = "INSERT"
-	StmtTypeUpdate       StmtType = "UPDATE"
-	StmtTypeDelete       StmtType = "DELETE"
-	StmtTypeMerge        StmtType = "MERGE"
-	StmtTypeCreateTable  StmtType = "CREATE_TABLE"
-	StmtTypeCreateView   StmtType = "CREATE_VIEW"
-	StmtTypeAlterTable   StmtType = "ALTER_TABLE"
-	StmtTypeReplaceTable StmtType = "REPLACE_TABLE"
-	StmtTypeDropTable    StmtType = "DROP_TABLE"
-	StmtTypeCreateLike   StmtType = "CREATE_LIKE"
-	StmtTypeTruncate     StmtType = "TRUNCATE"
-	StmtTypeUseDatabase  StmtType = "USE_DATABASE"
-	StmtTypeUseCatalog   StmtType = "USE_CATALOG"
+	StmtTypeSelect         StmtType = "SELECT"
+	StmtTypeInsert         StmtType = "INSERT"
+	StmtTypeUpdate         StmtType = "UPDATE"
+	StmtTypeDelete         StmtType = "DELETE"
+	StmtTypeMerge          StmtType = "MERGE"
+	StmtTypeCreateTable    StmtType = "CREATE_TABLE"
+	StmtTypeCreateView     StmtType = "CREATE_VIEW"
+	StmtTypeAlterTable     StmtType = "ALTER_TABLE"
+	StmtTypeReplaceTable   StmtType = "REPLACE_TABLE"
+	StmtTypeDropTable      StmtType = "DROP_TABLE"
+	StmtTypeCreateLike     StmtType = "CREATE_LIKE"
+	StmtTypeTruncate       StmtType = "TRUNCATE"
+	StmtTypeUseDatabase    StmtType = "USE_DATABASE"
+	StmtTypeUseCatalog     StmtType = "USE_CATALOG"
+	StmtTypeCreateCatalog  StmtType = "CREATE_CATALOG" // 创建外部数据目录，例如 StarRocks 的 EXTERNAL CATALOG
+	StmtTypeAlterCatalog   StmtType = "ALTER_CATALOG"
+	StmtTypeDropCatalog    StmtType = "DROP_CATALOG"
+	StmtTypeCompound       StmtType = "COMPOUND"     // BEGIN ... END 复合语句块
+	StmtTypeControlFlow    StmtType = "CONTROL_FLOW" // IF、CASE、WHILE、REPEAT、LOOP、FOR、LEAVE、ITERATE、RETURN 及游标操作
+	StmtTypeDeclare        StmtType = "DECLARE"      // 声明变量、条件或异常处理器
+	StmtTypeSet            StmtType = "SET"          // 变量赋值
+	StmtTypeOptimize       StmtType = "OPTIMIZE"     // 合并小文件等表优化，例如 OPTIMIZE、REORG
+	StmtTypeVacuum         StmtType = "VACUUM"       // 清理过期的数据文件
+	StmtTypeRestore        StmtType = "RESTORE"      // 将表恢复到历史版本，或从备份的快照恢复
+	StmtTypeCall           StmtType = "CALL"         // 调用存储过程，例如 Iceberg 的系统过程
+	StmtTypeSnapshotRef    StmtType = "SNAPSHOT_REF" // 创建、替换或删除表的分支和标签
+	StmtTypeCreateFunction StmtType = "CREATE_FUNCTION"
+	StmtTypeDropFunction   StmtType = "DROP_FUNCTION"
+	StmtTypeAddResource    StmtType = "ADD_RESOURCE" // ADD JAR、ADD FILE、ADD ARCHIVE
+	StmtTypeLoad           StmtType = "LOAD"         // 将文件导入表，例如 LOAD DATA
+	StmtTypeExport         StmtType = "EXPORT"       // 将表数据和元数据导出到路径
+	StmtTypeImport         StmtType = "IMPORT"       // 从导出的路径导入表
+	StmtTypeRoutineLoad    StmtType = "ROUTINE_LOAD" // 创建从消息队列持续导入的作业
+	StmtTypeCreatePipe     StmtType = "CREATE_PIPE"  // 创建持续导入文件的管道，嵌入的 INSERT 决定读写
+
+	StmtTypeCreateMaterializedView  StmtType = "CREATE_MATERIALIZED_VIEW"
+	StmtTypeAlterMaterializedView   StmtType = "ALTER_MATERIALIZED_VIEW"
+	StmtTypeRefreshMaterializedView StmtType = "REFRESH_MATERIALIZED_VIEW" // 根据定义重新计算物化视图，例如 REBUILD、REFRESH
+	StmtTypeDropMaterializedView    StmtType = "DROP_MATERIALIZED_VIEW"
+	StmtTypeCancelRefresh           StmtType = "CANCEL_REFRESH"         // 取消正在执行的物化视图或字典刷新任务
+	StmtTypeCreateScheduledQuery    StmtType = "CREATE_SCHEDULED_QUERY" // 创建定时执行的查询，嵌入的语句作为子语句
+	StmtTypeAlterScheduledQuery     StmtType = "ALTER_SCHEDULED_QUERY"
+	StmtTypeDropScheduledQuery      StmtType = "DROP_SCHEDULED_QUERY"
+	StmtTypeSubmitTask              StmtType = "SUBMIT_TASK" // 提交异步执行的任务，嵌入的语句作为子语句
+	StmtTypeDropTask                StmtType = "DROP_TASK"
+	StmtTypeBackup                  StmtType = "BACKUP" // 将表备份为仓库中的快照
+	StmtTypeCreateDictionary        StmtType = "CREATE_DICTIONARY"
+	StmtTypeRefreshDictionary       StmtType = "REFRESH_DICTIONARY"
+	StmtTypeDropDictionary          StmtType = "DROP_DICTIONARY"
+	StmtTypeCreateProcedure         StmtType = "CREATE_PROCEDURE" // 创建存储过程，过程体作为子语句
+	StmtTypeDropProcedure           StmtType = "DROP_PROCEDURE"
+	StmtTypeCreateTrigger           StmtType = "CREATE_TRIGGER" // 创建触发器，触发的表是读表，触发器体作为子语句
+	StmtTypeDropTrigger             StmtType = "DROP_TRIGGER"
+	StmtTypeCreateEvent             StmtType = "CREATE_EVENT" // 创建定时执行的事件，事件体作为子语句
+	StmtTypeAlterEvent              StmtType = "ALTER_EVENT"
+	StmtTypeDropEvent               StmtType = "DROP_EVENT"
+	StmtTypeSelectIntoFile          StmtType = "SELECT_INTO_FILE" // 将查询结果写入文件，例如 SELECT ... INTO OUTFILE
+	StmtTypeRenameTable             StmtType = "RENAME_TABLE"     // 重命名一张或多张表，原表名和新表名都是写表
+	StmtTypeCreateIndex             StmtType = "CREATE_INDEX"     // 创建索引，视为修改表，表是写表
+	StmtTypeDropIndex               StmtType = "DROP_INDEX"
 )
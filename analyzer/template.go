@@ -0,0 +1,313 @@
+package analyzer
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// UnresolvedWildcard 未解析的变量在表名中的替代标记
+const UnresolvedWildcard = "*"
+
+var (
+	// unresolvedPattern 未解析变量在预处理结果中的占位标识，可以作为标识符的一部分被解析
+	unresolvedPattern = regexp.MustCompile(`(?i)tpl__var(\d+)__`)
+	// setCommandPattern 形如 SET [hivevar:|hiveconf:]name=value 的变量赋值
+	setCommandPattern = regexp.MustCompile(`(?is)^\s*SET\s+((?:hivevar|hiveconf|system|env):)?([A-Za-z0-9_.\-]+)\s*=\s*(.*?)\s*;?\s*$`)
+	// leadingCommentPattern 语句开头的单行注释
+	leadingCommentPattern = regexp.MustCompile(`^(?:\s*--[^\n]*(?:\n|$))*`)
+	// errorPositionPattern 语法错误信息中的位置，兼容 "line:1 column:2" 和 "line 1:2" 两种格式
+	errorPositionPattern = regexp.MustCompile(`line(:| )(\d+)( column:|:)(\d+)`)
+)
+
+// Template 变量替换后的脚本，记录替换前后的偏移映射。
+// 支持 ${var}、${hivevar:var}、${hiveconf:var}、${env:VAR} 和 {{ var }} 形式的占位符，
+// 脚本中的 SET var=value 和 SET hivevar:var=value 会作用于其后的语句
+type Template struct {
+	Original     string // 原始脚本
+	SQL          string // 替换后的脚本
+	replacements []*templateReplacement
+}
+
+// templateReplacement 一处占位符替换
+type templateReplacement struct {
+	start, end         int    // 在替换后脚本中的范围
+	origStart, origEnd int    // 在原始脚本中的范围
+	placeholder        string // 占位符原文
+	resolved           bool
+}
+
+// NewTemplate 使用 vars 替换脚本中的占位符，vars 的键可以带 hivevar:、hiveconf:、env: 等前缀。
+// 未解析的占位符会被替换为可以解析的标识，分析结果经 Restore 后表名中以 UnresolvedWildcard 表示
+func NewTemplate(sql string, vars map[string]string) *Template {
+	t := &Template{Original: sql}
+	scope := make(map[string]string, len(vars))
+	for k, v := range vars {
+		scope[k] = v
+	}
+
+	var out strings.Builder
+	stmtStart, unresolved := 0, 0
+	var quote byte
+	endStmt := func() {
+		// 语句中的变量都解析了才记录赋值
+		if name, value, ok := ParseSetCommand(out.String()[stmtStart:]); ok && unresolved == 0 {
+			scope[name] = value
+		}
+		stmtStart, unresolved = out.Len(), 0
+	}
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote != '`' && i+1 < len(sql) {
+				out.WriteString(sql[i : i+2])
+				i += 2
+				continue
+			}
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case strings.HasPrefix(sql[i:], "--"):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				end = len(sql) - i
+			}
+			out.WriteString(sql[i : i+end])
+			i += end
+			continue
+		case strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				end = len(sql) - i
+			} else {
+				end += 4
+			}
+			out.WriteString(sql[i : i+end])
+			i += end
+			continue
+		case c == ';':
+			out.WriteByte(c)
+			i++
+			endStmt()
+			continue
+		}
+
+		// 占位符在引号内也会替换
+		if name, n := placeholderAt(sql[i:]); n > 0 {
+			r := &templateReplacement{
+				start:       out.Len(),
+				origStart:   i,
+				origEnd:     i + n,
+				placeholder: sql[i : i+n],
+			}
+			if value, ok := lookupVariable(scope, name); ok {
+				r.resolved = true
+				out.WriteString(value)
+			} else {
+				unresolved++
+				out.WriteString("tpl__var" + strconv.Itoa(len(t.replacements)) + "__")
+			}
+			r.end = out.Len()
+			t.replacements = append(t.replacements, r)
+			i += n
+			continue
+		}
+		out.WriteByte(c)
+		i++
+	}
+	endStmt()
+	t.SQL = out.String()
+	return t
+}
+
+// ParseSetCommand 解析 SET 变量赋值语句，name 保留 hivevar: 等前缀
+func ParseSetCommand(stmt string) (name, value string, ok bool) {
+	m := setCommandPattern.FindStringSubmatch(leadingCommentPattern.ReplaceAllString(stmt, ""))
+	if m == nil {
+		return "", "", false
+	}
+	return m[1] + m[2], m[3], true
+}
+
+// placeholderAt 识别 s 开头的占位符，返回变量名和占位符长度
+func placeholderAt(s string) (string, int) {
+	var open, closing string
+	switch {
+	case strings.HasPrefix(s, "${"):
+		open, closing = "${", "}"
+	case strings.HasPrefix(s, "{{"):
+		open, closing = "{{", "}}"
+	default:
+		return "", 0
+	}
+	end := strings.Index(s[len(open):], closing)
+	if end < 0 {
+		return "", 0
+	}
+	name := strings.TrimSpace(s[len(open) : len(open)+end])
+	if name == "" || strings.ContainsAny(name, "\n;") {
+		return "", 0
+	}
+	return name, len(open) + end + len(closing)
+}
+
+// lookupVariable 查找变量，带前缀的变量也会按不带前缀的名字查找，反之亦然
+func lookupVariable(scope map[string]string, name string) (string, bool) {
+	candidates := []string{name}
+	if i := strings.IndexByte(name, ':'); i >= 0 {
+		candidates = append(candidates, name[i+1:])
+	} else {
+		candidates = append(candidates, "hivevar:"+name, "hiveconf:"+name)
+	}
+	for _, c := range candidates {
+		if v, ok := scope[c]; ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// Unresolved 返回未解析的占位符原文，按出现顺序去重
+func (t *Template) Unresolved() []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, r := range t.replacements {
+		if !r.resolved && !seen[r.placeholder] {
+			seen[r.placeholder] = true
+			result = append(result, r.placeholder)
+		}
+	}
+	return result
+}
+
+// OriginalOffset 把替换后脚本中的字节偏移映射回原始脚本，替换值内部的偏移映射到占位符开头
+func (t *Template) OriginalOffset(offset int) int {
+	delta := 0
+	for _, r := range t.replacements {
+		if offset < r.start {
+			break
+		}
+		if offset < r.end {
+			return r.origStart
+		}
+		delta = r.origEnd - r.end
+	}
+	return offset + delta
+}
+
+// MapSpan 把替换后脚本中的语句位置映射回原始脚本，语句文本仍为替换后的文本
+func (t *Template) MapSpan(span *StatementSpan) {
+	if span.Start < 0 || len(t.replacements) == 0 {
+		return
+	}
+	span.Start, span.End = t.OriginalOffset(span.Start), t.OriginalOffset(span.End)
+	span.Line, span.Column = positionOf(t.Original, span.Start)
+}
+
+// MapError 把语句 stmt 的语法错误位置映射回原始脚本中的同一条语句，错误信息中的未解析变量还原为占位符原文，
+// start 为 stmt 在替换后脚本中的起始偏移
+func (t *Template) MapError(err error, stmt string, start int) error {
+	if err == nil || start < 0 || len(t.replacements) == 0 {
+		return err
+	}
+	origStart := t.OriginalOffset(start)
+	msg := errorPositionPattern.ReplaceAllStringFunc(err.Error(), func(s string) string {
+		m := errorPositionPattern.FindStringSubmatch(s)
+		line, _ := strconv.Atoi(m[2])
+		column, _ := strconv.Atoi(m[4])
+		offset := t.OriginalOffset(start + offsetOf(stmt, line, column))
+		line, column = positionOf(t.Original[origStart:], offset-origStart)
+		return fmt.Sprintf("line%s%d%s%d", m[1], line, m[3], column)
+	})
+	return fmt.Errorf("%s", t.restoreText(msg))
+}
+
+// Restore 还原分析结果中的未解析变量：表名中替换为 UnresolvedWildcard，语句、路径、分区和快照中还原为占位符原文
+func (t *Template) Restore(results []*DependencyResult) {
+	if len(t.Unresolved()) == 0 {
+		return
+	}
+	for _, result := range results {
+		result.Stmt = t.restoreText(result.Stmt)
+		if result.Span != nil {
+			result.Span.Raw, result.Span.Text = t.restoreText(result.Span.Raw), t.restoreText(result.Span.Text)
+			for i, comment := range result.Span.Comments {
+				result.Span.Comments[i] = t.restoreText(comment)
+			}
+		}
+		for _, tables := range [][]*DependencyTable{result.Read, result.Write} {
+			for _, table := range tables {
+				table.Cluster = unresolvedPattern.ReplaceAllString(table.Cluster, UnresolvedWildcard)
+				table.Database = unresolvedPattern.ReplaceAllString(table.Database, UnresolvedWildcard)
+				table.Table = unresolvedPattern.ReplaceAllString(table.Table, UnresolvedWildcard)
+				table.Location = t.restoreText(table.Location)
+				if table.AsOf != nil {
+					table.AsOf.Value = t.restoreText(table.AsOf.Value)
+				}
+				for _, spec := range table.Partitions {
+					spec.Name = t.restoreText(spec.Name)
+					for _, v := range spec.Values {
+						v.Value = t.restoreText(v.Value)
+					}
+				}
+			}
+		}
+		for _, externals := range [][]*DependencyExternal{result.ExternalRead, result.ExternalWrite} {
+			for _, external := range externals {
+				external.Location = t.restoreText(external.Location)
+				if external.AsOf != nil {
+					external.AsOf.Value = t.restoreText(external.AsOf.Value)
+				}
+				for k, v := range external.Properties {
+					external.Properties[k] = t.restoreText(v)
+				}
+			}
+		}
+		t.Restore(result.Children)
+	}
+}
+
+func (t *Template) restoreText(s string) string {
+	return unresolvedPattern.ReplaceAllStringFunc(s, func(id string) string {
+		i, err := strconv.Atoi(unresolvedPattern.FindStringSubmatch(id)[1])
+		if err != nil || i >= len(t.replacements) {
+			return id
+		}
+		return t.replacements[i].placeholder
+	})
+}
+
+// offsetOf 把从1开始的行号和从0开始的列号（按字符计）转换为字节偏移
+func offsetOf(s string, line, column int) int {
+	offset := 0
+	for l := 1; l < line; l++ {
+		i := strings.IndexByte(s[offset:], '\n')
+		if i < 0 {
+			return len(s)
+		}
+		offset += i + 1
+	}
+	for ; column > 0 && offset < len(s); column-- {
+		_, size := utf8.DecodeRuneInString(s[offset:])
+		offset += size
+	}
+	return offset
+}
+
+// positionOf 把字节偏移转换为从1开始的行号和从0开始的列号（按字符计）
+func positionOf(s string, offset int) (line, column int) {
+	if offset > len(s) {
+		offset = len(s)
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	prefix := s[:offset]
+	line = strings.Count(prefix, "\n") + 1
+	return line, utf8.RuneCountInString(prefix[strings.LastIndexByte(prefix, '\n')+1:])
+}
@@ -0,0 +1,101 @@
+package analyzer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTemplate(t *testing.T) {
+	tests := []struct {
+		name       string
+		sql        string
+		vars       map[string]string
+		expected   string
+		unresolved []string
+	}{
+		{
+			name:     "hivevar, env and jinja placeholders",
+			sql:      "INSERT INTO db_${env:ENV}.t SELECT * FROM ods.t WHERE dt = '${hivevar:dt}' AND ds = '{{ ds }}'",
+			vars:     map[string]string{"env:ENV": "prod", "dt": "2024-01-01", "ds": "20240101"},
+			expected: "INSERT INTO db_prod.t SELECT * FROM ods.t WHERE dt = '2024-01-01' AND ds = '20240101'",
+		},
+		{
+			name:     "SET statements apply to later statements",
+			sql:      "SELECT '${dt}';\nSET hivevar:dt=2024-01-02;\nSET tbl = t_${dt};\nSELECT * FROM ${hivevar:tbl} WHERE dt = '${dt}';",
+			expected: "SELECT 'tpl__var0__';\nSET hivevar:dt=2024-01-02;\nSET tbl = t_2024-01-02;\nSELECT * FROM t_2024-01-02 WHERE dt = '2024-01-02';",
+			unresolved: []string{
+				"${dt}",
+			},
+		},
+		{
+			name:       "SET with unresolved value is ignored",
+			sql:        "SET db=ods_${env};\nSELECT * FROM ${db}.t;",
+			expected:   "SET db=ods_tpl__var0__;\nSELECT * FROM tpl__var1__.t;",
+			unresolved: []string{"${env}", "${db}"},
+		},
+		{
+			name:     "placeholders in comments are kept",
+			sql:      "-- ${dt}\n/* ${dt} */ SELECT 1;",
+			expected: "-- ${dt}\n/* ${dt} */ SELECT 1;",
+		},
+		{
+			name:     "semicolon in string does not end SET",
+			sql:      "SET sep=';';\nSELECT ${sep}",
+			expected: "SET sep=';';\nSELECT ';'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tpl := NewTemplate(tt.sql, tt.vars)
+			assert.Equal(t, tt.expected, tpl.SQL)
+			assert.Equal(t, tt.unresolved, tpl.Unresolved())
+		})
+	}
+}
+
+func TestTemplate_OriginalOffset(t *testing.T) {
+	sql := "SELECT * FROM ${db}.t WHERE x = ${missing} AND y"
+	tpl := NewTemplate(sql, map[string]string{"db": "ods_prod"})
+	assert.Equal(t, "SELECT * FROM ods_prod.t WHERE x = tpl__var1__ AND y", tpl.SQL)
+	assert.Equal(t, 7, tpl.OriginalOffset(7))
+	// 替换值内部的偏移映射到占位符开头
+	assert.Equal(t, 14, tpl.OriginalOffset(16))
+	assert.Equal(t, 19, tpl.OriginalOffset(22))
+	assert.Equal(t, len(sql)-1, tpl.OriginalOffset(len(tpl.SQL)-1))
+}
+
+func TestTemplate_MapError(t *testing.T) {
+	sql := "SELECT 1;\nSELECT * FROM ${db}.t\nWHERE ${col} = 1 FROM"
+	tpl := NewTemplate(sql, map[string]string{"db": "a_much_longer_database", "col": "c"})
+	stmts := []string{"SELECT 1;", "SELECT * FROM a_much_longer_database.t\nWHERE c = 1 FROM"}
+	var starts []int
+	for _, span := range LocateSpans(tpl.SQL, stmts) {
+		starts = append(starts, span.Start)
+	}
+	assert.Equal(t, []int{0, 10}, starts)
+	err := tpl.MapError(errors.New("line 2:12 extraneous input 'FROM'"), stmts[1], starts[1])
+	assert.EqualError(t, err, "line 2:17 extraneous input 'FROM'")
+	err = tpl.MapError(errors.New("line:1 column:37 mismatched input"), stmts[1], starts[1])
+	assert.EqualError(t, err, "line:1 column:20 mismatched input")
+
+	// 错误信息中的未解析变量还原为占位符原文
+	tpl = NewTemplate("SELECT * FROM t WHERE ${x} ${y}", nil)
+	assert.Equal(t, "SELECT * FROM t WHERE tpl__var0__ tpl__var1__", tpl.SQL)
+	err = tpl.MapError(errors.New("line 1:34 extraneous input 'tpl__var1__'"), tpl.SQL, 0)
+	assert.EqualError(t, err, "line 1:27 extraneous input '${y}'")
+}
+
+func TestTemplate_Restore(t *testing.T) {
+	tpl := NewTemplate("INSERT INTO db_${env}.t_${dt} SELECT * FROM parquet.`/data/${dt}`", nil)
+	results := []*DependencyResult{{
+		Stmt:          tpl.SQL,
+		Write:         []*DependencyTable{{Cluster: "c", Database: "db_tpl__var0__", Table: "t_tpl__var1__"}},
+		ExternalWrite: []*DependencyExternal{{Type: ExternalTypePath, Location: "/data/tpl__var2__"}},
+	}}
+	tpl.Restore(results)
+	assert.Equal(t, "INSERT INTO db_${env}.t_${dt} SELECT * FROM parquet.`/data/${dt}`", results[0].Stmt)
+	assert.Equal(t, "c.db_*.t_*", results[0].Write[0].String())
+	assert.Equal(t, "/data/${dt}", results[0].ExternalWrite[0].Location)
+}
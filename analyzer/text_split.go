@@ -0,0 +1,290 @@
+package analyzer
+
+import (
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// Dialect 不使用语法文件拆分SQL语句时各引擎的词法规则
+type Dialect struct {
+	HashComments     bool // # 开始单行注释
+	DashSpace        bool // -- 之后必须是空白或行尾才是注释
+	NestedComments   bool // 块注释可以嵌套
+	DollarQuotes     bool // 支持 $$ ... $$ 和 $tag$ ... $tag$ 形式的字符串
+	BackslashEscapes bool // 字符串中的反斜杠转义下一个字符
+}
+
+// textTokenKind 无语法拆分时识别的词法单元类型
+type textTokenKind int
+
+const (
+	textOther     textTokenKind = iota // 字符串、标点等其他内容
+	textWord                           // 关键字或标识符
+	textSemicolon                      // 分号
+	textHidden                         // 空白和注释
+)
+
+// textToken 无语法拆分时识别的词法单元
+type textToken struct {
+	kind       textTokenKind
+	start, end int
+}
+
+// LexerErrors 记录词法分析器的错误数量，词法分析出错时按token拆分的结果不可靠
+type LexerErrors struct {
+	*antlr.DefaultErrorListener
+	Count int
+}
+
+// WatchLexerErrors 为词法分析器添加错误监听
+func WatchLexerErrors(lexer antlr.Lexer) *LexerErrors {
+	errs := &LexerErrors{DefaultErrorListener: antlr.NewDefaultErrorListener()}
+	lexer.AddErrorListener(errs)
+	return errs
+}
+
+func (e *LexerErrors) SyntaxError(antlr.Recognizer, interface{}, int, int, string, antlr.RecognitionException) {
+	e.Count++
+}
+
+// SplitSQLOrText 使用词法分析器拆分SQL语句，词法分析出错时改用 SplitText 按 dialect 拆分
+func SplitSQLOrText[T antlr.Lexer](sql string, lexer T, dialect Dialect) []string {
+	errs := WatchLexerErrors(lexer)
+	statements := SplitSQL(lexer)
+	if errs.Count > 0 {
+		return SplitText(sql, dialect)
+	}
+	return statements
+}
+
+// SplitText 不使用语法文件，按 dialect 的词法规则拆分SQL语句，用于词法分析器无法处理的脚本。
+// 引号、反引号、$$ 字符串和注释中的分号不作为语句结束，BEGIN ... END 程序体中的分号也不作为语句结束；
+// 未闭合的引号和注释按普通字符处理，未闭合的程序体在其中的分号处拆分，一处错误不影响其余语句的拆分
+func SplitText(sql string, dialect Dialect) []string {
+	tokens := scanText(sql, dialect)
+	var result []string
+	start := 0
+	emit := func(end int) {
+		if stmt := strings.TrimSpace(sql[start:end]); stmt != "" {
+			result = append(result, stmt)
+		}
+		start = end
+	}
+
+	first := true     // 是否还没遇到当前语句的第一个有效token
+	compound := false // 当前语句是否为存储程序或复合语句
+	depth := 0        // BEGIN/CASE 与 END 的嵌套深度
+	var inner []int   // 程序体内分号之后的位置
+	prev := ""
+	for i, t := range tokens {
+		if t.kind == textHidden {
+			continue
+		}
+		if first {
+			first = false
+			compound = isTextCompoundStart(sql, tokens, i)
+		}
+		word := upperWord(sql, t)
+		if compound {
+			switch word {
+			case "BEGIN":
+				depth++
+			case "CASE":
+				// END CASE 中的 CASE 不是新的 CASE
+				if prev != "END" {
+					depth++
+				}
+			case "END":
+				// END IF、END WHILE 等没有对应的 BEGIN
+				switch nextTextWord(sql, tokens, i) {
+				case "IF", "WHILE", "LOOP", "REPEAT", "FOR":
+				default:
+					depth--
+				}
+			}
+		}
+		prev = word
+		if t.kind != textSemicolon {
+			continue
+		}
+		if depth > 0 {
+			inner = append(inner, t.end)
+			continue
+		}
+		emit(t.end)
+		first, compound, depth, inner = true, false, 0, nil
+	}
+	// 程序体没有闭合时在其中的分号处拆分
+	for _, end := range inner {
+		emit(end)
+	}
+	emit(len(sql))
+	return result
+}
+
+// isTextCompoundStart 判断语句是否为 BEGIN ... END 复合语句或存储程序定义：
+// 以 BEGIN 或 label: BEGIN 开始，但不是开启事务的 BEGIN、BEGIN WORK；
+// 或者以 CREATE、ALTER 开始，在第一个括号之前出现 PROCEDURE、FUNCTION、TRIGGER 或 EVENT
+func isTextCompoundStart(sql string, tokens []textToken, i int) bool {
+	switch upperWord(sql, tokens[i]) {
+	case "BEGIN":
+		next := nextTextToken(tokens, i)
+		if next < 0 || tokens[next].kind == textSemicolon {
+			return false
+		}
+		switch upperWord(sql, tokens[next]) {
+		case "WORK", "TRANSACTION", "TRAN":
+			return false
+		}
+		return true
+	case "CREATE", "ALTER":
+		for j := nextTextToken(tokens, i); j >= 0; j = nextTextToken(tokens, j) {
+			if tokens[j].kind == textSemicolon || sql[tokens[j].start:tokens[j].end] == "(" {
+				return false
+			}
+			switch upperWord(sql, tokens[j]) {
+			case "PROCEDURE", "FUNCTION", "TRIGGER", "EVENT":
+				return true
+			}
+		}
+		return false
+	case "":
+		return false
+	}
+	j := nextTextToken(tokens, i)
+	return j >= 0 && sql[tokens[j].start:tokens[j].end] == ":" && nextTextWord(sql, tokens, j) == "BEGIN"
+}
+
+// upperWord 返回关键字或标识符的大写文本，其他token返回空
+func upperWord(sql string, t textToken) string {
+	if t.kind != textWord {
+		return ""
+	}
+	return strings.ToUpper(sql[t.start:t.end])
+}
+
+// nextTextToken 返回 i 之后第一个不是空白和注释的token的位置，没有时返回-1
+func nextTextToken(tokens []textToken, i int) int {
+	for j := i + 1; j < len(tokens); j++ {
+		if tokens[j].kind != textHidden {
+			return j
+		}
+	}
+	return -1
+}
+
+// nextTextWord 返回 i 之后第一个有效token的大写文本，不是关键字或标识符时返回空
+func nextTextWord(sql string, tokens []textToken, i int) string {
+	if j := nextTextToken(tokens, i); j >= 0 {
+		return upperWord(sql, tokens[j])
+	}
+	return ""
+}
+
+// scanText 按 dialect 把SQL切分为token，未闭合的引号和注释只把开头的字符作为普通字符
+func scanText(sql string, dialect Dialect) []textToken {
+	var tokens []textToken
+	for i := 0; i < len(sql); {
+		kind, end := textOther, i+1
+		c := sql[i]
+		switch {
+		case isSpace(c):
+			kind = textHidden
+			for end < len(sql) && isSpace(sql[end]) {
+				end++
+			}
+		case c == '#' && dialect.HashComments,
+			strings.HasPrefix(sql[i:], "--") && (!dialect.DashSpace || isDashComment(sql, i)):
+			kind, end = textHidden, len(sql)
+			if j := strings.IndexByte(sql[i:], '\n'); j >= 0 {
+				end = i + j
+			}
+		case strings.HasPrefix(sql[i:], "/*"):
+			if j, ok := blockCommentEnd(sql, i, dialect.NestedComments); ok {
+				kind, end = textHidden, j
+			}
+		case c == '\'' || c == '"' || c == '`':
+			if j, ok := quoteEnd(sql, i, dialect.BackslashEscapes && c != '`'); ok {
+				end = j
+			}
+		case c == '$' && dialect.DollarQuotes && (i == 0 || !isWordByte(sql[i-1])):
+			if j, ok := dollarQuoteEnd(sql, i); ok {
+				end = j
+			}
+		case c == ';':
+			kind = textSemicolon
+		case isWordByte(c):
+			kind = textWord
+			for end < len(sql) && isWordByte(sql[end]) {
+				end++
+			}
+		}
+		tokens = append(tokens, textToken{kind: kind, start: i, end: end})
+		i = end
+	}
+	return tokens
+}
+
+// quoteEnd 返回从 i 开始的字符串或反引号标识符结束引号之后的位置，连续两个引号表示引号本身
+func quoteEnd(sql string, i int, backslash bool) (int, bool) {
+	quote := sql[i]
+	for j := i + 1; j < len(sql); j++ {
+		switch sql[j] {
+		case '\\':
+			if backslash {
+				j++
+			}
+		case quote:
+			if j+1 < len(sql) && sql[j+1] == quote {
+				j++
+				continue
+			}
+			return j + 1, true
+		}
+	}
+	return 0, false
+}
+
+// blockCommentEnd 返回从 i 开始的块注释之后的位置，nested 为true时块注释可以嵌套
+func blockCommentEnd(sql string, i int, nested bool) (int, bool) {
+	depth := 0
+	for j := i; j+1 < len(sql); j++ {
+		switch {
+		case sql[j] == '/' && sql[j+1] == '*' && (nested || depth == 0):
+			depth++
+			j++
+		case sql[j] == '*' && sql[j+1] == '/':
+			depth--
+			j++
+			if depth == 0 {
+				return j + 1, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// dollarQuoteEnd 返回从 i 开始的 $tag$ ... $tag$ 字符串之后的位置
+func dollarQuoteEnd(sql string, i int) (int, bool) {
+	j := i + 1
+	for j < len(sql) && sql[j] != '$' {
+		if !isWordByte(sql[j]) || sql[j] >= '0' && sql[j] <= '9' && j == i+1 {
+			return 0, false
+		}
+		j++
+	}
+	if j >= len(sql) {
+		return 0, false
+	}
+	tag := sql[i : j+1]
+	if k := strings.Index(sql[j+1:], tag); k >= 0 {
+		return j + 1 + k + len(tag), true
+	}
+	return 0, false
+}
+
+// isWordByte 判断字节是否可以作为关键字或标识符的一部分，非 ASCII 字符都作为标识符的一部分
+func isWordByte(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
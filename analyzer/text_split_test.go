@@ -0,0 +1,77 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitText(t *testing.T) {
+	mysql := Dialect{HashComments: true, DashSpace: true, BackslashEscapes: true}
+	spark := Dialect{NestedComments: true, DollarQuotes: true, BackslashEscapes: true}
+	tests := []struct {
+		name     string
+		sql      string
+		dialect  Dialect
+		expected []string
+	}{
+		{
+			name:     "semicolons in quotes and comments",
+			sql:      "SELECT 'a;b', \"c;d\", `e;f` FROM t -- g;h\n# i;j\n/* k;l */;\nSELECT 'it''s;', 'x\\';y';",
+			dialect:  mysql,
+			expected: []string{"SELECT 'a;b', \"c;d\", `e;f` FROM t -- g;h\n# i;j\n/* k;l */;", "SELECT 'it''s;', 'x\\';y';"},
+		},
+		{
+			name:     "dash without space is not a comment in MySQL",
+			sql:      "SELECT 1--1;\nSELECT 2",
+			dialect:  mysql,
+			expected: []string{"SELECT 1--1;", "SELECT 2"},
+		},
+		{
+			name:     "hash is not a comment in Spark",
+			sql:      "SELECT a#b;SELECT 2",
+			dialect:  spark,
+			expected: []string{"SELECT a#b;", "SELECT 2"},
+		},
+		{
+			name:     "nested block comments and dollar quotes",
+			sql:      "/* a /* b; */ c; */ CREATE FUNCTION f() RETURNS INT LANGUAGE PYTHON AS $$ x = 1; return x $$;\nSELECT $tag$;$tag$, a$b;",
+			dialect:  spark,
+			expected: []string{"/* a /* b; */ c; */ CREATE FUNCTION f() RETURNS INT LANGUAGE PYTHON AS $$ x = 1; return x $$;", "SELECT $tag$;$tag$, a$b;"},
+		},
+		{
+			name: "BEGIN ... END blocks",
+			sql: "CREATE DEFINER=`root`@`%` PROCEDURE p()\nBEGIN\n  IF x THEN SELECT CASE WHEN a THEN 1 END; END IF;\n" +
+				"  CASE y WHEN 1 THEN DELETE FROM t; END CASE;\nEND;\n" +
+				"BEGIN;\nSELECT 1;\nCOMMIT;\nlbl: BEGIN SELECT 1; END;",
+			dialect: mysql,
+			expected: []string{
+				"CREATE DEFINER=`root`@`%` PROCEDURE p()\nBEGIN\n  IF x THEN SELECT CASE WHEN a THEN 1 END; END IF;\n  CASE y WHEN 1 THEN DELETE FROM t; END CASE;\nEND;",
+				"BEGIN;", "SELECT 1;", "COMMIT;", "lbl: BEGIN SELECT 1; END;",
+			},
+		},
+		{
+			name:     "unterminated quote is treated as a normal character",
+			sql:      "SELECT 'abc;\nSELECT 2;",
+			dialect:  mysql,
+			expected: []string{"SELECT 'abc;", "SELECT 2;"},
+		},
+		{
+			name:     "unterminated block comment is treated as normal characters",
+			sql:      "SELECT 1 /* oops;\nSELECT 2",
+			dialect:  spark,
+			expected: []string{"SELECT 1 /* oops;", "SELECT 2"},
+		},
+		{
+			name:     "unclosed BEGIN splits at its semicolons",
+			sql:      "CREATE PROCEDURE p() BEGIN DELETE FROM t; INSERT INTO t VALUES (1);\nSELECT 3",
+			dialect:  mysql,
+			expected: []string{"CREATE PROCEDURE p() BEGIN DELETE FROM t;", "INSERT INTO t VALUES (1);", "SELECT 3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, SplitText(tt.sql, tt.dialect))
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ViewCycleError 视图定义之间存在循环引用
+type ViewCycleError struct {
+	Path []string
+}
+
+func (e *ViewCycleError) Error() string {
+	return fmt.Sprintf("view cycle detected: %s", strings.Join(e.Path, " -> "))
+}
+
+// NewViewCatalog 根据 CREATE VIEW 语句构建只包含视图的 MemoryCatalog，
+// 视图名取自语句的写表，defs 的键仅用于错误信息
+func NewViewCatalog(a DependencyAnalyzer, defs map[string]string, defaultCluster, defaultDatabase string) (*MemoryCatalog, error) {
+	c := NewMemoryCatalog()
+	for name, def := range defs {
+		results, err := a.Analyze(&DependencyAnalyzeReq{
+			DefaultCluster:  defaultCluster,
+			DefaultDatabase: defaultDatabase,
+			SQL:             def,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("view %s: %w", name, err)
+		}
+		if len(results) != 1 || results[0].StmtType != StmtTypeCreateView || len(results[0].Write) != 1 {
+			return nil, fmt.Errorf("view %s: definition must be a single CREATE VIEW statement", name)
+		}
+		view := results[0].Write[0]
+		c.AddTable(&CatalogTable{
+			Cluster:        view.Cluster,
+			Database:       view.Database,
+			Table:          view.Table,
+			Kind:           TableKindView,
+			ViewDefinition: def,
+		})
+	}
+	return c, nil
+}
+
+// ExpandViews 将读取的视图展开为其背后的表，嵌套视图会递归展开，复合语句的子语句同样展开。
+// 展开得到的表追加到读表中，ViewPath 记录从最外层视图到该表的路径
+func ExpandViews(a DependencyAnalyzer, results []*DependencyResult, catalog Catalog) error {
+	e := &viewExpander{
+		analyzer:    a,
+		catalog:     catalog,
+		definitions: make(map[string][]*DependencyTable),
+	}
+	return e.expandResults(results)
+}
+
+// viewExpander 展开视图，视图定义读取的表只分析一次
+type viewExpander struct {
+	analyzer    DependencyAnalyzer
+	catalog     Catalog
+	definitions map[string][]*DependencyTable // 视图到其定义读取的表，不是视图时为nil
+}
+
+// viewExpansion 单条语句的展开状态
+type viewExpansion struct {
+	seen     map[string]bool // 已经在读表中的表
+	done     map[string]bool // 已经展开过的视图，菱形引用的视图只按第一次到达的路径展开
+	expanded []*DependencyTable
+}
+
+func (e *viewExpander) expandResults(results []*DependencyResult) error {
+	for _, result := range results {
+		if err := e.expandResults(result.Children); err != nil {
+			return err
+		}
+		s := &viewExpansion{seen: make(map[string]bool), done: make(map[string]bool)}
+		for _, t := range result.Read {
+			s.seen[strings.ToLower(t.String())] = true
+		}
+		for _, t := range result.Read {
+			if err := e.expand(t, []string{t.String()}, s); err != nil {
+				return err
+			}
+		}
+		result.Read = append(result.Read, s.expanded...)
+	}
+	return nil
+}
+
+// expand 展开单个视图，path 为到达该视图的路径
+func (e *viewExpander) expand(view *DependencyTable, path []string, s *viewExpansion) error {
+	reads, err := e.definition(view)
+	if err != nil || reads == nil {
+		return err
+	}
+	view.Kind = TableKindView
+	key := strings.ToLower(view.String())
+	if s.done[key] {
+		return nil
+	}
+	s.done[key] = true
+
+	for _, t := range reads {
+		for _, p := range path {
+			if strings.EqualFold(p, t.String()) {
+				return &ViewCycleError{Path: append(append([]string(nil), path...), t.String())}
+			}
+		}
+		table := &DependencyTable{
+			Cluster:  t.Cluster,
+			Database: t.Database,
+			Table:    t.Table,
+			ViewPath: append(append([]string(nil), path...), t.String()),
+		}
+		if ct, err := e.catalog.LookupTable(t.Cluster, t.Database, t.Table); err != nil {
+			return err
+		} else if ct != nil {
+			table.Kind = TableKindTable
+		}
+		// 同一张表只记录第一次到达的路径
+		if key := strings.ToLower(t.String()); !s.seen[key] {
+			s.seen[key] = true
+			s.expanded = append(s.expanded, table)
+		}
+		if err := e.expand(table, table.ViewPath, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// definition 返回视图定义读取的表，不是视图时返回nil。
+// 视图定义中未限定库名的表相对于视图所在的库解析
+func (e *viewExpander) definition(view *DependencyTable) ([]*DependencyTable, error) {
+	key := strings.ToLower(view.String())
+	if reads, ok := e.definitions[key]; ok {
+		return reads, nil
+	}
+	def, err := e.catalog.GetViewDefinition(view.Cluster, view.Database, view.Table)
+	if err != nil {
+		return nil, err
+	}
+	var reads []*DependencyTable
+	if def != "" {
+		results, err := e.analyzer.Analyze(&DependencyAnalyzeReq{
+			DefaultCluster:  view.Cluster,
+			DefaultDatabase: view.Database,
+			SQL:             def,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("view %s: %w", view, err)
+		}
+		reads = []*DependencyTable{}
+		for _, result := range results {
+			reads = append(reads, result.Read...)
+		}
+	}
+	e.definitions[key] = reads
+	return reads, nil
+}
+
+// AddRefreshReads 为刷新物化视图的语句补充读表，读表取自 Catalog 中物化视图定义读取的基表，
+// 定义中未限定库名的表相对于物化视图所在的库解析。子语句补充的读表同样加入父语句，
+// 例如 Hive 定时执行 ALTER MATERIALIZED VIEW ... REBUILD 的 SCHEDULED QUERY
+func AddRefreshReads(a DependencyAnalyzer, results []*DependencyResult, catalog Catalog) error {
+	for _, result := range results {
+		if err := AddRefreshReads(a, result.Children, catalog); err != nil {
+			return err
+		}
+		seen := make(map[string]bool)
+		for _, t := range result.Read {
+			seen[strings.ToLower(t.String())] = true
+		}
+		addRead := func(t *DependencyTable) {
+			if key := strings.ToLower(t.String()); !seen[key] {
+				seen[key] = true
+				result.Read = append(result.Read, &DependencyTable{Cluster: t.Cluster, Database: t.Database, Table: t.Table})
+			}
+		}
+		for _, child := range result.Children {
+			for _, t := range child.Read {
+				addRead(t)
+			}
+		}
+		if result.StmtType != StmtTypeRefreshMaterializedView {
+			continue
+		}
+		for _, mv := range result.Write {
+			ct, err := catalog.LookupTable(mv.Cluster, mv.Database, mv.Table)
+			if err != nil {
+				return err
+			}
+			if ct == nil || ct.Kind != TableKindMaterializedView || ct.ViewDefinition == "" {
+				continue
+			}
+			defs, err := a.Analyze(&DependencyAnalyzeReq{
+				DefaultCluster:  mv.Cluster,
+				DefaultDatabase: mv.Database,
+				SQL:             ct.ViewDefinition,
+			})
+			if err != nil {
+				return fmt.Errorf("materialized view %s: %w", mv, err)
+			}
+			for _, def := range defs {
+				for _, t := range def.Read {
+					addRead(t)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package hive
+
+import (
+	"strings"
+
+	"github.com/Edsuns/sql-parser/analyzer"
+	"github.com/Edsuns/sql-parser/internal/hive/parser"
+)
+
+// 监听进入LOAD DATA语句，导入的文件路径作为外部读依赖
+func (l *dependencyListener) EnterLoadStatement(ctx *parser.LoadStatementContext) {
+	l.isOnlyComment = false
+	l.firstOpType = analyzer.StmtTypeLoad
+	external := &analyzer.DependencyExternal{
+		Type:     analyzer.ExternalTypePath,
+		Location: unquote(ctx.StringLiteral().GetText()),
+	}
+	if ctx.KW_LOCAL() != nil {
+		external.Properties = map[string]string{"local": "true"}
+	}
+	l.dependencies.ExternalRead = append(l.dependencies.ExternalRead, external)
+}
+
+// 监听进入EXPORT语句，导出的目标路径作为外部写依赖
+func (l *dependencyListener) EnterExportStatement(ctx *parser.ExportStatementContext) {
+	l.isOnlyComment = false
+	l.firstOpType = analyzer.StmtTypeExport
+	l.dependencies.ExternalWrite = append(l.dependencies.ExternalWrite, &analyzer.DependencyExternal{
+		Type:     analyzer.ExternalTypePath,
+		Location: unquote(ctx.StringLiteral().GetText()),
+	})
+}
+
+// 监听进入IMPORT语句，导入的源路径作为外部读依赖
+func (l *dependencyListener) EnterImportStatement(ctx *parser.ImportStatementContext) {
+	l.isOnlyComment = false
+	l.firstOpType = analyzer.StmtTypeImport
+	l.dependencies.ExternalRead = append(l.dependencies.ExternalRead, &analyzer.DependencyExternal{
+		Type:     analyzer.ExternalTypePath,
+		Location: unquote(ctx.GetPath().GetText()),
+	})
+}
+
+// 监听离开IMPORT语句，LOCATION 指定的是导入表的存储位置
+func (l *dependencyListener) ExitImportStatement(ctx *parser.ImportStatementContext) {
+	if ctx.TableLocation() != nil && len(l.writeTables) > 0 {
+		l.writeTables[len(l.writeTables)-1].Location = unquote(ctx.TableLocation().GetLocn().GetText())
+	}
+}
+
+// 监听进入INSERT OVERWRITE [LOCAL] DIRECTORY，目标目录作为外部写依赖
+func (l *dependencyListener) EnterDestination(ctx *parser.DestinationContext) {
+	if ctx.KW_DIRECTORY() == nil {
+		return
+	}
+	external := &analyzer.DependencyExternal{
+		Type:     analyzer.ExternalTypePath,
+		Location: unquote(ctx.StringLiteral().GetText()),
+	}
+	if format := ctx.TableFileFormat(); format != nil {
+		if format.GetFileformat() != nil {
+			external.Format = strings.ToLower(format.GetFileformat().GetText())
+		} else if format.GetGenericSpec() != nil && format.KW_BY() == nil {
+			external.Format = strings.ToLower(format.GetGenericSpec().GetText())
+		}
+	}
+	if ctx.GetLocal() != nil {
+		external.Properties = map[string]string{"local": "true"}
+	}
+	l.dependencies.ExternalWrite = append(l.dependencies.ExternalWrite, external)
+}
+
+// 监听进入MSCK REPAIR TABLE语句，修复分区元数据视为修改表
+func (l *dependencyListener) EnterMetastoreCheck(ctx *parser.MetastoreCheckContext) {
+	l.isOnlyComment = false
+	l.firstOpType = analyzer.StmtTypeAlterTable
+}
+
+// 监听进入RENAME TO子句，原表名和新表名都是写表
+func (l *dependencyListener) EnterAlterStatementSuffixRename(ctx *parser.AlterStatementSuffixRenameContext) {
+	if len(l.writeTables) > 0 {
+		l.writeTables[len(l.writeTables)-1].Role = analyzer.TableRoleRenameFrom
+	}
+	l.tableRole = analyzer.TableRoleRenameTo
+}
+
+// 监听离开RENAME TO子句
+func (l *dependencyListener) ExitAlterStatementSuffixRename(ctx *parser.AlterStatementSuffixRenameContext) {
+	l.tableRole = ""
+}
+
+// 监听进入EXCHANGE PARTITION子句，分区从 WITH TABLE 指定的源表移入被修改的目标表
+func (l *dependencyListener) EnterAlterStatementSuffixExchangePartition(ctx *parser.AlterStatementSuffixExchangePartitionContext) {
+	if len(l.writeTables) > 0 {
+		l.writeTables[len(l.writeTables)-1].Role = analyzer.TableRoleTarget
+	}
+	l.tableRole = analyzer.TableRoleSource
+}
+
+// 监听离开EXCHANGE PARTITION子句，源表的数据被移出，同时作为读表和写表
+func (l *dependencyListener) ExitAlterStatementSuffixExchangePartition(ctx *parser.AlterStatementSuffixExchangePartitionContext) {
+	l.tableRole = ""
+	if len(l.writeTables) < 2 {
+		return
+	}
+	// 交换的分区同时属于源表
+	target, source := l.writeTables[len(l.writeTables)-2], l.writeTables[len(l.writeTables)-1]
+	source.Partitions = target.Partitions
+	read := *source
+	l.readTables = append(l.readTables, &read)
+}
@@ -18,28 +18,68 @@ func NewDependencyAnalyzer() analyzer.DependencyAnalyzer {
 }
 
 func (a *dependencyAnalyzer) Analyze(req *analyzer.DependencyAnalyzeReq) ([]*analyzer.DependencyResult, error) {
-	// 使用SplitSQL函数拆分SQL语句
-	statements := analyzer.SplitSQL(makeLexer(req.SQL))
+	// 替换脚本变量后使用SplitSQL函数拆分SQL语句，词法分析出错时无语法拆分
+	tpl := analyzer.NewTemplate(req.SQL, req.Variables)
+	spans := analyzer.LocateSpans(tpl.SQL, analyzer.SplitSQLOrText(tpl.SQL, makeLexer(tpl.SQL), dialect))
 	var result []*analyzer.DependencyResult
-	for _, stmt := range statements {
-		ddl, err := a.ParseOne(stmt, req.DefaultCluster, req.DefaultDatabase)
+	for _, span := range spans {
+		// 过滤掉只有注释的语句
+		if span.Text == "" {
+			continue
+		}
+		stmt, start := span.Raw, span.Start
+		tpl.MapSpan(span)
+		// SET 变量赋值和 ADD JAR 等是客户端命令，不属于Hive SQL语法
+		if _, _, ok := analyzer.ParseSetCommand(stmt); ok {
+			result = append(result, &analyzer.DependencyResult{
+				Stmt:     stmt,
+				StmtType: analyzer.StmtTypeSet,
+				Read:     []*analyzer.DependencyTable{},
+				Write:    []*analyzer.DependencyTable{},
+				Span:     span,
+			})
+			continue
+		}
+		if resources, ok := analyzer.ParseAddResource(stmt); ok {
+			result = append(result, &analyzer.DependencyResult{
+				Stmt:         stmt,
+				StmtType:     analyzer.StmtTypeAddResource,
+				Read:         []*analyzer.DependencyTable{},
+				Write:        []*analyzer.DependencyTable{},
+				ExternalRead: resources,
+				Span:         span,
+			})
+			continue
+		}
+		ddl, err := a.parse(stmt, req.DefaultCluster, req.DefaultDatabase, req.Catalog)
 		if err != nil {
-			return nil, err
+			return nil, tpl.MapError(err, stmt, start)
 		}
 		if ddl != nil {
+			ddl.Span = span
 			result = append(result, ddl)
 		}
 	}
+	tpl.Restore(result)
+	if err := analyzer.PostProcess(a, result, req); err != nil {
+		return nil, err
+	}
 	return result, nil
 }
 
 // ParseOne 解析SQL语句并返回Dependencies列表
 func (a *dependencyAnalyzer) ParseOne(sql, defaultCluster, defaultDatabase string) (*analyzer.DependencyResult, error) {
-	// 创建语法分析器
-	p := makeParser(makeLexer(sql))
+	return a.parse(sql, defaultCluster, defaultDatabase, nil)
+}
+
+// parse 解析单句SQL，提供 catalog 时根据其中的分区键得到读表读取的分区
+func (a *dependencyAnalyzer) parse(sql, defaultCluster, defaultDatabase string, catalog analyzer.Catalog) (*analyzer.DependencyResult, error) {
+	// 创建语法分析器，Hive的语句规则不接受结尾的分号
+	p := makeParser(makeLexer(strings.TrimSuffix(strings.TrimSpace(sql), ";")))
 
 	// 创建自定义监听器
 	listener := newDependencyListener(defaultCluster, defaultDatabase)
+	listener.catalog = catalog
 
 	// 创建自定义错误监听器
 	errListener := newSyntaxErrorListener(listener)
@@ -58,11 +98,5 @@ func (a *dependencyAnalyzer) ParseOne(sql, defaultCluster, defaultDatabase strin
 		return nil, nil
 	}
 
-	// 设置语句和操作类型
-	listener.dependencies.Stmt = sql
-	listener.dependencies.StmtType = listener.firstOpType
-	listener.dependencies.Read = listener.readTables
-	listener.dependencies.Write = listener.writeTables
-
-	return listener.dependencies, nil
+	return listener.result(sql), nil
 }
@@ -393,3 +393,429 @@ func TestHiveDependencyAnalyzer(t *testing.T) {
 		})
 	}
 }
+
+func TestHiveDependencyAnalyzer_Template(t *testing.T) {
+	sql := `SET hivevar:dt=20240101;
+SET target=dwd_${hivevar:dt};
+INSERT OVERWRITE TABLE ${env:DB}.${hiveconf:target}
+SELECT * FROM ods_${region}.events WHERE dt = '${dt}';`
+	results, err := NewDependencyAnalyzer().Analyze(&analyzer.DependencyAnalyzeReq{
+		DefaultCluster:  "default_cluster",
+		DefaultDatabase: "default_db",
+		Type:            analyzer.EngineHive,
+		SQL:             sql,
+		Variables:       map[string]string{"env:DB": "dw"},
+	})
+	if !assert.NoError(t, err) || !assert.Len(t, results, 3) {
+		return
+	}
+	assert.Equal(t, analyzer.StmtTypeSet, results[0].StmtType)
+	assert.Equal(t, analyzer.StmtTypeSet, results[1].StmtType)
+	insert := results[2]
+	assert.Equal(t, analyzer.StmtTypeInsert, insert.StmtType)
+	assert.Equal(t, "INSERT OVERWRITE TABLE dw.dwd_20240101\nSELECT * FROM ods_${region}.events WHERE dt = '20240101';", insert.Stmt)
+	if assert.Len(t, insert.Write, 1) && assert.Len(t, insert.Read, 1) {
+		assert.Equal(t, "default_cluster.dw.dwd_20240101", insert.Write[0].String())
+		assert.Equal(t, "default_cluster.ods_*.events", insert.Read[0].String())
+	}
+
+	// 分区中的未解析变量还原为占位符原文
+	results, err = NewDependencyAnalyzer().Analyze(&analyzer.DependencyAnalyzeReq{
+		DefaultCluster:  "default_cluster",
+		DefaultDatabase: "default_db",
+		Type:            analyzer.EngineHive,
+		SQL:             "INSERT OVERWRITE TABLE dwd.orders PARTITION (dt='${dt}') SELECT * FROM ods.orders",
+	})
+	if assert.NoError(t, err) && assert.Len(t, results, 1) && assert.Len(t, results[0].Write, 1) {
+		assert.Equal(t, []*analyzer.PartitionSpec{{Values: []*analyzer.PartitionValue{{Key: "dt", Value: "${dt}"}}}}, results[0].Write[0].Partitions)
+	}
+
+	// 语法错误的位置指向替换前的脚本
+	_, err = NewDependencyAnalyzer().Analyze(&analyzer.DependencyAnalyzeReq{
+		DefaultCluster:  "default_cluster",
+		DefaultDatabase: "default_db",
+		Type:            analyzer.EngineHive,
+		SQL:             "SELECT * FROM ${db}.t WHERE WHERE",
+		Variables:       map[string]string{"db": "a_long_database_name"},
+	})
+	assert.ErrorContains(t, err, "line 1:28")
+}
+
+func TestHiveDependencyAnalyzer_Functions(t *testing.T) {
+	sql := `ADD JARS hdfs:///libs/a.jar hdfs:///libs/b.jar;
+CREATE FUNCTION udfs.mask_phone AS 'com.example.MaskPhone' USING JAR 'hdfs:///libs/mask.jar';
+INSERT OVERWRITE TABLE dwd.users SELECT udfs.mask_phone(phone), nvl(name, ''), my_lower(email) FROM ods.users;
+DROP FUNCTION udfs.mask_phone;`
+	results, err := NewDependencyAnalyzer().Analyze(&analyzer.DependencyAnalyzeReq{
+		DefaultCluster:  "default_cluster",
+		DefaultDatabase: "default_db",
+		Type:            analyzer.EngineHive,
+		SQL:             sql,
+	})
+	if !assert.NoError(t, err) || !assert.Len(t, results, 4) {
+		return
+	}
+
+	assert.Equal(t, analyzer.StmtTypeAddResource, results[0].StmtType)
+	assert.Equal(t, []*analyzer.DependencyExternal{
+		{Type: analyzer.ExternalTypeJar, Location: "hdfs:///libs/a.jar"},
+		{Type: analyzer.ExternalTypeJar, Location: "hdfs:///libs/b.jar"},
+	}, results[0].ExternalRead)
+
+	jar := &analyzer.DependencyExternal{Type: analyzer.ExternalTypeJar, Location: "hdfs:///libs/mask.jar"}
+	assert.Equal(t, analyzer.StmtTypeCreateFunction, results[1].StmtType)
+	assert.Equal(t, []*analyzer.DependencyFunction{{
+		Database:  "udfs",
+		Name:      "mask_phone",
+		ClassName: "com.example.MaskPhone",
+		Resources: []*analyzer.DependencyExternal{jar},
+	}}, results[1].FunctionWrite)
+	assert.Equal(t, []*analyzer.DependencyExternal{jar}, results[1].ExternalRead)
+
+	assert.Equal(t, analyzer.StmtTypeInsert, results[2].StmtType)
+	assert.Equal(t, []*analyzer.DependencyFunction{
+		{Database: "udfs", Name: "mask_phone"},
+		{Name: "nvl", Builtin: true},
+		{Name: "my_lower"},
+	}, results[2].FunctionRead)
+
+	assert.Equal(t, analyzer.StmtTypeDropFunction, results[3].StmtType)
+	assert.Equal(t, []*analyzer.DependencyFunction{{Database: "udfs", Name: "mask_phone"}}, results[3].FunctionWrite)
+}
+
+func TestHiveDependencyAnalyzer_DataMovement(t *testing.T) {
+	table := func(db, name string, role analyzer.TableRole) *analyzer.DependencyTable {
+		return &analyzer.DependencyTable{Cluster: "default_cluster", Database: db, Table: name, Role: role}
+	}
+	partitioned := func(t *analyzer.DependencyTable) *analyzer.DependencyTable {
+		t.Partitions = []*analyzer.PartitionSpec{{Values: []*analyzer.PartitionValue{{Key: "dt", Value: "2024-01-01"}}}}
+		return t
+	}
+	path := func(location string, local bool) *analyzer.DependencyExternal {
+		external := &analyzer.DependencyExternal{Type: analyzer.ExternalTypePath, Location: location}
+		if local {
+			external.Properties = map[string]string{"local": "true"}
+		}
+		return external
+	}
+	tests := []struct {
+		name          string
+		sql           string
+		stmtType      analyzer.StmtType
+		read          []*analyzer.DependencyTable
+		write         []*analyzer.DependencyTable
+		externalRead  []*analyzer.DependencyExternal
+		externalWrite []*analyzer.DependencyExternal
+	}{
+		{
+			name:         "load local data into partition",
+			sql:          "LOAD DATA LOCAL INPATH '/tmp/users.csv' OVERWRITE INTO TABLE ods.users PARTITION (dt='2024-01-01')",
+			stmtType:     analyzer.StmtTypeLoad,
+			read:         []*analyzer.DependencyTable{},
+			write:        []*analyzer.DependencyTable{partitioned(table("ods", "users", ""))},
+			externalRead: []*analyzer.DependencyExternal{path("/tmp/users.csv", true)},
+		},
+		{
+			name:          "export table",
+			sql:           "EXPORT TABLE ods.users PARTITION (dt='2024-01-01') TO 'hdfs:///export/users'",
+			stmtType:      analyzer.StmtTypeExport,
+			read:          []*analyzer.DependencyTable{partitioned(table("ods", "users", ""))},
+			write:         []*analyzer.DependencyTable{},
+			externalWrite: []*analyzer.DependencyExternal{path("hdfs:///export/users", false)},
+		},
+		{
+			name:     "import table with location",
+			sql:      "IMPORT EXTERNAL TABLE bak.users FROM 'hdfs:///export/users' LOCATION 'hdfs:///bak/users'",
+			stmtType: analyzer.StmtTypeImport,
+			read:     []*analyzer.DependencyTable{},
+			write: []*analyzer.DependencyTable{{
+				Cluster: "default_cluster", Database: "bak", Table: "users", Location: "hdfs:///bak/users",
+			}},
+			externalRead: []*analyzer.DependencyExternal{path("hdfs:///export/users", false)},
+		},
+		{
+			name:         "import into exported table name",
+			sql:          "IMPORT FROM 'hdfs:///export/users'",
+			stmtType:     analyzer.StmtTypeImport,
+			read:         []*analyzer.DependencyTable{},
+			write:        []*analyzer.DependencyTable{},
+			externalRead: []*analyzer.DependencyExternal{path("hdfs:///export/users", false)},
+		},
+		{
+			name:     "exchange partition",
+			sql:      "ALTER TABLE dwd.orders EXCHANGE PARTITION (dt='2024-01-01') WITH TABLE tmp.orders_staging",
+			stmtType: analyzer.StmtTypeAlterTable,
+			read:     []*analyzer.DependencyTable{partitioned(table("tmp", "orders_staging", analyzer.TableRoleSource))},
+			write: []*analyzer.DependencyTable{
+				partitioned(table("dwd", "orders", analyzer.TableRoleTarget)),
+				partitioned(table("tmp", "orders_staging", analyzer.TableRoleSource)),
+			},
+		},
+		{
+			name:     "rename table",
+			sql:      "ALTER TABLE users RENAME TO bak.users_old",
+			stmtType: analyzer.StmtTypeAlterTable,
+			read:     []*analyzer.DependencyTable{},
+			write: []*analyzer.DependencyTable{
+				table("default_db", "users", analyzer.TableRoleRenameFrom),
+				table("bak", "users_old", analyzer.TableRoleRenameTo),
+			},
+		},
+		{
+			name:     "repair partitions",
+			sql:      "MSCK REPAIR TABLE ods.users",
+			stmtType: analyzer.StmtTypeAlterTable,
+			read:     []*analyzer.DependencyTable{},
+			write:    []*analyzer.DependencyTable{table("ods", "users", "")},
+		},
+		{
+			name:     "insert overwrite local directory",
+			sql:      "INSERT OVERWRITE LOCAL DIRECTORY '/tmp/out' ROW FORMAT DELIMITED FIELDS TERMINATED BY ',' STORED AS ORC SELECT * FROM ods.users",
+			stmtType: analyzer.StmtTypeInsert,
+			read:     []*analyzer.DependencyTable{table("ods", "users", "")},
+			write:    []*analyzer.DependencyTable{},
+			externalWrite: []*analyzer.DependencyExternal{{
+				Type: analyzer.ExternalTypePath, Format: "orc", Location: "/tmp/out", Properties: map[string]string{"local": "true"},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := NewDependencyAnalyzer().Analyze(&analyzer.DependencyAnalyzeReq{
+				DefaultCluster:  "default_cluster",
+				DefaultDatabase: "default_db",
+				Type:            analyzer.EngineHive,
+				SQL:             tt.sql,
+			})
+			if !assert.NoError(t, err) || !assert.Len(t, results, 1) {
+				return
+			}
+			assert.Equal(t, tt.stmtType, results[0].StmtType)
+			assert.Equal(t, tt.read, results[0].Read)
+			assert.Equal(t, tt.write, results[0].Write)
+			assert.Equal(t, tt.externalRead, results[0].ExternalRead)
+			assert.Equal(t, tt.externalWrite, results[0].ExternalWrite)
+		})
+	}
+}
+
+func TestHiveDependencyAnalyzer_Partitions(t *testing.T) {
+	catalog := analyzer.NewMemoryCatalog(
+		&analyzer.CatalogTable{Database: "ods", Table: "orders", PartitionKeys: []string{"dt"}},
+		&analyzer.CatalogTable{Database: "ods", Table: "events", PartitionKeys: []string{"dt", "hr"}},
+	)
+	sql := `INSERT OVERWRITE TABLE dwd.orders PARTITION (dt='2024-01-01', hr)
+SELECT o.id, e.hr FROM ods.orders o JOIN ods.events e ON o.id = e.order_id
+WHERE o.dt = '2024-01-01' AND e.dt IN ('2024-01-01', '2024-01-02') AND e.hr = 1 AND o.status = 'paid';
+ALTER TABLE dwd.orders DROP IF EXISTS PARTITION (dt='2023-01-01'), PARTITION (dt='2023-01-02');
+SELECT * FROM ods.orders WHERE dt = '2024-01-01' OR dt = '2024-01-02'`
+	results, err := NewDependencyAnalyzer().Analyze(&analyzer.DependencyAnalyzeReq{
+		DefaultCluster:  "default_cluster",
+		DefaultDatabase: "default_db",
+		Type:            analyzer.EngineHive,
+		SQL:             sql,
+		Catalog:         catalog,
+	})
+	if !assert.NoError(t, err) || !assert.Len(t, results, 3) {
+		return
+	}
+	spec := func(values ...*analyzer.PartitionValue) *analyzer.PartitionSpec {
+		return &analyzer.PartitionSpec{Values: values}
+	}
+	dt := func(value string) *analyzer.PartitionValue {
+		return &analyzer.PartitionValue{Key: "dt", Value: value}
+	}
+
+	assert.Equal(t, []*analyzer.PartitionSpec{
+		spec(dt("2024-01-01"), &analyzer.PartitionValue{Key: "hr", Dynamic: true}),
+	}, results[0].Write[0].Partitions)
+	assert.Equal(t, "dt=2024-01-01/hr", results[0].Write[0].Partitions[0].String())
+	assert.Equal(t, []*analyzer.PartitionSpec{spec(dt("2024-01-01"))}, results[0].Read[0].Partitions)
+	hr := &analyzer.PartitionValue{Key: "hr", Value: "1"}
+	assert.Equal(t, []*analyzer.PartitionSpec{
+		spec(dt("2024-01-01"), hr),
+		spec(dt("2024-01-02"), hr),
+	}, results[0].Read[1].Partitions)
+
+	assert.Equal(t, []*analyzer.PartitionSpec{spec(dt("2023-01-01")), spec(dt("2023-01-02"))}, results[1].Write[0].Partitions)
+
+	// OR 连接的条件不限定分区
+	assert.Nil(t, results[2].Read[0].Partitions)
+}
+
+func TestHiveDependencyAnalyzer_MergeAndMaterializedViews(t *testing.T) {
+	table := func(db, name string) *analyzer.DependencyTable {
+		return &analyzer.DependencyTable{Cluster: "default_cluster", Database: db, Table: name}
+	}
+	tests := []struct {
+		name     string
+		sql      string
+		stmtType analyzer.StmtType
+		read     []*analyzer.DependencyTable
+		write    []*analyzer.DependencyTable
+	}{
+		{
+			name: "merge from table",
+			sql: `MERGE INTO dwd.users AS t USING ods.users s ON t.id = s.id
+WHEN MATCHED AND s.deleted THEN DELETE
+WHEN MATCHED THEN UPDATE SET name = s.name
+WHEN NOT MATCHED THEN INSERT VALUES (s.id, s.name)`,
+			stmtType: analyzer.StmtTypeMerge,
+			read:     []*analyzer.DependencyTable{table("ods", "users")},
+			write:    []*analyzer.DependencyTable{table("dwd", "users")},
+		},
+		{
+			name: "merge from subquery",
+			sql: `MERGE INTO dwd.users t USING (SELECT u.id, u.name FROM ods.users u JOIN ods.accounts a ON u.id = a.user_id) s
+ON t.id = s.id WHEN NOT MATCHED THEN INSERT VALUES (s.id, s.name)`,
+			stmtType: analyzer.StmtTypeMerge,
+			read:     []*analyzer.DependencyTable{table("ods", "users"), table("ods", "accounts")},
+			write:    []*analyzer.DependencyTable{table("dwd", "users")},
+		},
+		{
+			name:     "create materialized view",
+			sql:      "CREATE MATERIALIZED VIEW dws.user_orders STORED AS ORC LOCATION 'hdfs:///mv/user_orders' AS SELECT u.id, count(*) FROM ods.users u JOIN ods.orders o ON u.id = o.user_id GROUP BY u.id",
+			stmtType: analyzer.StmtTypeCreateMaterializedView,
+			read:     []*analyzer.DependencyTable{table("ods", "users"), table("ods", "orders")},
+			write: []*analyzer.DependencyTable{{
+				Cluster: "default_cluster", Database: "dws", Table: "user_orders", Location: "hdfs:///mv/user_orders",
+			}},
+		},
+		{
+			name:     "rebuild materialized view",
+			sql:      "ALTER MATERIALIZED VIEW dws.user_orders REBUILD",
+			stmtType: analyzer.StmtTypeRefreshMaterializedView,
+			read:     []*analyzer.DependencyTable{},
+			write:    []*analyzer.DependencyTable{table("dws", "user_orders")},
+		},
+		{
+			name:     "disable rewrite",
+			sql:      "ALTER MATERIALIZED VIEW dws.user_orders DISABLE REWRITE",
+			stmtType: analyzer.StmtTypeAlterMaterializedView,
+			read:     []*analyzer.DependencyTable{},
+			write:    []*analyzer.DependencyTable{table("dws", "user_orders")},
+		},
+		{
+			name:     "drop materialized view",
+			sql:      "DROP MATERIALIZED VIEW IF EXISTS dws.user_orders",
+			stmtType: analyzer.StmtTypeDropMaterializedView,
+			read:     []*analyzer.DependencyTable{},
+			write:    []*analyzer.DependencyTable{table("dws", "user_orders")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := NewDependencyAnalyzer().Analyze(&analyzer.DependencyAnalyzeReq{
+				DefaultCluster:  "default_cluster",
+				DefaultDatabase: "default_db",
+				Type:            analyzer.EngineHive,
+				SQL:             tt.sql,
+			})
+			if !assert.NoError(t, err) || !assert.Len(t, results, 1) {
+				return
+			}
+			assert.Equal(t, tt.stmtType, results[0].StmtType)
+			assert.Equal(t, tt.read, results[0].Read)
+			assert.Equal(t, tt.write, results[0].Write)
+		})
+	}
+
+	t.Run("rebuild reads base tables from catalog", func(t *testing.T) {
+		catalog := analyzer.NewMemoryCatalog(
+			&analyzer.CatalogTable{Database: "ods", Table: "orders"},
+			&analyzer.CatalogTable{
+				Database:       "dws",
+				Table:          "user_orders",
+				Kind:           analyzer.TableKindMaterializedView,
+				ViewDefinition: "CREATE MATERIALIZED VIEW user_orders AS SELECT user_id, count(*) FROM ods.orders JOIN users ON users.id = orders.user_id GROUP BY user_id",
+			},
+		)
+		results, err := NewDependencyAnalyzer().Analyze(&analyzer.DependencyAnalyzeReq{
+			DefaultCluster:  "default_cluster",
+			DefaultDatabase: "default_db",
+			Type:            analyzer.EngineHive,
+			SQL:             "ALTER MATERIALIZED VIEW dws.user_orders REBUILD; SELECT * FROM dws.user_orders",
+			Catalog:         catalog,
+			ExpandViews:     true,
+		})
+		if !assert.NoError(t, err) || !assert.Len(t, results, 2) {
+			return
+		}
+		assert.Equal(t, []*analyzer.DependencyTable{
+			{Cluster: "default_cluster", Database: "ods", Table: "orders", Kind: analyzer.TableKindTable},
+			{Cluster: "default_cluster", Database: "dws", Table: "users"},
+		}, results[0].Read)
+		assert.Equal(t, analyzer.TableKindMaterializedView, results[0].Write[0].Kind)
+		// 读取物化视图不会展开为基表
+		assert.Len(t, results[1].Read, 1)
+	})
+
+	t.Run("scheduled rebuild reads base tables from catalog", func(t *testing.T) {
+		catalog := analyzer.NewMemoryCatalog(
+			&analyzer.CatalogTable{Database: "ods", Table: "orders"},
+			&analyzer.CatalogTable{
+				Database:       "dws",
+				Table:          "order_stats",
+				Kind:           analyzer.TableKindMaterializedView,
+				ViewDefinition: "CREATE MATERIALIZED VIEW order_stats AS SELECT count(*) FROM ods.orders",
+			},
+		)
+		results, err := NewDependencyAnalyzer().Analyze(&analyzer.DependencyAnalyzeReq{
+			DefaultCluster:  "default_cluster",
+			DefaultDatabase: "default_db",
+			Type:            analyzer.EngineHive,
+			SQL:             "CREATE SCHEDULED QUERY sq CRON '0 0 2 * * ? *' DEFINED AS ALTER MATERIALIZED VIEW dws.order_stats REBUILD",
+			Catalog:         catalog,
+		})
+		if !assert.NoError(t, err) || !assert.Len(t, results, 1) || !assert.Len(t, results[0].Children, 1) {
+			return
+		}
+		read := []*analyzer.DependencyTable{{Cluster: "default_cluster", Database: "ods", Table: "orders", Kind: analyzer.TableKindTable}}
+		assert.Equal(t, read, results[0].Children[0].Read)
+		assert.Equal(t, analyzer.TableKindMaterializedView, results[0].Children[0].Write[0].Kind)
+		// 父语句的读表包含子语句补充的读表
+		assert.Equal(t, read, results[0].Read)
+	})
+}
+
+func TestHiveDependencyAnalyzer_ScheduledQuery(t *testing.T) {
+	sql := `CREATE SCHEDULED QUERY daily_users EVERY 1 DAY AT '2024-01-01 02:00:00' EXECUTED AS 'etl' DISABLE
+DEFINED AS INSERT OVERWRITE TABLE dws.users SELECT * FROM ods.users;
+ALTER SCHEDULED QUERY daily_users CRON '0 0 2 * * ? *';
+ALTER SCHEDULED QUERY daily_users ENABLE;
+DROP SCHEDULED QUERY daily_users`
+	results, err := NewDependencyAnalyzer().Analyze(&analyzer.DependencyAnalyzeReq{
+		DefaultCluster:  "default_cluster",
+		DefaultDatabase: "default_db",
+		Type:            analyzer.EngineHive,
+		SQL:             sql,
+	})
+	if !assert.NoError(t, err) || !assert.Len(t, results, 4) {
+		return
+	}
+	disabled, enabled := false, true
+	read := []*analyzer.DependencyTable{{Cluster: "default_cluster", Database: "ods", Table: "users"}}
+	write := []*analyzer.DependencyTable{{Cluster: "default_cluster", Database: "dws", Table: "users"}}
+
+	assert.Equal(t, analyzer.StmtTypeCreateScheduledQuery, results[0].StmtType)
+	assert.Equal(t, &analyzer.Schedule{
+		Name: "daily_users", Every: "1 DAY", Offset: "2024-01-01 02:00:00", ExecutedAs: "etl", Enabled: &disabled,
+	}, results[0].Schedule)
+	assert.Equal(t, read, results[0].Read)
+	assert.Equal(t, write, results[0].Write)
+	if assert.Len(t, results[0].Children, 1) {
+		child := results[0].Children[0]
+		assert.Equal(t, "INSERT OVERWRITE TABLE dws.users SELECT * FROM ods.users", child.Stmt)
+		assert.Equal(t, analyzer.StmtTypeInsert, child.StmtType)
+		assert.Equal(t, read, child.Read)
+		assert.Equal(t, write, child.Write)
+	}
+
+	assert.Equal(t, analyzer.StmtTypeAlterScheduledQuery, results[1].StmtType)
+	assert.Equal(t, &analyzer.Schedule{Name: "daily_users", Cron: "0 0 2 * * ? *"}, results[1].Schedule)
+	assert.Equal(t, analyzer.StmtTypeAlterScheduledQuery, results[2].StmtType)
+	assert.Equal(t, &analyzer.Schedule{Name: "daily_users", Enabled: &enabled}, results[2].Schedule)
+	assert.Equal(t, analyzer.StmtTypeDropScheduledQuery, results[3].StmtType)
+	assert.Equal(t, &analyzer.Schedule{Name: "daily_users"}, results[3].Schedule)
+	assert.Empty(t, results[3].Children)
+}
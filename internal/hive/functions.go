@@ -0,0 +1,100 @@
+package hive
+
+import (
+	"strings"
+
+	"github.com/Edsuns/sql-parser/analyzer"
+	"github.com/Edsuns/sql-parser/internal/hive/parser"
+)
+
+// builtinFunctions Hive 内置函数，用于区分内置函数和用户自定义函数
+var builtinFunctions = analyzer.NewNameSet(`
+abs acos add_months aes_decrypt aes_encrypt and array array_contains ascii asin assert_true atan avg
+base64 between bigint bin binary boolean bround cardinality_violation case cast cbrt ceil ceiling char
+character_length chr coalesce collect_list collect_set compute_stats concat concat_ws context_ngrams conv
+corr cos count covar_pop covar_samp crc32 create_union cume_dist current_authorizer current_database
+current_date current_groups current_timestamp current_user date date_add date_format date_sub datediff
+day dayofmonth dayofweek decimal decode degrees dense_rank div double e elt encode ewah_bitmap
+ewah_bitmap_and ewah_bitmap_empty ewah_bitmap_or exp explode extract_union factorial field find_in_set
+first_value float floor floor_day floor_hour floor_minute floor_month floor_quarter floor_second
+floor_week floor_year format_number from_unixtime from_utc_timestamp get_json_object get_splits greatest
+grouping grouping__id hash hex histogram_numeric hour if in in_file index initcap inline instr internal_interval
+isfalse isnotfalse isnotnull isnottrue isnull istrue java_method json_tuple lag last_day last_value lcase
+lead least length levenshtein like likeall likeany ln locate log log10 log2 logged_in_user lower lpad
+ltrim map map_keys map_values mask mask_first_n mask_hash mask_last_n mask_show_first_n mask_show_last_n
+matchpath max md5 min minute mod month months_between murmur_hash named_struct negative next_day ngrams
+noop noopstreaming noopwithmap noopwithmapstreaming not ntile nullif nvl octet_length or parse_url
+parse_url_tuple percent_rank percentile percentile_approx pi pmod posexplode positive pow power printf
+quarter radians rand rank reflect reflect2 regexp regexp_extract regexp_replace regr_avgx regr_avgy
+regr_count regr_intercept regr_r2 regr_slope regr_sxx regr_sxy regr_syy repeat replace replicate_rows
+restrict_information_schema reverse rlike round row_number rpad rtrim second sentences sha sha1 sha2
+shiftleft shiftright shiftrightunsigned sign sin size sort_array sort_array_by soundex space split sq_count_check
+sqrt stack std stddev stddev_pop stddev_samp str_to_map string struct substr substring substring_index
+sum surrogate_key tan timestamp tinyint to_date to_epoch_milli to_unix_timestamp to_utc_timestamp
+translate trim trunc ucase unbase64 unhex unix_timestamp upper uuid var_pop var_samp variance version
+weekofyear when width_bucket windowingtablefunction xpath xpath_boolean xpath_double xpath_float xpath_int
+xpath_long xpath_number xpath_short xpath_string year
+`)
+
+// 监听进入函数调用，记录调用的函数
+func (l *dependencyListener) EnterFunction_(ctx *parser.Function_Context) {
+	name := ctx.FunctionName()
+	if name == nil {
+		// TRIM 等特殊语法的内置函数
+		return
+	}
+	var parts []string
+	if name.FunctionIdentifier() != nil {
+		for _, part := range name.FunctionIdentifier().AllId_() {
+			parts = append(parts, part.GetText())
+		}
+	} else {
+		parts = []string{name.GetText()}
+	}
+	f := analyzer.NewFunction(parts, builtinFunctions)
+	l.dependencies.FunctionRead = analyzer.AppendFunction(l.dependencies.FunctionRead, f)
+}
+
+// 监听进入创建函数语句，USING 引用的资源同时作为外部读依赖
+func (l *dependencyListener) EnterCreateFunctionStatement(ctx *parser.CreateFunctionStatementContext) {
+	f := l.functionWrite(analyzer.StmtTypeCreateFunction, ctx.FunctionIdentifier(), ctx.KW_TEMPORARY() != nil)
+	f.ClassName = unquote(ctx.StringLiteral().GetText())
+	if ctx.ResourceList() == nil {
+		return
+	}
+	for _, resource := range ctx.ResourceList().AllResource() {
+		external := &analyzer.DependencyExternal{
+			Type:     analyzer.ResourceType(resource.ResourceType().GetText()),
+			Location: unquote(resource.StringLiteral().GetText()),
+		}
+		f.Resources = append(f.Resources, external)
+		l.dependencies.ExternalRead = append(l.dependencies.ExternalRead, external)
+	}
+}
+
+// 监听进入删除函数语句
+func (l *dependencyListener) EnterDropFunctionStatement(ctx *parser.DropFunctionStatementContext) {
+	l.functionWrite(analyzer.StmtTypeDropFunction, ctx.FunctionIdentifier(), ctx.KW_TEMPORARY() != nil)
+}
+
+// functionWrite 记录创建或删除的函数
+func (l *dependencyListener) functionWrite(stmtType analyzer.StmtType, ctx parser.IFunctionIdentifierContext, temporary bool) *analyzer.DependencyFunction {
+	l.isOnlyComment = false
+	l.firstOpType = stmtType
+	f := &analyzer.DependencyFunction{Temporary: temporary}
+	ids := ctx.AllId_()
+	f.Name = ids[len(ids)-1].GetText()
+	if len(ids) > 1 {
+		f.Database = ids[0].GetText()
+	}
+	l.dependencies.FunctionWrite = append(l.dependencies.FunctionWrite, f)
+	return f
+}
+
+// unquote 去掉字符串字面量的引号
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return strings.TrimSpace(s)
+}
@@ -39,6 +39,21 @@ type dependencyListener struct {
 
 	// 标志：是否正在处理SELECT语句的FROM子句（源表）
 	isProcessingSourceTable bool
+
+	// 标志：是否在创建临时表
+	isTemporary bool
+
+	// 之后提取的表在语句中的角色，例如重命名后的表名
+	tableRole analyzer.TableRole
+
+	// 最近提取的表，之后的分区限定属于该表
+	lastTable *analyzer.DependencyTable
+
+	// 表名节点对应的表，用于将WHERE条件关联到读表
+	tables map[parser.ITableNameContext]*analyzer.DependencyTable
+
+	// 提供时根据其中的分区键得到读表读取的分区
+	catalog analyzer.Catalog
 }
 
 // newDependencyListener 创建一个新的DependencyListener实例
@@ -54,6 +69,7 @@ func newDependencyListener(defaultCluster, defaultDatabase string) *dependencyLi
 		readTables:              []*analyzer.DependencyTable{},
 		writeTables:             []*analyzer.DependencyTable{},
 		cteTables:               make(map[string]bool),
+		tables:                  make(map[parser.ITableNameContext]*analyzer.DependencyTable),
 		isProcessingSourceTable: false,
 		stmtTypeMap: map[int]analyzer.StmtType{
 			parser.HiveParserRULE_selectStatement:        analyzer.StmtTypeSelect,
@@ -70,6 +86,15 @@ func newDependencyListener(defaultCluster, defaultDatabase string) *dependencyLi
 	}
 }
 
+// result 设置语句和操作类型，返回解析结果
+func (l *dependencyListener) result(stmt string) *analyzer.DependencyResult {
+	l.dependencies.Stmt = stmt
+	l.dependencies.StmtType = l.firstOpType
+	l.dependencies.Read = l.readTables
+	l.dependencies.Write = l.writeTables
+	return l.dependencies
+}
+
 // 辅助函数：提取表信息
 func (l *dependencyListener) extractTable(ctx *parser.TableNameContext) {
 	if ctx == nil {
@@ -99,7 +124,10 @@ func (l *dependencyListener) extractTable(ctx *parser.TableNameContext) {
 		Cluster:  l.defaultCluster,
 		Database: db,
 		Table:    table,
+		Role:     l.tableRole,
 	}
+	l.tables[ctx] = tableDep
+	l.lastTable = tableDep
 
 	// 根据当前上下文判断是读表还是写表
 	if l.isProcessingSourceTable {
@@ -108,14 +136,17 @@ func (l *dependencyListener) extractTable(ctx *parser.TableNameContext) {
 	} else {
 		// 不是FROM子句，是目标表，根据语句类型添加
 		switch l.firstOpType {
-		case analyzer.StmtTypeSelect:
-			// SELECT语句，所有表都是读表
+		case analyzer.StmtTypeSelect, analyzer.StmtTypeExport:
+			// SELECT和EXPORT语句，所有表都是读表
 			l.readTables = append(l.readTables, tableDep)
-		case analyzer.StmtTypeInsert, analyzer.StmtTypeUpdate, analyzer.StmtTypeDelete,
+		case analyzer.StmtTypeInsert, analyzer.StmtTypeUpdate, analyzer.StmtTypeDelete, analyzer.StmtTypeMerge,
 			analyzer.StmtTypeCreateTable, analyzer.StmtTypeAlterTable,
 			analyzer.StmtTypeDropTable, analyzer.StmtTypeTruncate,
-			analyzer.StmtTypeCreateView:
+			analyzer.StmtTypeCreateView, analyzer.StmtTypeLoad, analyzer.StmtTypeImport,
+			analyzer.StmtTypeCreateMaterializedView, analyzer.StmtTypeAlterMaterializedView,
+			analyzer.StmtTypeRefreshMaterializedView, analyzer.StmtTypeDropMaterializedView:
 			// 这些语句中的表都是目标表，添加到写表
+			tableDep.Temporary = l.isTemporary
 			l.writeTables = append(l.writeTables, tableDep)
 		}
 	}
@@ -192,10 +223,31 @@ func (l *dependencyListener) EnterDeleteStatement(ctx *parser.DeleteStatementCon
 	l.firstOpType = analyzer.StmtTypeDelete
 }
 
+// 监听进入合并语句，INTO 的目标表是写表
+func (l *dependencyListener) EnterMergeStatement(ctx *parser.MergeStatementContext) {
+	l.isOnlyComment = false
+	l.firstOpType = analyzer.StmtTypeMerge
+}
+
+// 监听进入连接的数据源，MERGE 语句 USING 的表和子查询中的表是读表
+func (l *dependencyListener) EnterJoinSourcePart(ctx *parser.JoinSourcePartContext) {
+	if _, ok := ctx.GetParent().(*parser.MergeStatementContext); ok {
+		l.isProcessingSourceTable = true
+	}
+}
+
+// 监听离开连接的数据源
+func (l *dependencyListener) ExitJoinSourcePart(ctx *parser.JoinSourcePartContext) {
+	if _, ok := ctx.GetParent().(*parser.MergeStatementContext); ok {
+		l.isProcessingSourceTable = false
+	}
+}
+
 // 监听进入创建表语句
 func (l *dependencyListener) EnterCreateTableStatement(ctx *parser.CreateTableStatementContext) {
 	l.isOnlyComment = false
 	l.firstOpType = analyzer.StmtTypeCreateTable
+	l.isTemporary = ctx.KW_TEMPORARY() != nil
 }
 
 // 监听进入修改表语句
@@ -0,0 +1,35 @@
+package hive
+
+import (
+	"github.com/Edsuns/sql-parser/analyzer"
+	"github.com/Edsuns/sql-parser/internal/hive/parser"
+)
+
+// 监听进入创建物化视图语句，物化视图是写表，定义中查询的基表是读表
+func (l *dependencyListener) EnterCreateMaterializedViewStatement(ctx *parser.CreateMaterializedViewStatementContext) {
+	l.isOnlyComment = false
+	l.firstOpType = analyzer.StmtTypeCreateMaterializedView
+}
+
+// 监听离开创建物化视图语句，记录 LOCATION 指定的存储位置
+func (l *dependencyListener) ExitCreateMaterializedViewStatement(ctx *parser.CreateMaterializedViewStatementContext) {
+	if mv := l.tables[ctx.GetName()]; mv != nil && ctx.TableLocation() != nil {
+		mv.Location = unquote(ctx.TableLocation().GetLocn().GetText())
+	}
+}
+
+// 监听进入 ENABLE/DISABLE REWRITE 子句
+func (l *dependencyListener) EnterAlterMaterializedViewSuffixRewrite(ctx *parser.AlterMaterializedViewSuffixRewriteContext) {
+	l.firstOpType = analyzer.StmtTypeAlterMaterializedView
+}
+
+// 监听进入 REBUILD 子句，重新计算物化视图，提供 Catalog 时由 analyzer.AddRefreshReads 补充读取的基表
+func (l *dependencyListener) EnterAlterMaterializedViewSuffixRebuild(ctx *parser.AlterMaterializedViewSuffixRebuildContext) {
+	l.firstOpType = analyzer.StmtTypeRefreshMaterializedView
+}
+
+// 监听进入删除物化视图语句
+func (l *dependencyListener) EnterDropMaterializedViewStatement(ctx *parser.DropMaterializedViewStatementContext) {
+	l.isOnlyComment = false
+	l.firstOpType = analyzer.StmtTypeDropMaterializedView
+}
@@ -1,10 +1,14 @@
 package hive
 
 import (
+	"github.com/Edsuns/sql-parser/analyzer"
 	"github.com/Edsuns/sql-parser/internal/hive/parser"
 	"github.com/antlr4-go/antlr/v4"
 )
 
+// dialect 词法分析出错时无语法拆分SQL语句使用的词法规则
+var dialect = analyzer.Dialect{BackslashEscapes: true}
+
 func makeLexer(sql string) *parser.HiveLexer {
 	// 创建字符流
 	input := antlr.NewInputStream(sql)
@@ -0,0 +1,79 @@
+package hive
+
+import (
+	"github.com/Edsuns/sql-parser/analyzer"
+	"github.com/Edsuns/sql-parser/internal/hive/parser"
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// 监听进入分区限定，例如 INSERT OVERWRITE TABLE t PARTITION (dt='2024-01-01', hr)，分区属于前面的表
+func (l *dependencyListener) EnterPartitionSpec(ctx *parser.PartitionSpecContext) {
+	if l.lastTable == nil {
+		return
+	}
+	spec := &analyzer.PartitionSpec{}
+	for _, val := range ctx.AllPartitionVal() {
+		value := &analyzer.PartitionValue{Key: val.Id_().GetText()}
+		if val.Constant() != nil {
+			value.Value = unquote(val.Constant().GetText())
+		} else {
+			// 没有取值的分区键为动态分区
+			value.Dynamic = true
+		}
+		spec.Values = append(spec.Values, value)
+	}
+	l.lastTable.Partitions = append(l.lastTable.Partitions, spec)
+}
+
+// 监听进入删除分区的限定，例如 DROP PARTITION (dt='2024-01-01')，只记录等值条件
+func (l *dependencyListener) EnterPartitionSelectorSpec(ctx *parser.PartitionSelectorSpecContext) {
+	if l.lastTable == nil {
+		return
+	}
+	spec := &analyzer.PartitionSpec{}
+	for _, val := range ctx.AllPartitionSelectorVal() {
+		if val.PartitionSelectorOperator().GetText() != "=" {
+			// 范围条件无法确定具体的分区
+			return
+		}
+		spec.Values = append(spec.Values, &analyzer.PartitionValue{
+			Key:   val.Id_().GetText(),
+			Value: unquote(val.Constant().GetText()),
+		})
+	}
+	l.lastTable.Partitions = append(l.lastTable.Partitions, spec)
+}
+
+// 监听进入WHERE子句，根据 Catalog 中的分区键得到同一查询中读表读取的分区
+func (l *dependencyListener) EnterWhereClause(ctx *parser.WhereClauseContext) {
+	query, ok := ctx.GetParent().(*parser.AtomSelectStatementContext)
+	if l.catalog == nil || !ok || query.FromClause() == nil {
+		return
+	}
+	filters := analyzer.ParseFilters(analyzer.ContextTokens(ctx.GetParser().GetTokenStream(), ctx.SearchCondition()))
+	for _, source := range tableSources(query.FromClause(), nil) {
+		table := l.tables[source.GetTabname()]
+		if table == nil {
+			continue
+		}
+		alias := ""
+		if source.GetAlias() != nil {
+			alias = source.GetAlias().GetText()
+		}
+		analyzer.ApplyFilters(l.catalog, table, alias, filters)
+	}
+}
+
+// tableSources 查找FROM子句中的表，不包括子查询中的表
+func tableSources(tree antlr.Tree, sources []*parser.TableSourceContext) []*parser.TableSourceContext {
+	switch node := tree.(type) {
+	case *parser.TableSourceContext:
+		return append(sources, node)
+	case *parser.SubQuerySourceContext, *parser.SubQueryExpressionContext:
+		return sources
+	}
+	for _, child := range tree.GetChildren() {
+		sources = tableSources(child, sources)
+	}
+	return sources
+}
@@ -0,0 +1,80 @@
+package hive
+
+import (
+	"github.com/Edsuns/sql-parser/analyzer"
+	"github.com/Edsuns/sql-parser/internal/hive/parser"
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// 监听进入创建定时查询语句，嵌入语句的读写表同时作为该语句的读写表
+func (l *dependencyListener) EnterCreateScheduledQueryStatement(ctx *parser.CreateScheduledQueryStatementContext) {
+	l.isOnlyComment = false
+	l.dependencies.Schedule = &analyzer.Schedule{Name: ctx.GetName().GetText()}
+}
+
+// 监听离开创建定时查询语句，语句类型不受嵌入语句影响
+func (l *dependencyListener) ExitCreateScheduledQueryStatement(ctx *parser.CreateScheduledQueryStatementContext) {
+	l.firstOpType = analyzer.StmtTypeCreateScheduledQuery
+}
+
+// 监听进入修改定时查询语句，只记录被修改的调度信息
+func (l *dependencyListener) EnterAlterScheduledQueryStatement(ctx *parser.AlterScheduledQueryStatementContext) {
+	l.isOnlyComment = false
+	l.dependencies.Schedule = &analyzer.Schedule{Name: ctx.GetName().GetText()}
+}
+
+// 监听离开修改定时查询语句
+func (l *dependencyListener) ExitAlterScheduledQueryStatement(ctx *parser.AlterScheduledQueryStatementContext) {
+	l.firstOpType = analyzer.StmtTypeAlterScheduledQuery
+}
+
+// 监听进入删除定时查询语句
+func (l *dependencyListener) EnterDropScheduledQueryStatement(ctx *parser.DropScheduledQueryStatementContext) {
+	l.isOnlyComment = false
+	l.firstOpType = analyzer.StmtTypeDropScheduledQuery
+	l.dependencies.Schedule = &analyzer.Schedule{Name: ctx.GetName().GetText()}
+}
+
+// 监听进入调度周期，CRON 表达式或 EVERY n 单位 [AT|OFFSET BY 'ts']
+func (l *dependencyListener) EnterScheduleSpec(ctx *parser.ScheduleSpecContext) {
+	schedule := l.dependencies.Schedule
+	if schedule == nil {
+		return
+	}
+	if ctx.GetCronString() != nil {
+		schedule.Cron = unquote(ctx.GetCronString().GetText())
+		return
+	}
+	schedule.Every = ctx.GetQualifier().GetText()
+	if ctx.GetValue() != nil {
+		schedule.Every = ctx.GetValue().GetText() + " " + schedule.Every
+	}
+	if ctx.GetOffsetTs() != nil {
+		schedule.Offset = unquote(ctx.GetOffsetTs().GetText())
+	}
+}
+
+// 监听进入 EXECUTED AS 子句
+func (l *dependencyListener) EnterExecutedAsSpec(ctx *parser.ExecutedAsSpecContext) {
+	if l.dependencies.Schedule != nil {
+		l.dependencies.Schedule.ExecutedAs = unquote(ctx.GetExecutedAs().GetText())
+	}
+}
+
+// 监听进入 ENABLE/DISABLE，只处理定时查询的启用状态，约束的 ENABLE/DISABLE 不在此处理
+func (l *dependencyListener) EnterEnableSpecification(ctx *parser.EnableSpecificationContext) {
+	switch ctx.GetParent().(type) {
+	case *parser.CreateScheduledQueryStatementContext, *parser.AlterScheduledQueryChangeContext:
+		enabled := ctx.KW_ENABLE() != nil
+		l.dependencies.Schedule.Enabled = &enabled
+	}
+}
+
+// 监听离开 DEFINED AS 子句，嵌入的语句单独分析后作为子语句
+func (l *dependencyListener) ExitDefinedAsSpec(ctx *parser.DefinedAsSpecContext) {
+	stmt := ctx.Statement().GetChild(0).(antlr.ParserRuleContext)
+	child := newDependencyListener(l.defaultCluster, l.defaultDatabase)
+	child.catalog = l.catalog
+	antlr.ParseTreeWalkerDefault.Walk(child, stmt)
+	l.dependencies.Children = append(l.dependencies.Children, child.result(analyzer.OriginalText(stmt)))
+}
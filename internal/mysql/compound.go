@@ -0,0 +1,99 @@
+package mysql
+
+import (
+	"github.com/Edsuns/sql-parser/analyzer"
+	"github.com/Edsuns/sql-parser/internal/mysql/parser"
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// compoundBuilder 把存储程序的程序体构建为结果树，每条子语句单独分析读写表和类型
+type compoundBuilder struct {
+	defaultCluster  string
+	defaultDatabase string
+}
+
+// statement 构建程序体中的一条语句
+func (b *compoundBuilder) statement(ctx parser.ICompoundStatementContext) *analyzer.DependencyResult {
+	switch {
+	case ctx.SimpleStatement() != nil:
+		result := b.walk(ctx, ctx.SimpleStatement())
+		if ctx.SimpleStatement().SetStatement() != nil {
+			result.StmtType = analyzer.StmtTypeSet
+		}
+		return result
+	case ctx.LabeledBlock() != nil:
+		return b.block(ctx, ctx.LabeledBlock().BeginEndBlock())
+	case ctx.UnlabeledBlock() != nil:
+		return b.block(ctx, ctx.UnlabeledBlock().BeginEndBlock())
+	default:
+		// IF、CASE、WHILE、REPEAT、LOOP、LEAVE、ITERATE、RETURN 及游标操作
+		return b.controlFlow(ctx)
+	}
+}
+
+// block 构建 BEGIN ... END 语句块，声明和语句依次作为子语句
+func (b *compoundBuilder) block(ctx antlr.ParserRuleContext, block parser.IBeginEndBlockContext) *analyzer.DependencyResult {
+	result := analyzer.NewCompoundResult(ctx, analyzer.StmtTypeCompound)
+	if declarations := block.SpDeclarations(); declarations != nil {
+		for _, declaration := range declarations.AllSpDeclaration() {
+			analyzer.AddChild(result, b.declaration(declaration))
+		}
+	}
+	if list := block.CompoundStatementList(); list != nil {
+		b.addList(result, list)
+	}
+	return result
+}
+
+// declaration 构建变量、条件、游标或异常处理器的声明，游标的查询和变量的默认值读取的表属于该声明
+func (b *compoundBuilder) declaration(ctx parser.ISpDeclarationContext) *analyzer.DependencyResult {
+	if handler := ctx.HandlerDeclaration(); handler != nil {
+		result := analyzer.NewCompoundResult(ctx, analyzer.StmtTypeDeclare)
+		analyzer.AddChild(result, b.statement(handler.CompoundStatement()))
+		return result
+	}
+	result := b.walk(ctx, ctx)
+	result.StmtType = analyzer.StmtTypeDeclare
+	return result
+}
+
+// controlFlow 构建控制流语句，条件表达式中读取的表属于该语句本身
+func (b *compoundBuilder) controlFlow(ctx parser.ICompoundStatementContext) *analyzer.DependencyResult {
+	result := analyzer.NewCompoundResult(ctx, analyzer.StmtTypeControlFlow)
+	var visit func(tree antlr.Tree)
+	visit = func(tree antlr.Tree) {
+		for _, child := range tree.GetChildren() {
+			switch c := child.(type) {
+			case parser.ICompoundStatementListContext:
+				b.addList(result, c)
+			case *parser.IfStatementContext, *parser.IfBodyContext, *parser.ThenStatementContext,
+				*parser.CaseStatementContext, *parser.ElseStatementContext,
+				*parser.LabeledControlContext, *parser.UnlabeledControlContext,
+				*parser.LoopBlockContext, *parser.WhileDoBlockContext, *parser.RepeatUntilBlockContext:
+				visit(c)
+			case antlr.ParserRuleContext:
+				condition := b.walk(c, c)
+				analyzer.MergeTables(result, condition)
+			}
+		}
+	}
+	visit(ctx)
+	return result
+}
+
+// addList 添加语句列表中的所有语句
+func (b *compoundBuilder) addList(parent *analyzer.DependencyResult, list parser.ICompoundStatementListContext) {
+	for _, stmt := range list.AllCompoundStatement() {
+		analyzer.AddChild(parent, b.statement(stmt))
+	}
+}
+
+// walk 使用新的监听器分析语法树，text 为结果中的语句文本
+func (b *compoundBuilder) walk(text antlr.ParserRuleContext, tree antlr.ParseTree) *analyzer.DependencyResult {
+	listener := newDependencyListener(b.defaultCluster, b.defaultDatabase)
+	antlr.ParseTreeWalkerDefault.Walk(listener, tree)
+	result := listener.dependencies
+	result.Stmt = analyzer.OriginalText(text)
+	result.StmtType = listener.firstOpType
+	return result
+}
@@ -0,0 +1,65 @@
+package mysql
+
+import (
+	"github.com/Edsuns/sql-parser/analyzer"
+	"github.com/Edsuns/sql-parser/internal/mysql/parser"
+)
+
+// EnterLoadStatement 进入LOAD DATA/XML语句时调用，导入的文件路径作为外部读依赖
+func (l *dependencyListener) EnterLoadStatement(ctx *parser.LoadStatementContext) {
+	l.curOpType = analyzer.StmtTypeLoad
+	l.onWriteStmt()
+	external := &analyzer.DependencyExternal{
+		Type:     analyzer.ExternalTypePath,
+		Location: unquote(ctx.TextStringLiteral().GetText()),
+	}
+	if ctx.DataOrXml().XML_SYMBOL() != nil {
+		external.Format = "xml"
+	}
+	if ctx.LOCAL_SYMBOL() != nil {
+		external.Properties = map[string]string{"local": "true"}
+	}
+	l.dependencies.ExternalRead = append(l.dependencies.ExternalRead, external)
+}
+
+// EnterIntoClause 进入查询的INTO子句时调用，INTO OUTFILE/DUMPFILE 的文件路径作为外部写依赖，
+// INTO 变量不产生依赖
+func (l *dependencyListener) EnterIntoClause(ctx *parser.IntoClauseContext) {
+	if ctx.OUTFILE_SYMBOL() == nil && ctx.DUMPFILE_SYMBOL() == nil {
+		return
+	}
+	l.isWriteOp = true
+	if l.firstOpType == analyzer.StmtTypeSelect {
+		l.firstOpType = analyzer.StmtTypeSelectIntoFile
+	}
+	l.dependencies.ExternalWrite = append(l.dependencies.ExternalWrite, &analyzer.DependencyExternal{
+		Type:     analyzer.ExternalTypePath,
+		Location: unquote(ctx.TextStringLiteral().GetText()),
+	})
+}
+
+// EnterRenameTableStatement 进入RENAME TABLE语句时调用
+func (l *dependencyListener) EnterRenameTableStatement(ctx *parser.RenameTableStatementContext) {
+	l.curOpType = analyzer.StmtTypeRenameTable
+	l.onWriteStmt()
+}
+
+// ExitRenamePair 退出RENAME TABLE的每一对表名时调用，标记原表名和新表名
+func (l *dependencyListener) ExitRenamePair(ctx *parser.RenamePairContext) {
+	if n := len(l.dependencies.Write); n >= 2 {
+		l.dependencies.Write[n-2].Role = analyzer.TableRoleRenameFrom
+		l.dependencies.Write[n-1].Role = analyzer.TableRoleRenameTo
+	}
+}
+
+// EnterCreateIndex 进入CREATE INDEX语句时调用
+func (l *dependencyListener) EnterCreateIndex(ctx *parser.CreateIndexContext) {
+	l.curOpType = analyzer.StmtTypeCreateIndex
+	l.onWriteStmt()
+}
+
+// EnterDropIndex 进入DROP INDEX语句时调用
+func (l *dependencyListener) EnterDropIndex(ctx *parser.DropIndexContext) {
+	l.curOpType = analyzer.StmtTypeDropIndex
+	l.onWriteStmt()
+}
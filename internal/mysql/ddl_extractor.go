@@ -0,0 +1,45 @@
+package mysql
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/Edsuns/sql-parser/analyzer"
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// ddlExtractor 实现了 DDLExtractor 接口
+type ddlExtractor struct {
+}
+
+// NewDDLExtractor 创建一个新的 DDLExtractor 实例
+func NewDDLExtractor() analyzer.DDLExtractor {
+	return &ddlExtractor{}
+}
+
+// ExtractDDL 拆分SQL语句并提取每条DDL语句的表结构变更
+func (e *ddlExtractor) ExtractDDL(sql, defaultCluster, defaultDatabase string) ([]*analyzer.DDLEvent, error) {
+	statements := analyzer.SplitSQL(makeLexer(sql))
+	var result []*analyzer.DDLEvent
+	for _, stmt := range statements {
+		// 创建语法分析器
+		p := makeParser(makeLexer(stmt))
+
+		// 创建DDL监听器
+		listener := newDDLListener(stmt, defaultCluster, defaultDatabase)
+
+		// 创建自定义错误监听器
+		errListener := newSyntaxErrorListener(newDependencyListener(defaultCluster, defaultDatabase))
+		p.AddErrorListener(errListener)
+
+		// 解析并遍历语法树
+		antlr.ParseTreeWalkerDefault.Walk(listener, p.Queries())
+
+		// 检查是否有语法错误
+		if len(errListener.errors) > 0 {
+			return nil, errors.New(strings.Join(errListener.errors, "; "))
+		}
+		result = append(result, listener.events...)
+	}
+	return result, nil
+}
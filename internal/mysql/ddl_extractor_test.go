@@ -0,0 +1,52 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/Edsuns/sql-parser/analyzer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMySQLDDLExtractor(t *testing.T) {
+	sql := "CREATE TABLE `orders` (id BIGINT COMMENT '订单ID', amount INT, status INT, PRIMARY KEY (id))" +
+		" PARTITION BY RANGE (id) (PARTITION p0 VALUES LESS THAN (100));\n" +
+		"ALTER TABLE orders ADD COLUMN note VARCHAR(64), DROP COLUMN amount;\n" +
+		"ALTER TABLE orders CHANGE status state INT;\n" +
+		"ALTER TABLE orders ADD PARTITION (PARTITION p1 VALUES LESS THAN (200));\n" +
+		"ALTER TABLE orders DROP PARTITION p0;\n" +
+		"CREATE TABLE orders_bak LIKE orders;\n" +
+		"RENAME TABLE orders_bak TO orders_old;\n" +
+		"CREATE VIEW orders_v AS SELECT id FROM orders;\n" +
+		"DROP VIEW orders_v;\n" +
+		"ALTER TABLE missing ADD COLUMN c INT;"
+
+	model := analyzer.NewSchemaModel()
+	err := model.Replay(NewDDLExtractor(), &analyzer.DependencyAnalyzeReq{
+		SQL:             sql,
+		DefaultCluster:  "mysql",
+		DefaultDatabase: "shop",
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	orders, _ := model.LookupTable("mysql", "shop", "orders")
+	if assert.NotNil(t, orders) {
+		assert.Equal(t, []*analyzer.CatalogColumn{
+			{Name: "id", Type: "BIGINT", Comment: "订单ID"},
+			{Name: "state", Type: "INT"},
+			{Name: "note", Type: "VARCHAR(64)"},
+		}, orders.Columns)
+		assert.Equal(t, []string{"id"}, orders.PartitionKeys)
+		assert.Equal(t, []string{"p1"}, orders.Partitions)
+	}
+
+	exists, _ := model.TableExists("mysql", "shop", "orders_old")
+	assert.True(t, exists)
+	exists, _ = model.TableExists("mysql", "shop", "orders_v")
+	assert.False(t, exists)
+
+	if assert.Len(t, model.Conflicts, 1) {
+		assert.Equal(t, "mysql.shop.missing", model.Conflicts[0].Table)
+	}
+}
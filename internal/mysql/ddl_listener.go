@@ -0,0 +1,274 @@
+package mysql
+
+import (
+	"strings"
+
+	"github.com/Edsuns/sql-parser/analyzer"
+	"github.com/Edsuns/sql-parser/internal/mysql/parser"
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// ddlListener 提取DDL语句中的表结构变更
+type ddlListener struct {
+	*parser.BaseMySQLParserListener
+
+	stmt            string
+	defaultCluster  string
+	defaultDatabase string
+	events          []*analyzer.DDLEvent
+	alterTable      *analyzer.DependencyTable // 当前ALTER TABLE的表
+	partitionEvent  *analyzer.DDLEvent        // 当前接收分区名的变更
+}
+
+// newDDLListener 创建新的DDL监听器实例
+func newDDLListener(stmt, defaultCluster, defaultDatabase string) *ddlListener {
+	return &ddlListener{
+		stmt:            stmt,
+		defaultCluster:  defaultCluster,
+		defaultDatabase: defaultDatabase,
+		events:          []*analyzer.DDLEvent{},
+	}
+}
+
+// EnterCreateTable 进入创建表语句时调用
+func (l *ddlListener) EnterCreateTable(ctx *parser.CreateTableContext) {
+	// CREATE TABLE ... LIKE
+	if ctx.LIKE_SYMBOL() != nil {
+		e := l.addEvent(analyzer.DDLOpCreateLike, l.table(ctx.TableName()))
+		e.IfNotExists = ctx.IfNotExists() != nil
+		e.Source = l.table(ctx.TableRef())
+		return
+	}
+	e := l.addEvent(analyzer.DDLOpCreateTable, l.table(ctx.TableName()))
+	e.IfNotExists = ctx.IfNotExists() != nil
+	if ctx.TableElementList() != nil {
+		e.Columns = l.columns(ctx.TableElementList())
+	}
+	l.partitionEvent = e
+}
+
+// ExitCreateTable 离开创建表语句时调用
+func (l *ddlListener) ExitCreateTable(ctx *parser.CreateTableContext) {
+	l.partitionEvent = nil
+}
+
+// EnterCreateView 进入创建视图语句时调用
+func (l *ddlListener) EnterCreateView(ctx *parser.CreateViewContext) {
+	e := l.addEvent(analyzer.DDLOpCreateView, l.table(ctx.ViewName()))
+	e.OrReplace = ctx.ViewReplaceOrAlgorithm() != nil && ctx.ViewReplaceOrAlgorithm().REPLACE_SYMBOL() != nil
+	e.ViewDefinition = l.stmt
+	if list := ctx.ViewTail().ColumnInternalRefList(); list != nil {
+		for _, column := range list.AllColumnInternalRef() {
+			e.Columns = append(e.Columns, &analyzer.CatalogColumn{Name: unquote(column.GetText())})
+		}
+	}
+}
+
+// EnterDropTable 进入删除表语句时调用
+func (l *ddlListener) EnterDropTable(ctx *parser.DropTableContext) {
+	for _, ref := range ctx.TableRefList().AllTableRef() {
+		e := l.addEvent(analyzer.DDLOpDropTable, l.table(ref))
+		e.IfExists = ctx.IfExists() != nil
+	}
+}
+
+// EnterDropView 进入删除视图语句时调用
+func (l *ddlListener) EnterDropView(ctx *parser.DropViewContext) {
+	for _, ref := range ctx.ViewRefList().AllViewRef() {
+		e := l.addEvent(analyzer.DDLOpDropView, l.table(ref))
+		e.IfExists = ctx.IfExists() != nil
+	}
+}
+
+// EnterRenamePair 进入RENAME TABLE的每一对表名时调用
+func (l *ddlListener) EnterRenamePair(ctx *parser.RenamePairContext) {
+	e := l.addEvent(analyzer.DDLOpRenameTable, l.table(ctx.TableRef()))
+	e.NewTable = l.table(ctx.TableName())
+}
+
+// EnterAlterTable 进入修改表语句时调用，具体变更由各个子句处理
+func (l *ddlListener) EnterAlterTable(ctx *parser.AlterTableContext) {
+	l.alterTable = l.table(ctx.TableRef())
+}
+
+// ExitAlterTable 离开修改表语句时调用
+func (l *ddlListener) ExitAlterTable(ctx *parser.AlterTableContext) {
+	l.alterTable = nil
+}
+
+// EnterAlterListItem 进入ALTER TABLE的子句时调用，只处理列和表名的变更
+func (l *ddlListener) EnterAlterListItem(ctx *parser.AlterListItemContext) {
+	switch {
+	case ctx.ALTER_SYMBOL() != nil:
+		// ALTER COLUMN 只修改默认值或可见性
+	case ctx.ADD_SYMBOL() != nil && ctx.TableConstraintDef() == nil:
+		if e := l.addAlterEvent(analyzer.DDLOpAddColumn); e != nil {
+			if ctx.FieldDefinition() != nil {
+				e.Columns = append(e.Columns, l.column(ctx.Identifier(), ctx.FieldDefinition()))
+			} else {
+				e.Columns = l.columns(ctx.TableElementList())
+			}
+		}
+	case ctx.CHANGE_SYMBOL() != nil:
+		if e := l.addAlterEvent(analyzer.DDLOpModifyColumn); e != nil {
+			e.Column = unquote(ctx.ColumnInternalRef().GetText())
+			e.Columns = append(e.Columns, l.column(ctx.Identifier(), ctx.FieldDefinition()))
+		}
+	case ctx.MODIFY_SYMBOL() != nil:
+		if e := l.addAlterEvent(analyzer.DDLOpModifyColumn); e != nil {
+			e.Columns = append(e.Columns, l.column(ctx.ColumnInternalRef(), ctx.FieldDefinition()))
+		}
+	case ctx.DROP_SYMBOL() != nil && ctx.ColumnInternalRef() != nil && ctx.FOREIGN_SYMBOL() == nil:
+		if e := l.addAlterEvent(analyzer.DDLOpDropColumn); e != nil {
+			e.Column = unquote(ctx.ColumnInternalRef().GetText())
+		}
+	case ctx.RENAME_SYMBOL() != nil && ctx.COLUMN_SYMBOL() != nil:
+		if e := l.addAlterEvent(analyzer.DDLOpRenameColumn); e != nil {
+			e.Column = unquote(ctx.ColumnInternalRef().GetText())
+			e.NewColumn = unquote(ctx.Identifier().GetText())
+		}
+	case ctx.RENAME_SYMBOL() != nil && ctx.TableName() != nil:
+		if e := l.addAlterEvent(analyzer.DDLOpRenameTable); e != nil {
+			e.NewTable = l.table(ctx.TableName())
+		}
+	}
+}
+
+// EnterAlterPartition 进入分区变更子句时调用
+func (l *ddlListener) EnterAlterPartition(ctx *parser.AlterPartitionContext) {
+	switch {
+	case ctx.ADD_SYMBOL() != nil && ctx.PartitionDefinitions() != nil:
+		l.partitionEvent = l.addAlterEvent(analyzer.DDLOpAddPartition)
+	case ctx.DROP_SYMBOL() != nil:
+		if e := l.addAlterEvent(analyzer.DDLOpDropPartition); e != nil {
+			for _, id := range ctx.IdentifierList().AllIdentifier() {
+				e.Partitions = append(e.Partitions, unquote(id.GetText()))
+			}
+		}
+	}
+}
+
+// ExitAlterPartition 离开分区变更子句时调用
+func (l *ddlListener) ExitAlterPartition(ctx *parser.AlterPartitionContext) {
+	l.partitionEvent = nil
+}
+
+// EnterPartitionDefinition 进入分区定义时调用
+func (l *ddlListener) EnterPartitionDefinition(ctx *parser.PartitionDefinitionContext) {
+	if l.partitionEvent == nil {
+		return
+	}
+	l.partitionEvent.Partitions = append(l.partitionEvent.Partitions, unquote(ctx.Identifier().GetText()))
+}
+
+// EnterPartitionDefKey 进入 PARTITION BY KEY 子句时调用
+func (l *ddlListener) EnterPartitionDefKey(ctx *parser.PartitionDefKeyContext) {
+	l.addPartitionKeys(ctx.IdentifierList(), nil)
+}
+
+// EnterPartitionDefHash 进入 PARTITION BY HASH 子句时调用
+func (l *ddlListener) EnterPartitionDefHash(ctx *parser.PartitionDefHashContext) {
+	l.addPartitionKeys(nil, ctx.BitExpr())
+}
+
+// EnterPartitionDefRangeList 进入 PARTITION BY RANGE/LIST 子句时调用
+func (l *ddlListener) EnterPartitionDefRangeList(ctx *parser.PartitionDefRangeListContext) {
+	l.addPartitionKeys(ctx.IdentifierList(), ctx.BitExpr())
+}
+
+// addPartitionKeys 记录建表语句的分区键，分区表达式只有一个列名时才作为分区键，例如 RANGE (YEAR(dt)) 没有分区键
+func (l *ddlListener) addPartitionKeys(list parser.IIdentifierListContext, expr parser.IBitExprContext) {
+	if l.partitionEvent == nil || l.partitionEvent.Op != analyzer.DDLOpCreateTable {
+		return
+	}
+	if list != nil {
+		for _, id := range list.AllIdentifier() {
+			l.partitionEvent.PartitionKeys = append(l.partitionEvent.PartitionKeys, unquote(id.GetText()))
+		}
+	}
+	if expr != nil && expr.GetStart() == expr.GetStop() {
+		l.partitionEvent.PartitionKeys = append(l.partitionEvent.PartitionKeys, unquote(expr.GetText()))
+	}
+}
+
+// addEvent 添加一个表结构变更
+func (l *ddlListener) addEvent(op analyzer.DDLOpType, table *analyzer.DependencyTable) *analyzer.DDLEvent {
+	e := &analyzer.DDLEvent{
+		Op:    op,
+		Stmt:  l.stmt,
+		Table: table,
+	}
+	l.events = append(l.events, e)
+	return e
+}
+
+// addAlterEvent 添加一个ALTER TABLE的子句变更
+func (l *ddlListener) addAlterEvent(op analyzer.DDLOpType) *analyzer.DDLEvent {
+	if l.alterTable == nil {
+		return nil
+	}
+	return l.addEvent(op, l.alterTable)
+}
+
+// table 解析表名
+func (l *ddlListener) table(name antlr.ParserRuleContext) *analyzer.DependencyTable {
+	cluster, database, table := "", "", ""
+	parts := strings.Split(name.GetText(), ".")
+	switch len(parts) {
+	case 1:
+		table = parts[0]
+	case 2:
+		database, table = parts[0], parts[1]
+	default:
+		cluster, database, table = parts[0], parts[1], parts[2]
+	}
+	if cluster == "" {
+		cluster = l.defaultCluster
+	}
+	if database == "" {
+		database = l.defaultDatabase
+	}
+	return &analyzer.DependencyTable{
+		Cluster:  unquote(cluster),
+		Database: unquote(database),
+		Table:    unquote(table),
+	}
+}
+
+// columns 解析表元素中的列定义，忽略索引和约束
+func (l *ddlListener) columns(ctx parser.ITableElementListContext) []*analyzer.CatalogColumn {
+	var columns []*analyzer.CatalogColumn
+	for _, element := range ctx.AllTableElement() {
+		if def := element.ColumnDefinition(); def != nil {
+			columns = append(columns, l.column(def.ColumnName(), def.FieldDefinition()))
+		}
+	}
+	return columns
+}
+
+// column 解析列定义
+func (l *ddlListener) column(name antlr.ParserRuleContext, def parser.IFieldDefinitionContext) *analyzer.CatalogColumn {
+	c := &analyzer.CatalogColumn{
+		Name: unquote(name.GetText()),
+		Type: analyzer.OriginalText(def.DataType()),
+	}
+	for _, attr := range def.AllColumnAttribute() {
+		if attr.COMMENT_SYMBOL() != nil {
+			c.Comment = unquote(attr.TextLiteral().GetText())
+		}
+	}
+	return c
+}
+
+// unquote 去掉标识符的反引号或字符串的引号
+func unquote(s string) string {
+	if len(s) >= 2 {
+		switch s[0] {
+		case '`', '\'', '"':
+			if s[len(s)-1] == s[0] {
+				return s[1 : len(s)-1]
+			}
+		}
+	}
+	return s
+}
@@ -18,18 +18,33 @@ func NewDependencyAnalyzer() analyzer.DependencyAnalyzer {
 }
 
 func (a *dependencyAnalyzer) Analyze(req *analyzer.DependencyAnalyzeReq) ([]*analyzer.DependencyResult, error) {
-	// 使用SplitSQL函数拆分SQL语句
-	statements := analyzer.SplitSQL(makeLexer(req.SQL))
+	// 先按客户端的 DELIMITER 拆分脚本，再拆分SQL语句，存储程序的 BEGIN ... END 程序体作为一条语句
+	var statements []string
+	for _, piece := range analyzer.SplitClientScript(req.SQL) {
+		statements = append(statements, splitSQL(piece)...)
+	}
 	var result []*analyzer.DependencyResult
-	for _, stmt := range statements {
-		ddl, err := a.ParseOne(stmt, req.DefaultCluster, req.DefaultDatabase)
+	for _, span := range analyzer.LocateSpans(req.SQL, statements) {
+		// 过滤掉只有注释的语句
+		if span.Text == "" {
+			continue
+		}
+		ddl, err := a.ParseOne(span.Raw, req.DefaultCluster, req.DefaultDatabase)
 		if err != nil {
 			return nil, err
 		}
 		if ddl != nil {
+			ddl.Span = span
 			result = append(result, ddl)
 		}
 	}
+	// 调用的存储过程展开为过程体的读写表
+	if err := analyzer.ResolveCalls(a, req, result); err != nil {
+		return nil, err
+	}
+	if err := analyzer.PostProcess(a, result, req); err != nil {
+		return nil, err
+	}
 	return result, nil
 }
 
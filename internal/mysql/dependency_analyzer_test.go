@@ -319,3 +319,188 @@ func TestMySQLDependencyAnalyzer(t *testing.T) {
 		})
 	}
 }
+
+func TestMySQLDependencyAnalyzer_StoredPrograms(t *testing.T) {
+	sql := `CREATE PROCEDURE etl.load_orders(IN p_dt DATE)
+BEGIN
+  DECLARE cnt INT DEFAULT 0;
+  DELETE FROM dwd.orders WHERE dt = p_dt;
+  INSERT INTO dwd.orders SELECT * FROM ods.orders WHERE dt = p_dt;
+  IF (SELECT COUNT(*) FROM dwd.orders) > 0 THEN
+    UPDATE dwd.load_log SET finished = 1;
+  END IF;
+END;
+CREATE TRIGGER trg_orders_audit AFTER INSERT ON orders FOR EACH ROW
+  INSERT INTO audit_log (order_id) VALUES (NEW.id);
+CREATE EVENT nightly_load ON SCHEDULE EVERY 1 DAY STARTS '2024-01-01 02:00:00' ENABLE
+  DO CALL etl.load_orders(CURDATE());
+CALL etl.load_orders('2024-01-01');
+DROP TRIGGER trg_orders_audit;
+DROP EVENT nightly_load;
+DROP PROCEDURE etl.load_orders`
+	results, err := NewDependencyAnalyzer().Analyze(&analyzer.DependencyAnalyzeReq{
+		DefaultCluster:  "cl",
+		DefaultDatabase: "db",
+		Type:            analyzer.EngineMySQL,
+		SQL:             sql,
+	})
+	if !assert.NoError(t, err) || !assert.Len(t, results, 7) {
+		return
+	}
+	table := func(db, name string) *analyzer.DependencyTable {
+		return &analyzer.DependencyTable{Cluster: "cl", Database: db, Table: name}
+	}
+	procedureRead := []*analyzer.DependencyTable{table("ods", "orders"), table("dwd", "orders")}
+	procedureWrite := []*analyzer.DependencyTable{table("dwd", "orders"), table("dwd", "load_log")}
+
+	// 过程体中的每条语句单独分析
+	procedure := results[0]
+	assert.Equal(t, analyzer.StmtTypeCreateProcedure, procedure.StmtType)
+	assert.Equal(t, []*analyzer.DependencyFunction{{Database: "etl", Name: "load_orders", Procedure: true}}, procedure.FunctionWrite)
+	assert.Equal(t, procedureRead, procedure.Read)
+	assert.Equal(t, procedureWrite, procedure.Write)
+	if assert.Len(t, procedure.Children, 1) && assert.Len(t, procedure.Children[0].Children, 4) {
+		body := procedure.Children[0].Children
+		assert.Equal(t, analyzer.StmtTypeDeclare, body[0].StmtType)
+		assert.Equal(t, analyzer.StmtTypeDelete, body[1].StmtType)
+		assert.Equal(t, analyzer.StmtTypeInsert, body[2].StmtType)
+		assert.Equal(t, analyzer.StmtTypeControlFlow, body[3].StmtType)
+		assert.Equal(t, []*analyzer.DependencyTable{table("dwd", "orders")}, body[3].Read)
+		assert.Equal(t, []*analyzer.DependencyTable{table("dwd", "load_log")}, body[3].Write)
+	}
+
+	// 触发的表是读表，触发器体写入的表是写表
+	trigger := results[1]
+	assert.Equal(t, analyzer.StmtTypeCreateTrigger, trigger.StmtType)
+	assert.Equal(t, &analyzer.Trigger{Name: "trg_orders_audit", Timing: "AFTER", Event: "INSERT", Table: table("db", "orders")}, trigger.Trigger)
+	assert.Equal(t, []*analyzer.DependencyTable{table("db", "orders")}, trigger.Read)
+	assert.Equal(t, []*analyzer.DependencyTable{table("db", "audit_log")}, trigger.Write)
+
+	enabled := true
+	event := results[2]
+	assert.Equal(t, analyzer.StmtTypeCreateEvent, event.StmtType)
+	assert.Equal(t, &analyzer.Schedule{Name: "nightly_load", Every: "1 DAY", Offset: "2024-01-01 02:00:00", Enabled: &enabled}, event.Schedule)
+	assert.Equal(t, procedureRead, event.Read)
+	assert.Equal(t, procedureWrite, event.Write)
+
+	// CALL 展开为同一脚本中定义的存储过程的读写表
+	call := results[3]
+	assert.Equal(t, analyzer.StmtTypeCall, call.StmtType)
+	assert.Equal(t, []*analyzer.DependencyFunction{{Database: "etl", Name: "load_orders", Procedure: true}}, call.FunctionRead)
+	assert.Equal(t, procedureRead, call.Read)
+	assert.Equal(t, procedureWrite, call.Write)
+
+	assert.Equal(t, analyzer.StmtTypeDropTrigger, results[4].StmtType)
+	assert.Equal(t, &analyzer.Trigger{Name: "trg_orders_audit"}, results[4].Trigger)
+	assert.Equal(t, analyzer.StmtTypeDropEvent, results[5].StmtType)
+	assert.Equal(t, &analyzer.Schedule{Name: "nightly_load"}, results[5].Schedule)
+	assert.Equal(t, analyzer.StmtTypeDropProcedure, results[6].StmtType)
+}
+
+func TestMySQLDependencyAnalyzer_CallFromCatalog(t *testing.T) {
+	catalog := analyzer.NewMemoryCatalog()
+	catalog.AddRoutine("", "etl", "refresh_stats", `CREATE PROCEDURE refresh_stats()
+BEGIN
+  REPLACE INTO stats SELECT dt, COUNT(*) FROM orders GROUP BY dt;
+END`)
+	results, err := NewDependencyAnalyzer().Analyze(&analyzer.DependencyAnalyzeReq{
+		DefaultCluster:  "cl",
+		DefaultDatabase: "etl",
+		Type:            analyzer.EngineMySQL,
+		SQL:             "CALL refresh_stats()",
+		Catalog:         catalog,
+	})
+	if !assert.NoError(t, err) || !assert.Len(t, results, 1) {
+		return
+	}
+	assert.Equal(t, []*analyzer.DependencyTable{{Cluster: "cl", Database: "etl", Table: "orders"}}, results[0].Read)
+	assert.Equal(t, []*analyzer.DependencyTable{{Cluster: "cl", Database: "etl", Table: "stats"}}, results[0].Write)
+}
+
+func TestMySQLDependencyAnalyzer_DataMovementAndDDL(t *testing.T) {
+	sql := `LOAD DATA LOCAL INFILE '/data/orders.csv' INTO TABLE ods.orders FIELDS TERMINATED BY ',' IGNORE 1 LINES;
+SELECT * FROM ods.orders WHERE dt = '2024-01-01' INTO OUTFILE '/tmp/orders.csv' FIELDS TERMINATED BY ',';
+SELECT payload FROM ods.blobs WHERE id = 1 INTO DUMPFILE '/tmp/blob.bin';
+RENAME TABLE orders TO orders_old, orders_new TO orders;
+CREATE INDEX idx_dt ON ods.orders (dt);
+DROP INDEX idx_dt ON ods.orders;
+CREATE TABLE ods.orders_bak LIKE ods.orders`
+	results, err := NewDependencyAnalyzer().Analyze(&analyzer.DependencyAnalyzeReq{
+		DefaultCluster:  "cl",
+		DefaultDatabase: "db",
+		Type:            analyzer.EngineMySQL,
+		SQL:             sql,
+	})
+	if !assert.NoError(t, err) || !assert.Len(t, results, 7) {
+		return
+	}
+	table := func(db, name string, role analyzer.TableRole) *analyzer.DependencyTable {
+		return &analyzer.DependencyTable{Cluster: "cl", Database: db, Table: name, Role: role}
+	}
+	orders := []*analyzer.DependencyTable{table("ods", "orders", "")}
+
+	assert.Equal(t, analyzer.StmtTypeLoad, results[0].StmtType)
+	assert.Equal(t, orders, results[0].Write)
+	assert.Equal(t, []*analyzer.DependencyExternal{{
+		Type: analyzer.ExternalTypePath, Location: "/data/orders.csv", Properties: map[string]string{"local": "true"},
+	}}, results[0].ExternalRead)
+
+	assert.Equal(t, analyzer.StmtTypeSelectIntoFile, results[1].StmtType)
+	assert.Equal(t, orders, results[1].Read)
+	assert.Equal(t, []*analyzer.DependencyExternal{{Type: analyzer.ExternalTypePath, Location: "/tmp/orders.csv"}}, results[1].ExternalWrite)
+	assert.Equal(t, analyzer.StmtTypeSelectIntoFile, results[2].StmtType)
+	assert.Equal(t, []*analyzer.DependencyExternal{{Type: analyzer.ExternalTypePath, Location: "/tmp/blob.bin"}}, results[2].ExternalWrite)
+
+	// 每一对表名依次为原表名和新表名
+	assert.Equal(t, analyzer.StmtTypeRenameTable, results[3].StmtType)
+	assert.Empty(t, results[3].Read)
+	assert.Equal(t, []*analyzer.DependencyTable{
+		table("db", "orders", analyzer.TableRoleRenameFrom),
+		table("db", "orders_old", analyzer.TableRoleRenameTo),
+		table("db", "orders_new", analyzer.TableRoleRenameFrom),
+		table("db", "orders", analyzer.TableRoleRenameTo),
+	}, results[3].Write)
+
+	assert.Equal(t, analyzer.StmtTypeCreateIndex, results[4].StmtType)
+	assert.Equal(t, orders, results[4].Write)
+	assert.Equal(t, analyzer.StmtTypeDropIndex, results[5].StmtType)
+	assert.Equal(t, orders, results[5].Write)
+
+	assert.Equal(t, analyzer.StmtTypeCreateLike, results[6].StmtType)
+	assert.Equal(t, orders, results[6].Read)
+	assert.Equal(t, []*analyzer.DependencyTable{table("ods", "orders_bak", "")}, results[6].Write)
+}
+
+func TestMySQLDependencyAnalyzer_ClientScript(t *testing.T) {
+	sql := `DROP PROCEDURE IF EXISTS refresh_orders;
+DELIMITER $$
+CREATE PROCEDURE refresh_orders()
+BEGIN
+  DELETE FROM dwd.orders;
+  INSERT INTO dwd.orders SELECT * FROM ods.orders;
+END$$
+DELIMITER ;
+SELECT * FROM dwd.orders\G
+source /tmp/other.sql
+CALL refresh_orders();`
+	results, err := NewDependencyAnalyzer().Analyze(&analyzer.DependencyAnalyzeReq{
+		DefaultCluster:  "cl",
+		DefaultDatabase: "db",
+		Type:            analyzer.EngineMySQL,
+		SQL:             sql,
+	})
+	if !assert.NoError(t, err) || !assert.Len(t, results, 4) {
+		return
+	}
+	orders := []*analyzer.DependencyTable{{Cluster: "cl", Database: "dwd", Table: "orders"}}
+
+	assert.Equal(t, analyzer.StmtTypeDropProcedure, results[0].StmtType)
+	// 自定义分隔符不属于语句
+	assert.Equal(t, analyzer.StmtTypeCreateProcedure, results[1].StmtType)
+	assert.Equal(t, "CREATE PROCEDURE refresh_orders()\nBEGIN\n  DELETE FROM dwd.orders;\n  INSERT INTO dwd.orders SELECT * FROM ods.orders;\nEND", results[1].Stmt)
+	assert.Equal(t, analyzer.StmtTypeSelect, results[2].StmtType)
+	assert.Equal(t, "SELECT * FROM dwd.orders", results[2].Stmt)
+	assert.Equal(t, orders, results[2].Read)
+	assert.Equal(t, analyzer.StmtTypeCall, results[3].StmtType)
+	assert.Equal(t, orders, results[3].Write)
+}
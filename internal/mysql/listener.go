@@ -78,9 +78,20 @@ func (l *dependencyListener) EnterTruncateTableStatement(ctx *parser.TruncateTab
 // EnterCreateTable 进入CREATE TABLE语句时调用
 func (l *dependencyListener) EnterCreateTable(ctx *parser.CreateTableContext) {
 	l.curOpType = analyzer.StmtTypeCreateTable
+	if ctx.LIKE_SYMBOL() != nil {
+		l.curOpType = analyzer.StmtTypeCreateLike
+	}
 	l.onWriteStmt()
 }
 
+// ExitCreateTable 退出CREATE TABLE语句时调用，LIKE 的源表只提供表结构，作为读表
+func (l *dependencyListener) ExitCreateTable(ctx *parser.CreateTableContext) {
+	if n := len(l.dependencies.Write); ctx.LIKE_SYMBOL() != nil && n >= 2 {
+		l.dependencies.Read = append(l.dependencies.Read, l.dependencies.Write[n-1])
+		l.dependencies.Write = l.dependencies.Write[:n-1]
+	}
+}
+
 // EnterCreateView 进入CREATE VIEW语句时调用
 func (l *dependencyListener) EnterCreateView(ctx *parser.CreateViewContext) {
 	l.curOpType = analyzer.StmtTypeCreateView
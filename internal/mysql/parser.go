@@ -1,10 +1,14 @@
 package mysql
 
 import (
+	"github.com/Edsuns/sql-parser/analyzer"
 	"github.com/Edsuns/sql-parser/internal/mysql/parser"
 	"github.com/antlr4-go/antlr/v4"
 )
 
+// dialect 词法分析出错时无语法拆分SQL语句使用的词法规则，# 开始单行注释，-- 之后必须是空白
+var dialect = analyzer.Dialect{HashComments: true, DashSpace: true, BackslashEscapes: true}
+
 func makeLexer(sql string) *parser.MySQLLexer {
 	// 创建字符流
 	input := antlr.NewInputStream(sql)
@@ -0,0 +1,220 @@
+package mysql
+
+import (
+	"strings"
+
+	"github.com/Edsuns/sql-parser/analyzer"
+	"github.com/Edsuns/sql-parser/internal/mysql/parser"
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// EnterCreateProcedure 进入创建存储过程语句时调用
+func (l *dependencyListener) EnterCreateProcedure(ctx *parser.CreateProcedureContext) {
+	l.routineWrite(analyzer.StmtTypeCreateProcedure, ctx.ProcedureName()).Procedure = true
+}
+
+// ExitCreateProcedure 退出创建存储过程语句时调用，过程体单独分析
+func (l *dependencyListener) ExitCreateProcedure(ctx *parser.CreateProcedureContext) {
+	l.setBody(ctx.StoredRoutineBody().CompoundStatement())
+}
+
+// EnterCreateFunction 进入创建存储函数语句时调用
+func (l *dependencyListener) EnterCreateFunction(ctx *parser.CreateFunctionContext) {
+	l.routineWrite(analyzer.StmtTypeCreateFunction, ctx.FunctionName())
+}
+
+// ExitCreateFunction 退出创建存储函数语句时调用，函数体单独分析
+func (l *dependencyListener) ExitCreateFunction(ctx *parser.CreateFunctionContext) {
+	l.setBody(ctx.StoredRoutineBody().CompoundStatement())
+}
+
+// EnterCreateUdf 进入创建 SONAME 函数语句时调用，函数所在的动态库作为外部读依赖
+func (l *dependencyListener) EnterCreateUdf(ctx *parser.CreateUdfContext) {
+	f := l.routineWrite(analyzer.StmtTypeCreateFunction, ctx.UdfName())
+	external := &analyzer.DependencyExternal{Type: analyzer.ExternalTypeFile, Location: unquote(ctx.TextLiteral().GetText())}
+	f.Resources = append(f.Resources, external)
+	l.dependencies.ExternalRead = append(l.dependencies.ExternalRead, external)
+}
+
+// EnterDropProcedure 进入删除存储过程语句时调用
+func (l *dependencyListener) EnterDropProcedure(ctx *parser.DropProcedureContext) {
+	l.routineWrite(analyzer.StmtTypeDropProcedure, ctx.ProcedureRef()).Procedure = true
+}
+
+// EnterDropFunction 进入删除函数语句时调用
+func (l *dependencyListener) EnterDropFunction(ctx *parser.DropFunctionContext) {
+	l.routineWrite(analyzer.StmtTypeDropFunction, ctx.FunctionRef())
+}
+
+// EnterCallStatement 进入CALL语句时调用，调用的存储过程记录在 FunctionRead 中
+func (l *dependencyListener) EnterCallStatement(ctx *parser.CallStatementContext) {
+	l.curOpType = analyzer.StmtTypeCall
+	l.isOnlyComment = false
+	if l.firstOpType == "" {
+		l.firstOpType = analyzer.StmtTypeCall
+	}
+	f := routine(ctx.ProcedureRef())
+	f.Procedure = true
+	l.dependencies.FunctionRead = analyzer.AppendFunction(l.dependencies.FunctionRead, f)
+}
+
+// EnterCreateTrigger 进入创建触发器语句时调用
+func (l *dependencyListener) EnterCreateTrigger(ctx *parser.CreateTriggerContext) {
+	l.curOpType = analyzer.StmtTypeCreateTrigger
+	l.isOnlyComment = false
+	if l.firstOpType == "" {
+		l.firstOpType = analyzer.StmtTypeCreateTrigger
+	}
+	l.dependencies.Trigger = &analyzer.Trigger{
+		Name:   qualifiedName(ctx.TriggerName()),
+		Timing: strings.ToUpper(ctx.GetTiming().GetText()),
+		Event:  strings.ToUpper(ctx.GetEvent().GetText()),
+	}
+}
+
+// ExitCreateTrigger 退出创建触发器语句时调用，触发的表作为第一个读表，触发器体单独分析
+func (l *dependencyListener) ExitCreateTrigger(ctx *parser.CreateTriggerContext) {
+	l.setBody(ctx.CompoundStatement())
+	body := l.dependencies.Read
+	l.dependencies.Read = []*analyzer.DependencyTable{}
+	l.addReadTable(splitTableName(ctx.TableRef().GetText()))
+	l.dependencies.Trigger.Table = l.dependencies.Read[0]
+	l.dependencies.Read = analyzer.AppendTables(l.dependencies.Read, body)
+}
+
+// EnterDropTrigger 进入删除触发器语句时调用
+func (l *dependencyListener) EnterDropTrigger(ctx *parser.DropTriggerContext) {
+	l.curOpType = analyzer.StmtTypeDropTrigger
+	l.isOnlyComment = false
+	if l.firstOpType == "" {
+		l.firstOpType = analyzer.StmtTypeDropTrigger
+	}
+	l.dependencies.Trigger = &analyzer.Trigger{Name: qualifiedName(ctx.TriggerRef())}
+}
+
+// EnterCreateEvent 进入创建事件语句时调用
+func (l *dependencyListener) EnterCreateEvent(ctx *parser.CreateEventContext) {
+	l.eventStmt(analyzer.StmtTypeCreateEvent, ctx.EventName(), ctx.ENABLE_SYMBOL(), ctx.DISABLE_SYMBOL())
+}
+
+// ExitCreateEvent 退出创建事件语句时调用，事件体单独分析
+func (l *dependencyListener) ExitCreateEvent(ctx *parser.CreateEventContext) {
+	l.setBody(ctx.CompoundStatement())
+}
+
+// EnterAlterEvent 进入修改事件语句时调用
+func (l *dependencyListener) EnterAlterEvent(ctx *parser.AlterEventContext) {
+	l.eventStmt(analyzer.StmtTypeAlterEvent, ctx.EventRef(), ctx.ENABLE_SYMBOL(), ctx.DISABLE_SYMBOL())
+}
+
+// ExitAlterEvent 退出修改事件语句时调用，修改了事件体时单独分析
+func (l *dependencyListener) ExitAlterEvent(ctx *parser.AlterEventContext) {
+	if ctx.CompoundStatement() != nil {
+		l.setBody(ctx.CompoundStatement())
+	}
+}
+
+// EnterDropEvent 进入删除事件语句时调用
+func (l *dependencyListener) EnterDropEvent(ctx *parser.DropEventContext) {
+	l.eventStmt(analyzer.StmtTypeDropEvent, ctx.EventRef(), nil, nil)
+}
+
+// EnterSchedule 进入事件的调度时调用，例如 ON SCHEDULE EVERY 1 DAY STARTS '2024-01-01 02:00:00'
+func (l *dependencyListener) EnterSchedule(ctx *parser.ScheduleContext) {
+	schedule := l.dependencies.Schedule
+	if schedule == nil {
+		return
+	}
+	if ctx.AT_SYMBOL() != nil {
+		schedule.At = unquote(analyzer.OriginalText(ctx.Expr(0)))
+		return
+	}
+	schedule.Every = unquote(analyzer.OriginalText(ctx.Expr(0))) + " " + strings.ToUpper(ctx.Interval().GetText())
+	// STARTS 和 ENDS 都是可选的，按关键字确定其后的表达式
+	children := ctx.GetChildren()
+	for i := 0; i+1 < len(children); i++ {
+		keyword, ok := children[i].(antlr.TerminalNode)
+		expr, isExpr := children[i+1].(parser.IExprContext)
+		if !ok || !isExpr {
+			continue
+		}
+		switch keyword.GetSymbol().GetTokenType() {
+		case parser.MySQLLexerSTARTS_SYMBOL:
+			schedule.Offset = unquote(analyzer.OriginalText(expr))
+		case parser.MySQLLexerENDS_SYMBOL:
+			schedule.Ends = unquote(analyzer.OriginalText(expr))
+		}
+	}
+}
+
+// eventStmt 记录事件语句的类型和事件名，enable、disable 为语句中的 ENABLE、DISABLE 关键字
+func (l *dependencyListener) eventStmt(stmtType analyzer.StmtType, name antlr.ParserRuleContext, enable, disable antlr.TerminalNode) {
+	l.curOpType = stmtType
+	l.isOnlyComment = false
+	if l.firstOpType == "" {
+		l.firstOpType = stmtType
+	}
+	schedule := &analyzer.Schedule{Name: qualifiedName(name)}
+	if enable != nil || disable != nil {
+		enabled := enable != nil
+		schedule.Enabled = &enabled
+	}
+	l.dependencies.Schedule = schedule
+}
+
+// routineWrite 记录创建或删除的存储过程或函数
+func (l *dependencyListener) routineWrite(stmtType analyzer.StmtType, name antlr.ParserRuleContext) *analyzer.DependencyFunction {
+	l.curOpType = stmtType
+	l.isOnlyComment = false
+	if l.firstOpType == "" {
+		l.firstOpType = stmtType
+	}
+	f := routine(name)
+	l.dependencies.FunctionWrite = append(l.dependencies.FunctionWrite, f)
+	return f
+}
+
+// setBody 单独分析存储程序的程序体，程序体作为唯一的子语句，语句的读写表取自程序体。
+// 遍历语法树时程序体内语句记录的读写表不区分语句，因此先清空
+func (l *dependencyListener) setBody(body parser.ICompoundStatementContext) {
+	if body == nil {
+		return
+	}
+	l.dependencies.Read = []*analyzer.DependencyTable{}
+	l.dependencies.Write = []*analyzer.DependencyTable{}
+	b := &compoundBuilder{defaultCluster: l.defaultCluster, defaultDatabase: l.defaultDatabase}
+	analyzer.AddChild(l.dependencies, b.statement(body))
+}
+
+// routine 解析存储过程或函数名
+func routine(name antlr.ParserRuleContext) *analyzer.DependencyFunction {
+	parts := strings.Split(name.GetText(), ".")
+	f := &analyzer.DependencyFunction{Name: unquote(parts[len(parts)-1])}
+	if len(parts) > 1 {
+		f.Database = unquote(parts[len(parts)-2])
+	}
+	return f
+}
+
+// qualifiedName 去掉限定名各部分的反引号
+func qualifiedName(name antlr.ParserRuleContext) string {
+	parts := strings.Split(name.GetText(), ".")
+	for i := range parts {
+		parts[i] = unquote(parts[i])
+	}
+	return strings.Join(parts, ".")
+}
+
+// splitTableName 拆分表名，缺少的部分为空
+func splitTableName(name string) (cluster, database, table string) {
+	parts := strings.Split(name, ".")
+	switch len(parts) {
+	case 1:
+		table = parts[0]
+	case 2:
+		database, table = parts[0], parts[1]
+	default:
+		cluster, database, table = parts[0], parts[1], parts[2]
+	}
+	return cluster, database, table
+}
@@ -0,0 +1,119 @@
+package mysql
+
+import (
+	"strings"
+
+	"github.com/Edsuns/sql-parser/analyzer"
+	"github.com/Edsuns/sql-parser/internal/mysql/parser"
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// splitSQL 拆分SQL语句，保留原始缩进和换行，存储过程、函数、触发器和事件的
+// BEGIN ... END 程序体内部的分号不作为语句结束
+func splitSQL(sql string) []string {
+	lexer := makeLexer(sql)
+	errs := analyzer.WatchLexerErrors(lexer)
+	var tokens []antlr.Token
+	for {
+		token := lexer.NextToken()
+		if token.GetTokenType() == antlr.TokenEOF {
+			break
+		}
+		tokens = append(tokens, token)
+	}
+	// 词法分析出错时按token拆分的结果不可靠，改为无语法拆分
+	if errs.Count > 0 {
+		return analyzer.SplitText(sql, dialect)
+	}
+
+	var result []string
+	var s strings.Builder
+	first := true     // 是否还没遇到当前语句的第一个有效token
+	compound := false // 当前语句是否为存储程序的定义
+	depth := 0        // 程序体中 BEGIN/CASE 与 END 的嵌套深度
+	prev := antlr.TokenInvalidType
+	for i, token := range tokens {
+		s.WriteString(token.GetText())
+		if token.GetChannel() != antlr.TokenDefaultChannel {
+			continue
+		}
+		if first {
+			first = false
+			compound = isCompoundStart(tokens, i)
+		}
+		if compound {
+			switch token.GetTokenType() {
+			case parser.MySQLLexerBEGIN_SYMBOL:
+				depth++
+			case parser.MySQLLexerCASE_SYMBOL:
+				// END CASE 中的 CASE 不是新的 CASE
+				if prev != parser.MySQLLexerEND_SYMBOL {
+					depth++
+				}
+			case parser.MySQLLexerEND_SYMBOL:
+				// END IF、END WHILE 等没有对应的 BEGIN，END CASE 与 CASE 对应
+				switch nextTokenType(tokens, i) {
+				case parser.MySQLLexerIF_SYMBOL, parser.MySQLLexerWHILE_SYMBOL, parser.MySQLLexerLOOP_SYMBOL,
+					parser.MySQLLexerREPEAT_SYMBOL:
+				default:
+					depth--
+				}
+			}
+		}
+		prev = token.GetTokenType()
+		// 分号则是语句结束
+		if token.GetTokenType() == parser.MySQLLexerSEMICOLON_SYMBOL && depth <= 0 {
+			result = append(result, strings.TrimSpace(s.String()))
+			s.Reset()
+			first, compound, depth = true, false, 0
+		}
+	}
+	// 处理最后一条可能没有分号结束的语句
+	if s.Len() > 0 {
+		result = append(result, strings.TrimSpace(s.String()))
+	}
+	return result
+}
+
+// isCompoundStart 判断语句是否为 CREATE/ALTER [DEFINER = user] PROCEDURE|FUNCTION|TRIGGER|EVENT
+func isCompoundStart(tokens []antlr.Token, i int) bool {
+	switch tokens[i].GetTokenType() {
+	case parser.MySQLLexerCREATE_SYMBOL, parser.MySQLLexerALTER_SYMBOL:
+	default:
+		return false
+	}
+	for j := nextTokenIndex(tokens, i); j >= 0; j = nextTokenIndex(tokens, j) {
+		switch tokens[j].GetTokenType() {
+		case parser.MySQLLexerPROCEDURE_SYMBOL, parser.MySQLLexerFUNCTION_SYMBOL,
+			parser.MySQLLexerTRIGGER_SYMBOL, parser.MySQLLexerEVENT_SYMBOL:
+			return true
+		case parser.MySQLLexerDEFINER_SYMBOL, parser.MySQLLexerEQUAL_OPERATOR, parser.MySQLLexerCURRENT_USER_SYMBOL,
+			parser.MySQLLexerOPEN_PAR_SYMBOL, parser.MySQLLexerCLOSE_PAR_SYMBOL,
+			parser.MySQLLexerIDENTIFIER, parser.MySQLLexerBACK_TICK_QUOTED_ID,
+			parser.MySQLLexerSINGLE_QUOTED_TEXT, parser.MySQLLexerDOUBLE_QUOTED_TEXT,
+			parser.MySQLLexerAT_SIGN_SYMBOL, parser.MySQLLexerAT_TEXT_SUFFIX, parser.MySQLLexerAGGREGATE_SYMBOL:
+			// DEFINER 子句中的用户名
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// nextTokenIndex 返回下一个有效token的下标，没有时返回-1
+func nextTokenIndex(tokens []antlr.Token, i int) int {
+	for j := i + 1; j < len(tokens); j++ {
+		if tokens[j].GetChannel() == antlr.TokenDefaultChannel {
+			return j
+		}
+	}
+	return -1
+}
+
+// nextTokenType 返回下一个有效token的类型
+func nextTokenType(tokens []antlr.Token, i int) int {
+	if j := nextTokenIndex(tokens, i); j >= 0 {
+		return tokens[j].GetTokenType()
+	}
+	return antlr.TokenEOF
+}
@@ -0,0 +1,101 @@
+package spark
+
+import (
+	"github.com/Edsuns/sql-parser/analyzer"
+	"github.com/Edsuns/sql-parser/internal/spark/parser"
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// compoundBuilder 把 BEGIN ... END 复合语句构建为结果树，每条子语句单独分析读写表和类型
+type compoundBuilder struct {
+	defaultCluster  string
+	defaultDatabase string
+	catalog         analyzer.Catalog
+}
+
+// block 构建语句块，body 为空表示空块
+func (b *compoundBuilder) block(ctx antlr.ParserRuleContext, body parser.ICompoundBodyContext) *analyzer.DependencyResult {
+	result := analyzer.NewCompoundResult(ctx, analyzer.StmtTypeCompound)
+	if body != nil {
+		b.addBody(result, body)
+	}
+	return result
+}
+
+// statement 构建复合语句中的一条语句
+func (b *compoundBuilder) statement(ctx parser.ICompoundStatementContext) *analyzer.DependencyResult {
+	switch {
+	case ctx.Statement() != nil:
+		return b.walk(ctx, ctx.Statement())
+	case ctx.SetStatementInsideSqlScript() != nil:
+		result := b.walk(ctx, ctx.SetStatementInsideSqlScript())
+		result.StmtType = analyzer.StmtTypeSet
+		return result
+	case ctx.BeginEndCompoundBlock() != nil:
+		return b.block(ctx, ctx.BeginEndCompoundBlock().CompoundBody())
+	case ctx.DeclareConditionStatement() != nil:
+		return analyzer.NewCompoundResult(ctx, analyzer.StmtTypeDeclare)
+	case ctx.DeclareHandlerStatement() != nil:
+		handler := ctx.DeclareHandlerStatement()
+		result := analyzer.NewCompoundResult(ctx, analyzer.StmtTypeDeclare)
+		var child *analyzer.DependencyResult
+		switch {
+		case handler.BeginEndCompoundBlock() != nil:
+			child = b.block(handler.BeginEndCompoundBlock(), handler.BeginEndCompoundBlock().CompoundBody())
+		case handler.Statement() != nil:
+			child = b.walk(handler.Statement(), handler.Statement())
+		case handler.SetStatementInsideSqlScript() != nil:
+			child = b.walk(handler.SetStatementInsideSqlScript(), handler.SetStatementInsideSqlScript())
+			child.StmtType = analyzer.StmtTypeSet
+		}
+		if child != nil {
+			analyzer.AddChild(result, child)
+		}
+		return result
+	default:
+		// IF、CASE、WHILE、REPEAT、LOOP、FOR、LEAVE、ITERATE
+		return b.controlFlow(ctx)
+	}
+}
+
+// controlFlow 构建控制流语句，条件表达式和FOR的查询中读取的表属于该语句本身
+func (b *compoundBuilder) controlFlow(ctx parser.ICompoundStatementContext) *analyzer.DependencyResult {
+	result := analyzer.NewCompoundResult(ctx, analyzer.StmtTypeControlFlow)
+	var visit func(tree antlr.Tree)
+	visit = func(tree antlr.Tree) {
+		for _, child := range tree.GetChildren() {
+			switch c := child.(type) {
+			case parser.ICompoundBodyContext:
+				b.addBody(result, c)
+			case *parser.SearchedCaseStatementContext, *parser.SimpleCaseStatementContext,
+				*parser.IfElseStatementContext, *parser.WhileStatementContext, *parser.RepeatStatementContext,
+				*parser.LoopStatementContext, *parser.ForStatementContext,
+				*parser.LeaveStatementContext, *parser.IterateStatementContext:
+				visit(c)
+			case antlr.ParserRuleContext:
+				condition := b.walk(c, c)
+				analyzer.MergeTables(result, condition)
+			}
+		}
+	}
+	visit(ctx)
+	return result
+}
+
+// addBody 添加语句块中的所有语句
+func (b *compoundBuilder) addBody(parent *analyzer.DependencyResult, body parser.ICompoundBodyContext) {
+	for _, stmt := range body.AllCompoundStatement() {
+		analyzer.AddChild(parent, b.statement(stmt))
+	}
+}
+
+// walk 使用新的监听器分析语法树，text 为结果中的语句文本
+func (b *compoundBuilder) walk(text antlr.ParserRuleContext, tree antlr.ParseTree) *analyzer.DependencyResult {
+	listener := newDependencyListener(b.defaultCluster, b.defaultDatabase)
+	listener.catalog = b.catalog
+	antlr.ParseTreeWalkerDefault.Walk(listener, tree)
+	result := listener.dependencies
+	result.Stmt = analyzer.OriginalText(text)
+	result.StmtType = listener.firstOpType
+	return result
+}
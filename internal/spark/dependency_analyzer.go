@@ -18,35 +18,83 @@ func NewDependencyAnalyzer() analyzer.DependencyAnalyzer {
 }
 
 func (a *dependencyAnalyzer) Analyze(req *analyzer.DependencyAnalyzeReq) ([]*analyzer.DependencyResult, error) {
-	// 使用SplitSQL函数拆分SQL语句
-	statements := analyzer.SplitSQL(makeLexer(req.SQL))
+	// 替换脚本变量后拆分SQL语句，BEGIN ... END 复合语句作为一条语句
+	tpl := analyzer.NewTemplate(req.SQL, req.Variables)
+	spans := analyzer.LocateSpans(tpl.SQL, splitSQL(tpl.SQL))
 	var result []*analyzer.DependencyResult
-	for _, stmt := range statements {
-		ddl, err := a.ParseOne(stmt, req.DefaultCluster, req.DefaultDatabase)
+	for _, span := range spans {
+		// 过滤掉只有注释的语句
+		if span.Text == "" {
+			continue
+		}
+		stmt, start := span.Raw, span.Start
+		tpl.MapSpan(span)
+		// SET key=value 设置配置项或变量，语法文件把它解析为没有读写表的配置语句
+		if _, _, ok := analyzer.ParseSetCommand(stmt); ok {
+			result = append(result, &analyzer.DependencyResult{
+				Stmt:     stmt,
+				StmtType: analyzer.StmtTypeSet,
+				Read:     []*analyzer.DependencyTable{},
+				Write:    []*analyzer.DependencyTable{},
+				Span:     span,
+			})
+			continue
+		}
+		ddl, err := a.parse(stmt, req.DefaultCluster, req.DefaultDatabase, req.Catalog)
 		if err != nil {
-			return nil, err
+			return nil, tpl.MapError(err, stmt, start)
 		}
 		if ddl != nil {
+			ddl.Span = span
 			result = append(result, ddl)
 		}
 	}
+	tpl.Restore(result)
+	if err := analyzer.PostProcess(a, result, req); err != nil {
+		return nil, err
+	}
 	return result, nil
 }
 
 // ParseOne 解析SQL语句并返回Dependencies列表
 func (a *dependencyAnalyzer) ParseOne(sql, defaultCluster, defaultDatabase string) (*analyzer.DependencyResult, error) {
+	return a.parse(sql, defaultCluster, defaultDatabase, nil)
+}
+
+// parse 解析单句SQL，提供 catalog 时根据其中的分区键得到读表读取的分区
+func (a *dependencyAnalyzer) parse(sql, defaultCluster, defaultDatabase string, catalog analyzer.Catalog) (*analyzer.DependencyResult, error) {
+	// 语法文件不支持的 Delta 和 Iceberg 语句
+	if result := parseLakehouse(sql, defaultCluster, defaultDatabase, catalog); result != nil {
+		return result, nil
+	}
+
 	// 创建语法分析器
 	p := makeParser(makeLexer(sql))
 
 	// 创建自定义监听器
 	listener := newDependencyListener(defaultCluster, defaultDatabase)
+	listener.catalog = catalog
 
 	// 创建自定义错误监听器
 	errListener := newSyntaxErrorListener(listener)
 	p.AddErrorListener(errListener)
 
-	// 解析并遍历语法树
-	antlr.ParseTreeWalkerDefault.Walk(listener, p.CompoundOrSingleStatement())
+	// 解析语法树，复合语句构建为结果树，单条语句直接遍历
+	tree := p.CompoundOrSingleStatement()
+	if err := unclosedComment(p.GetTokenStream()); err != nil {
+		return nil, err
+	}
+	if compound := tree.SingleCompoundStatement(); compound != nil {
+		listener.isOnlyComment = false
+		if len(errListener.errors) > 0 {
+			return nil, errors.New(strings.Join(errListener.errors, "; "))
+		}
+		b := &compoundBuilder{defaultCluster: defaultCluster, defaultDatabase: defaultDatabase, catalog: catalog}
+		result := b.block(compound, compound.CompoundBody())
+		result.Stmt = sql
+		return result, nil
+	}
+	antlr.ParseTreeWalkerDefault.Walk(listener, tree)
 
 	// 检查是否有语法错误
 	if len(errListener.errors) > 0 {
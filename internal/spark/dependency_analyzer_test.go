@@ -688,3 +688,806 @@ func TestSparkDependencyAnalyzer_SyntaxError(t *testing.T) {
 		})
 	}
 }
+
+func TestSparkDependencyAnalyzer_Catalog(t *testing.T) {
+	catalog := analyzer.NewMemoryCatalog(
+		&analyzer.CatalogTable{Database: "default_db", Table: "orders"},
+		&analyzer.CatalogTable{Database: "default_db", Table: "orders_v", Kind: analyzer.TableKindView},
+	)
+	req := &analyzer.DependencyAnalyzeReq{
+		DefaultCluster:  "default_cluster",
+		DefaultDatabase: "default_db",
+		Type:            analyzer.EngineSpark,
+		SQL:             "INSERT INTO report SELECT * FROM orders JOIN orders_v ON orders.id = orders_v.id;",
+		Catalog:         catalog,
+	}
+
+	results, err := NewDependencyAnalyzer().Analyze(req)
+	if assert.NoError(t, err) && assert.Len(t, results, 1) {
+		assert.Equal(t, analyzer.TableKindTable, results[0].Read[0].Kind)
+		assert.Equal(t, analyzer.TableKindView, results[0].Read[1].Kind)
+		assert.Equal(t, analyzer.TableKind(""), results[0].Write[0].Kind)
+	}
+}
+
+func TestSparkDependencyAnalyzer_ExpandViews(t *testing.T) {
+	a := NewDependencyAnalyzer()
+	catalog, err := analyzer.NewViewCatalog(a, map[string]string{
+		"report_v": "CREATE VIEW ads.report_v AS SELECT * FROM daily_v JOIN dim.region ON daily_v.region_id = region.id",
+		"daily_v":  "CREATE VIEW ads.daily_v AS SELECT dt, region_id, sum(amount) FROM dwd.fact_orders GROUP BY dt, region_id",
+	}, "default_cluster", "default_db")
+	if !assert.NoError(t, err) {
+		return
+	}
+	catalog.AddTable(&analyzer.CatalogTable{Database: "dwd", Table: "fact_orders"})
+
+	results, err := a.Analyze(&analyzer.DependencyAnalyzeReq{
+		DefaultCluster:  "default_cluster",
+		DefaultDatabase: "default_db",
+		Type:            analyzer.EngineSpark,
+		SQL:             "SELECT * FROM ads.report_v",
+		Catalog:         catalog,
+		ExpandViews:     true,
+	})
+	if assert.NoError(t, err) && assert.Len(t, results, 1) {
+		assert.Equal(t, []*analyzer.DependencyTable{
+			{Cluster: "default_cluster", Database: "ads", Table: "report_v", Kind: analyzer.TableKindView},
+			{
+				Cluster:  "default_cluster",
+				Database: "ads",
+				Table:    "daily_v",
+				Kind:     analyzer.TableKindView,
+				ViewPath: []string{"default_cluster.ads.report_v", "default_cluster.ads.daily_v"},
+			},
+			{
+				Cluster:  "default_cluster",
+				Database: "dwd",
+				Table:    "fact_orders",
+				Kind:     analyzer.TableKindTable,
+				ViewPath: []string{"default_cluster.ads.report_v", "default_cluster.ads.daily_v", "default_cluster.dwd.fact_orders"},
+			},
+			{
+				Cluster:  "default_cluster",
+				Database: "dim",
+				Table:    "region",
+				ViewPath: []string{"default_cluster.ads.report_v", "default_cluster.dim.region"},
+			},
+		}, results[0].Read)
+	}
+
+	// 复合语句的子语句同样展开
+	results, err = a.Analyze(&analyzer.DependencyAnalyzeReq{
+		DefaultCluster:  "default_cluster",
+		DefaultDatabase: "default_db",
+		Type:            analyzer.EngineSpark,
+		SQL:             "BEGIN\n  SELECT * FROM ads.daily_v;\nEND",
+		Catalog:         catalog,
+		ExpandViews:     true,
+	})
+	if assert.NoError(t, err) && assert.Len(t, results, 1) && assert.Len(t, results[0].Children, 1) {
+		expanded := &analyzer.DependencyTable{
+			Cluster:  "default_cluster",
+			Database: "dwd",
+			Table:    "fact_orders",
+			Kind:     analyzer.TableKindTable,
+			ViewPath: []string{"default_cluster.ads.daily_v", "default_cluster.dwd.fact_orders"},
+		}
+		assert.Contains(t, results[0].Children[0].Read, expanded)
+		assert.Contains(t, results[0].Read, expanded)
+	}
+
+	// 视图之间循环引用
+	catalog, err = analyzer.NewViewCatalog(a, map[string]string{
+		"v1": "CREATE VIEW v1 AS SELECT * FROM v2",
+		"v2": "CREATE VIEW v2 AS SELECT * FROM v1",
+	}, "default_cluster", "default_db")
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = a.Analyze(&analyzer.DependencyAnalyzeReq{
+		DefaultCluster:  "default_cluster",
+		DefaultDatabase: "default_db",
+		SQL:             "SELECT * FROM v1",
+		Catalog:         catalog,
+		ExpandViews:     true,
+	})
+	var cycle *analyzer.ViewCycleError
+	if assert.ErrorAs(t, err, &cycle) {
+		assert.Equal(t, []string{"default_cluster.default_db.v1", "default_cluster.default_db.v2", "default_cluster.default_db.v1"}, cycle.Path)
+	}
+
+	_, err = analyzer.NewViewCatalog(a, map[string]string{"bad": "SELECT 1"}, "default_cluster", "default_db")
+	assert.ErrorContains(t, err, "single CREATE VIEW statement")
+}
+
+// countingAnalyzer 记录 Analyze 的调用次数
+type countingAnalyzer struct {
+	analyzer.DependencyAnalyzer
+	calls int
+}
+
+func (c *countingAnalyzer) Analyze(req *analyzer.DependencyAnalyzeReq) ([]*analyzer.DependencyResult, error) {
+	c.calls++
+	return c.DependencyAnalyzer.Analyze(req)
+}
+
+func TestSparkDependencyAnalyzer_ExpandViewsDiamond(t *testing.T) {
+	a := NewDependencyAnalyzer()
+	catalog, err := analyzer.NewViewCatalog(a, map[string]string{
+		"top":   "CREATE VIEW top AS SELECT * FROM left_v JOIN right_v ON left_v.id = right_v.id",
+		"left":  "CREATE VIEW left_v AS SELECT * FROM base_v",
+		"right": "CREATE VIEW right_v AS SELECT * FROM base_v",
+		"base":  "CREATE VIEW base_v AS SELECT * FROM dwd.orders",
+	}, "default_cluster", "default_db")
+	if !assert.NoError(t, err) {
+		return
+	}
+	results, err := a.Analyze(&analyzer.DependencyAnalyzeReq{
+		DefaultCluster:  "default_cluster",
+		DefaultDatabase: "default_db",
+		SQL:             "SELECT * FROM top",
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// 两条路径都到达 base_v，它只展开一次，保留第一次到达的路径
+	counter := &countingAnalyzer{DependencyAnalyzer: a}
+	if !assert.NoError(t, analyzer.ExpandViews(counter, results, catalog)) {
+		return
+	}
+	assert.Equal(t, 4, counter.calls)
+	var paths [][]string
+	for _, table := range results[0].Read[1:] {
+		paths = append(paths, table.ViewPath)
+	}
+	assert.Equal(t, [][]string{
+		{"default_cluster.default_db.top", "default_cluster.default_db.left_v"},
+		{"default_cluster.default_db.top", "default_cluster.default_db.left_v", "default_cluster.default_db.base_v"},
+		{"default_cluster.default_db.top", "default_cluster.default_db.left_v", "default_cluster.default_db.base_v", "default_cluster.dwd.orders"},
+		{"default_cluster.default_db.top", "default_cluster.default_db.right_v"},
+	}, paths)
+}
+
+func TestSparkDependencyAnalyzer_External(t *testing.T) {
+	tests := []struct {
+		name          string
+		sql           string
+		read          []string
+		write         []string
+		externalRead  []*analyzer.DependencyExternal
+		externalWrite []*analyzer.DependencyExternal
+		location      string
+		catalog       analyzer.Catalog
+	}{
+		{
+			name:         "SELECT from file path",
+			sql:          "SELECT * FROM parquet.`/data/events` e JOIN dim.users u ON e.uid = u.id",
+			read:         []string{"default_cluster.dim.users"},
+			write:        []string{},
+			externalRead: []*analyzer.DependencyExternal{{Type: analyzer.ExternalTypePath, Format: "parquet", Location: "/data/events"}},
+		},
+		{
+			name:         "SELECT from relative file path missing from catalog",
+			sql:          "SELECT * FROM csv.`data/rel.csv` JOIN ods.events ON true",
+			read:         []string{"default_cluster.ods.events"},
+			write:        []string{},
+			externalRead: []*analyzer.DependencyExternal{{Type: analyzer.ExternalTypePath, Format: "csv", Location: "data/rel.csv"}},
+			catalog:      analyzer.NewMemoryCatalog(&analyzer.CatalogTable{Database: "ods", Table: "events"}),
+		},
+		{
+			name:  "relative name after file format without catalog is a table",
+			sql:   "SELECT * FROM json.`events`",
+			read:  []string{"default_cluster.json.`events`"},
+			write: []string{},
+		},
+		{
+			name:    "relative name after file format found in catalog is a table",
+			sql:     "SELECT * FROM text.`notes`",
+			read:    []string{"default_cluster.text.`notes`"},
+			write:   []string{},
+			catalog: analyzer.NewMemoryCatalog(&analyzer.CatalogTable{Database: "text", Table: "notes"}),
+		},
+		{
+			name:          "INSERT INTO file path",
+			sql:           "INSERT INTO delta.`s3://bucket/events` SELECT * FROM ods.events",
+			read:          []string{"default_cluster.ods.events"},
+			write:         []string{},
+			externalWrite: []*analyzer.DependencyExternal{{Type: analyzer.ExternalTypePath, Format: "delta", Location: "s3://bucket/events"}},
+		},
+		{
+			name:  "INSERT OVERWRITE DIRECTORY USING",
+			sql:   "INSERT OVERWRITE DIRECTORY '/out/events' USING parquet OPTIONS ('compression' = 'snappy') SELECT * FROM ods.events",
+			read:  []string{"default_cluster.ods.events"},
+			write: []string{},
+			externalWrite: []*analyzer.DependencyExternal{{
+				Type:       analyzer.ExternalTypePath,
+				Format:     "parquet",
+				Location:   "/out/events",
+				Properties: map[string]string{"compression": "snappy"},
+			}},
+		},
+		{
+			name:  "INSERT OVERWRITE DIRECTORY with path option",
+			sql:   "INSERT OVERWRITE DIRECTORY USING csv OPTIONS (path '/out/csv', header 'true') SELECT 1",
+			read:  []string{},
+			write: []string{},
+			externalWrite: []*analyzer.DependencyExternal{{
+				Type:       analyzer.ExternalTypePath,
+				Format:     "csv",
+				Location:   "/out/csv",
+				Properties: map[string]string{"path": "/out/csv", "header": "true"},
+			}},
+		},
+		{
+			name:  "INSERT OVERWRITE LOCAL DIRECTORY Hive format",
+			sql:   "INSERT OVERWRITE LOCAL DIRECTORY '/tmp/out' STORED AS ORC SELECT * FROM ods.events",
+			read:  []string{"default_cluster.ods.events"},
+			write: []string{},
+			externalWrite: []*analyzer.DependencyExternal{{
+				Type:       analyzer.ExternalTypePath,
+				Format:     "orc",
+				Location:   "/tmp/out",
+				Properties: map[string]string{"local": "true"},
+			}},
+		},
+		{
+			name:     "CREATE TABLE with LOCATION",
+			sql:      "CREATE TABLE dwd.events (id INT) USING parquet LOCATION 's3://bucket/dwd/events'",
+			read:     []string{},
+			write:    []string{"default_cluster.dwd.events"},
+			location: "s3://bucket/dwd/events",
+		},
+		{
+			name:     "CTAS with LOCATION",
+			sql:      "CREATE TABLE dwd.events USING parquet LOCATION '/warehouse/events' AS SELECT * FROM ods.events",
+			read:     []string{"default_cluster.ods.events"},
+			write:    []string{"default_cluster.dwd.events"},
+			location: "/warehouse/events",
+		},
+		{
+			name:     "ALTER TABLE SET LOCATION",
+			sql:      "ALTER TABLE dwd.events SET LOCATION '/warehouse/events_v2'",
+			read:     []string{},
+			write:    []string{"default_cluster.dwd.events"},
+			location: "/warehouse/events_v2",
+		},
+		{
+			name:  "backquoted database is not a path",
+			sql:   "SELECT * FROM `ods`.`events`",
+			read:  []string{"default_cluster.`ods`.`events`"},
+			write: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := NewDependencyAnalyzer().Analyze(&analyzer.DependencyAnalyzeReq{
+				DefaultCluster:  "default_cluster",
+				DefaultDatabase: "default_db",
+				Type:            analyzer.EngineSpark,
+				SQL:             tt.sql,
+				Catalog:         tt.catalog,
+			})
+			if !assert.NoError(t, err) || !assert.Len(t, results, 1) {
+				return
+			}
+			result := results[0]
+			read, write := []string{}, []string{}
+			for _, table := range result.Read {
+				read = append(read, table.String())
+			}
+			for _, table := range result.Write {
+				write = append(write, table.String())
+				assert.Equal(t, tt.location, table.Location)
+			}
+			assert.Equal(t, tt.read, read)
+			assert.Equal(t, tt.write, write)
+			assert.Equal(t, tt.externalRead, result.ExternalRead)
+			assert.Equal(t, tt.externalWrite, result.ExternalWrite)
+		})
+	}
+}
+
+func TestSparkDependencyAnalyzer_Compound(t *testing.T) {
+	sql := `BEGIN
+  DECLARE n INT DEFAULT 0;
+  SET n = (SELECT COUNT(*) FROM ods.events);
+  IF n > 0 THEN
+    INSERT INTO dwd.events SELECT * FROM ods.events;
+  ELSE
+    INSERT INTO dwd.empty_log VALUES (1);
+  END IF;
+  WHILE n < (SELECT MAX(id) FROM dim.limits) DO
+    SET n = n + 1;
+  END WHILE;
+  inner_blk: BEGIN
+    INSERT OVERWRITE dws.summary SELECT * FROM dwd.events;
+  END inner_blk;
+END;
+SELECT * FROM ads.report;`
+
+	results, err := NewDependencyAnalyzer().Analyze(&analyzer.DependencyAnalyzeReq{
+		DefaultCluster:  "default_cluster",
+		DefaultDatabase: "default_db",
+		Type:            analyzer.EngineSpark,
+		SQL:             sql,
+	})
+	if !assert.NoError(t, err) || !assert.Len(t, results, 2) {
+		return
+	}
+	tableNames := func(tables []*analyzer.DependencyTable) []string {
+		names := []string{}
+		for _, table := range tables {
+			names = append(names, table.String())
+		}
+		return names
+	}
+
+	root := results[0]
+	assert.Equal(t, analyzer.StmtTypeCompound, root.StmtType)
+	assert.Equal(t, []string{
+		"default_cluster.ods.events",
+		"default_cluster.dim.limits",
+		"default_cluster.dwd.events",
+	}, tableNames(root.Read))
+	assert.Equal(t, []string{
+		"default_cluster.dwd.events",
+		"default_cluster.dwd.empty_log",
+		"default_cluster.dws.summary",
+	}, tableNames(root.Write))
+
+	var types []analyzer.StmtType
+	for _, child := range root.Children {
+		types = append(types, child.StmtType)
+	}
+	assert.Equal(t, []analyzer.StmtType{
+		analyzer.StmtTypeDeclare,
+		analyzer.StmtTypeSet,
+		analyzer.StmtTypeControlFlow,
+		analyzer.StmtTypeControlFlow,
+		analyzer.StmtTypeCompound,
+	}, types)
+	if !assert.Len(t, root.Children, 5) {
+		return
+	}
+
+	// SET 中子查询读取的表
+	assert.Equal(t, []string{"default_cluster.ods.events"}, tableNames(root.Children[1].Read))
+	// IF 的两个分支都是子语句
+	branches := root.Children[2]
+	if assert.Len(t, branches.Children, 2) {
+		assert.Equal(t, analyzer.StmtTypeInsert, branches.Children[0].StmtType)
+		assert.Equal(t, []string{"default_cluster.dwd.events"}, tableNames(branches.Children[0].Write))
+		assert.Equal(t, []string{"default_cluster.dwd.empty_log"}, tableNames(branches.Children[1].Write))
+	}
+	// WHILE 条件中读取的表属于 WHILE 语句本身
+	loop := root.Children[3]
+	assert.Equal(t, []string{"default_cluster.dim.limits"}, tableNames(loop.Read))
+	if assert.Len(t, loop.Children, 1) {
+		assert.Equal(t, analyzer.StmtTypeSet, loop.Children[0].StmtType)
+	}
+	block := root.Children[4]
+	if assert.Len(t, block.Children, 1) {
+		assert.Equal(t, analyzer.StmtTypeInsert, block.Children[0].StmtType)
+		assert.Equal(t, []string{"default_cluster.dws.summary"}, tableNames(block.Children[0].Write))
+	}
+
+	assert.Equal(t, analyzer.StmtTypeSelect, results[1].StmtType)
+	assert.Equal(t, []string{"default_cluster.ads.report"}, tableNames(results[1].Read))
+}
+
+func TestSparkDependencyAnalyzer_Template(t *testing.T) {
+	sql := `SET hivevar:db=dwd;
+INSERT INTO ${db}.events_{{ ds }} SELECT * FROM ${src}.events;
+INSERT OVERWRITE DIRECTORY '/out/${dt}' USING parquet SELECT * FROM ${db}.events_{{ ds }};`
+	results, err := NewDependencyAnalyzer().Analyze(&analyzer.DependencyAnalyzeReq{
+		DefaultCluster:  "default_cluster",
+		DefaultDatabase: "default_db",
+		Type:            analyzer.EngineSpark,
+		SQL:             sql,
+		Variables:       map[string]string{"ds": "20240101"},
+	})
+	if !assert.NoError(t, err) || !assert.Len(t, results, 3) {
+		return
+	}
+	tableNames := func(tables []*analyzer.DependencyTable) []string {
+		names := []string{}
+		for _, table := range tables {
+			names = append(names, table.String())
+		}
+		return names
+	}
+	assert.Equal(t, "INSERT INTO dwd.events_20240101 SELECT * FROM ${src}.events;", results[1].Stmt)
+	assert.Equal(t, []string{"default_cluster.*.events"}, tableNames(results[1].Read))
+	assert.Equal(t, []string{"default_cluster.dwd.events_20240101"}, tableNames(results[1].Write))
+	assert.Equal(t, []string{"default_cluster.dwd.events_20240101"}, tableNames(results[2].Read))
+	if assert.Len(t, results[2].ExternalWrite, 1) {
+		assert.Equal(t, "/out/${dt}", results[2].ExternalWrite[0].Location)
+	}
+
+	// 分区中的未解析变量还原为占位符原文
+	results, err = NewDependencyAnalyzer().Analyze(&analyzer.DependencyAnalyzeReq{
+		DefaultCluster:  "default_cluster",
+		DefaultDatabase: "default_db",
+		Type:            analyzer.EngineSpark,
+		SQL:             "INSERT OVERWRITE TABLE dwd.orders PARTITION (dt='${dt}') SELECT * FROM ods.orders",
+	})
+	if assert.NoError(t, err) && assert.Len(t, results, 1) && assert.Len(t, results[0].Write, 1) {
+		assert.Equal(t, []*analyzer.PartitionSpec{{Values: []*analyzer.PartitionValue{{Key: "dt", Value: "${dt}"}}}}, results[0].Write[0].Partitions)
+	}
+	results, err = NewDependencyAnalyzer().Analyze(&analyzer.DependencyAnalyzeReq{
+		DefaultCluster:  "default_cluster",
+		DefaultDatabase: "default_db",
+		Type:            analyzer.EngineSpark,
+		SQL:             "SELECT * FROM ods.orders TIMESTAMP AS OF '${ts}'",
+	})
+	if assert.NoError(t, err) && assert.Len(t, results, 1) && assert.Len(t, results[0].Read, 1) && assert.NotNil(t, results[0].Read[0].AsOf) {
+		assert.Equal(t, "${ts}", results[0].Read[0].AsOf.Value)
+	}
+}
+
+func TestSparkDependencyAnalyzer_Lakehouse(t *testing.T) {
+	tests := []struct {
+		name          string
+		sql           string
+		stmtType      analyzer.StmtType
+		read          []string
+		write         []string
+		asOf          []*analyzer.TimeTravel
+		externalRead  []*analyzer.DependencyExternal
+		externalWrite []*analyzer.DependencyExternal
+	}{
+		{
+			name:     "OPTIMIZE ZORDER BY",
+			sql:      "OPTIMIZE dwd.events WHERE dt >= '2024-01-01' ZORDER BY (uid)",
+			stmtType: analyzer.StmtTypeOptimize,
+			read:     []string{},
+			write:    []string{"default_cluster.dwd.events"},
+		},
+		{
+			name:          "OPTIMIZE path",
+			sql:           "OPTIMIZE delta.`/data/events`",
+			stmtType:      analyzer.StmtTypeOptimize,
+			read:          []string{},
+			write:         []string{},
+			externalWrite: []*analyzer.DependencyExternal{{Type: analyzer.ExternalTypePath, Format: "delta", Location: "/data/events"}},
+		},
+		{
+			name:     "REORG TABLE",
+			sql:      "REORG TABLE dwd.events APPLY (PURGE)",
+			stmtType: analyzer.StmtTypeOptimize,
+			read:     []string{},
+			write:    []string{"default_cluster.dwd.events"},
+		},
+		{
+			name:     "VACUUM RETAIN",
+			sql:      "VACUUM events RETAIN 168 HOURS DRY RUN",
+			stmtType: analyzer.StmtTypeVacuum,
+			read:     []string{},
+			write:    []string{"default_cluster.default_db.events"},
+		},
+		{
+			name:          "VACUUM path",
+			sql:           "VACUUM '/data/events'",
+			stmtType:      analyzer.StmtTypeVacuum,
+			read:          []string{},
+			write:         []string{},
+			externalWrite: []*analyzer.DependencyExternal{{Type: analyzer.ExternalTypePath, Location: "/data/events"}},
+		},
+		{
+			name:     "RESTORE TABLE TO VERSION AS OF",
+			sql:      "RESTORE TABLE dwd.events TO VERSION AS OF 12",
+			stmtType: analyzer.StmtTypeRestore,
+			read:     []string{},
+			write:    []string{"default_cluster.dwd.events"},
+			asOf:     []*analyzer.TimeTravel{{Kind: analyzer.TimeTravelVersion, Value: "12"}},
+		},
+		{
+			name:     "RESTORE TABLE TO TIMESTAMP AS OF",
+			sql:      "RESTORE dwd.events TIMESTAMP AS OF '2024-01-01 00:00:00'",
+			stmtType: analyzer.StmtTypeRestore,
+			read:     []string{},
+			write:    []string{"default_cluster.dwd.events"},
+			asOf:     []*analyzer.TimeTravel{{Kind: analyzer.TimeTravelTimestamp, Value: "2024-01-01 00:00:00"}},
+		},
+		{
+			name:     "ALTER TABLE CREATE BRANCH",
+			sql:      "ALTER TABLE ice.dwd.events CREATE BRANCH IF NOT EXISTS audit AS OF VERSION 1234 RETAIN 7 DAYS",
+			stmtType: analyzer.StmtTypeSnapshotRef,
+			read:     []string{},
+			write:    []string{"ice.dwd.events"},
+		},
+		{
+			name:     "ALTER TABLE DROP TAG",
+			sql:      "ALTER TABLE dwd.events DROP TAG `v1`",
+			stmtType: analyzer.StmtTypeSnapshotRef,
+			read:     []string{},
+			write:    []string{"default_cluster.dwd.events"},
+		},
+		{
+			name:     "ALTER TABLE ADD PARTITION FIELD",
+			sql:      "ALTER TABLE dwd.events ADD PARTITION FIELD days(ts)",
+			stmtType: analyzer.StmtTypeAlterTable,
+			read:     []string{},
+			write:    []string{"default_cluster.dwd.events"},
+		},
+		{
+			name:     "ALTER TABLE WRITE ORDERED BY",
+			sql:      "ALTER TABLE dwd.events WRITE ORDERED BY uid",
+			stmtType: analyzer.StmtTypeAlterTable,
+			read:     []string{},
+			write:    []string{"default_cluster.dwd.events"},
+		},
+		{
+			name:     "CALL rewrite_data_files with named table",
+			sql:      "CALL ice.system.rewrite_data_files(table => 'dwd.events', strategy => 'sort')",
+			stmtType: analyzer.StmtTypeCall,
+			read:     []string{},
+			write:    []string{"default_cluster.dwd.events"},
+		},
+		{
+			name:     "CALL expire_snapshots with positional table",
+			sql:      "CALL ice.system.expire_snapshots('ice.dwd.events', TIMESTAMP '2024-01-01 00:00:00')",
+			stmtType: analyzer.StmtTypeCall,
+			read:     []string{},
+			write:    []string{"ice.dwd.events"},
+		},
+		{
+			name:     "CALL snapshot reads source table",
+			sql:      "CALL ice.system.snapshot('ods.events', 'dwd.events_snapshot')",
+			stmtType: analyzer.StmtTypeCall,
+			read:     []string{"default_cluster.ods.events"},
+			write:    []string{"default_cluster.dwd.events_snapshot"},
+		},
+		{
+			name:         "CALL add_files from path",
+			sql:          "CALL ice.system.add_files(table => 'dwd.events', source_table => '`parquet`.`/data/events`')",
+			stmtType:     analyzer.StmtTypeCall,
+			read:         []string{},
+			write:        []string{"default_cluster.dwd.events"},
+			externalRead: []*analyzer.DependencyExternal{{Type: analyzer.ExternalTypePath, Format: "parquet", Location: "/data/events"}},
+		},
+		{
+			name:     "CALL ancestors_of is read only",
+			sql:      "CALL ice.system.ancestors_of('dwd.events')",
+			stmtType: analyzer.StmtTypeCall,
+			read:     []string{"default_cluster.dwd.events"},
+			write:    []string{},
+		},
+		{
+			name:     "time travel reads",
+			sql:      "SELECT * FROM dwd.events VERSION AS OF 12 e JOIN dim.users FOR TIMESTAMP AS OF '2024-01-01' u ON e.uid = u.id JOIN dim.regions r ON u.rid = r.id",
+			stmtType: analyzer.StmtTypeSelect,
+			read:     []string{"default_cluster.dwd.events", "default_cluster.dim.users", "default_cluster.dim.regions"},
+			write:    []string{},
+			asOf: []*analyzer.TimeTravel{
+				{Kind: analyzer.TimeTravelVersion, Value: "12"},
+				{Kind: analyzer.TimeTravelTimestamp, Value: "2024-01-01"},
+				nil,
+			},
+		},
+		{
+			name:     "time travel by branch name",
+			sql:      "INSERT INTO dwd.events_copy SELECT * FROM dwd.events VERSION AS OF 'audit'",
+			stmtType: analyzer.StmtTypeInsert,
+			read:     []string{"default_cluster.dwd.events"},
+			write:    []string{"default_cluster.dwd.events_copy"},
+			asOf:     []*analyzer.TimeTravel{{Kind: analyzer.TimeTravelVersion, Value: "audit"}},
+		},
+		{
+			name:     "time travel on path by version",
+			sql:      "SELECT * FROM delta.`/tmp/x` VERSION AS OF 1",
+			stmtType: analyzer.StmtTypeSelect,
+			read:     []string{},
+			write:    []string{},
+			externalRead: []*analyzer.DependencyExternal{{
+				Type: analyzer.ExternalTypePath, Format: "delta", Location: "/tmp/x",
+				AsOf: &analyzer.TimeTravel{Kind: analyzer.TimeTravelVersion, Value: "1"},
+			}},
+		},
+		{
+			name:     "time travel on path by timestamp",
+			sql:      "SELECT * FROM delta.`s3://bucket/events` TIMESTAMP AS OF '2024-01-01' e JOIN dim.users u ON e.uid = u.id",
+			stmtType: analyzer.StmtTypeSelect,
+			read:     []string{"default_cluster.dim.users"},
+			write:    []string{},
+			asOf:     []*analyzer.TimeTravel{nil},
+			externalRead: []*analyzer.DependencyExternal{{
+				Type: analyzer.ExternalTypePath, Format: "delta", Location: "s3://bucket/events",
+				AsOf: &analyzer.TimeTravel{Kind: analyzer.TimeTravelTimestamp, Value: "2024-01-01"},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := NewDependencyAnalyzer().Analyze(&analyzer.DependencyAnalyzeReq{
+				DefaultCluster:  "default_cluster",
+				DefaultDatabase: "default_db",
+				Type:            analyzer.EngineSpark,
+				SQL:             tt.sql,
+			})
+			if !assert.NoError(t, err) || !assert.Len(t, results, 1) {
+				return
+			}
+			result := results[0]
+			assert.Equal(t, tt.stmtType, result.StmtType)
+			read, write := []string{}, []string{}
+			var asOf []*analyzer.TimeTravel
+			for _, table := range result.Read {
+				read = append(read, table.String())
+				asOf = append(asOf, table.AsOf)
+			}
+			for _, table := range result.Write {
+				write = append(write, table.String())
+				if table.AsOf != nil {
+					asOf = append(asOf, table.AsOf)
+				}
+			}
+			assert.Equal(t, tt.read, read)
+			assert.Equal(t, tt.write, write)
+			if tt.asOf != nil {
+				assert.Equal(t, tt.asOf, asOf)
+			}
+			assert.Equal(t, tt.externalRead, result.ExternalRead)
+			assert.Equal(t, tt.externalWrite, result.ExternalWrite)
+		})
+	}
+}
+
+func TestSparkDependencyAnalyzer_Functions(t *testing.T) {
+	sql := `ADD JAR hdfs:///libs/udf.jar;
+CREATE TEMPORARY FUNCTION parse_ua AS 'com.example.udf.ParseUA' USING JAR 'hdfs:///libs/ua.jar', FILE '/conf/ua.yaml';
+INSERT INTO dwd.visits SELECT parse_ua(agent), udfs.geo(ip), upper(name), COUNT(*) FROM ods.visits GROUP BY 1, 2, 3;
+DROP TEMPORARY FUNCTION IF EXISTS parse_ua;`
+	results, err := NewDependencyAnalyzer().Analyze(&analyzer.DependencyAnalyzeReq{
+		DefaultCluster:  "default_cluster",
+		DefaultDatabase: "default_db",
+		Type:            analyzer.EngineSpark,
+		SQL:             sql,
+	})
+	if !assert.NoError(t, err) || !assert.Len(t, results, 4) {
+		return
+	}
+
+	assert.Equal(t, analyzer.StmtTypeAddResource, results[0].StmtType)
+	assert.Equal(t, []*analyzer.DependencyExternal{{Type: analyzer.ExternalTypeJar, Location: "hdfs:///libs/udf.jar"}}, results[0].ExternalRead)
+
+	resources := []*analyzer.DependencyExternal{
+		{Type: analyzer.ExternalTypeJar, Location: "hdfs:///libs/ua.jar"},
+		{Type: analyzer.ExternalTypeFile, Location: "/conf/ua.yaml"},
+	}
+	assert.Equal(t, analyzer.StmtTypeCreateFunction, results[1].StmtType)
+	assert.Empty(t, results[1].Read)
+	assert.Equal(t, []*analyzer.DependencyFunction{{
+		Name:      "parse_ua",
+		Temporary: true,
+		ClassName: "com.example.udf.ParseUA",
+		Resources: resources,
+	}}, results[1].FunctionWrite)
+	assert.Equal(t, resources, results[1].ExternalRead)
+
+	assert.Equal(t, analyzer.StmtTypeInsert, results[2].StmtType)
+	assert.Equal(t, []*analyzer.DependencyFunction{
+		{Name: "parse_ua"},
+		{Database: "udfs", Name: "geo"},
+		{Name: "upper", Builtin: true},
+		{Name: "COUNT", Builtin: true},
+	}, results[2].FunctionRead)
+
+	assert.Equal(t, analyzer.StmtTypeDropFunction, results[3].StmtType)
+	assert.Empty(t, results[3].Read)
+	assert.Empty(t, results[3].Write)
+	assert.Equal(t, []*analyzer.DependencyFunction{{Name: "parse_ua", Temporary: true}}, results[3].FunctionWrite)
+}
+
+func TestSparkDependencyAnalyzer_Partitions(t *testing.T) {
+	catalog := analyzer.NewMemoryCatalog(
+		&analyzer.CatalogTable{Database: "ods", Table: "events", PartitionKeys: []string{"dt", "hr"}},
+	)
+	sql := `INSERT INTO dwd.events PARTITION (dt='2024-01-01', hr)
+SELECT e.id, e.hr FROM ods.events e WHERE (e.dt = DATE '2024-01-01' AND e.hr BETWEEN 0 AND 12) AND e.id > 0;
+ALTER TABLE dwd.events DROP IF EXISTS PARTITION (dt='2023-01-01', hr=0), PARTITION (dt='2023-01-01', hr=1);
+SELECT * FROM (SELECT * FROM ods.events WHERE hr IN (1, 2)) t WHERE dt = '2024-01-01'`
+	results, err := NewDependencyAnalyzer().Analyze(&analyzer.DependencyAnalyzeReq{
+		DefaultCluster:  "default_cluster",
+		DefaultDatabase: "default_db",
+		Type:            analyzer.EngineSpark,
+		SQL:             sql,
+		Catalog:         catalog,
+	})
+	if !assert.NoError(t, err) || !assert.Len(t, results, 3) {
+		return
+	}
+	value := func(key, value string) *analyzer.PartitionValue {
+		return &analyzer.PartitionValue{Key: key, Value: value}
+	}
+
+	assert.Equal(t, []*analyzer.PartitionSpec{{Values: []*analyzer.PartitionValue{
+		value("dt", "2024-01-01"), {Key: "hr", Dynamic: true},
+	}}}, results[0].Write[0].Partitions)
+	assert.Equal(t, []*analyzer.PartitionSpec{{Values: []*analyzer.PartitionValue{value("dt", "2024-01-01")}}}, results[0].Read[0].Partitions)
+
+	assert.Equal(t, []*analyzer.PartitionSpec{
+		{Values: []*analyzer.PartitionValue{value("dt", "2023-01-01"), value("hr", "0")}},
+		{Values: []*analyzer.PartitionValue{value("dt", "2023-01-01"), value("hr", "1")}},
+	}, results[1].Write[0].Partitions)
+
+	// 外层查询的条件不作用于子查询中的表
+	assert.Equal(t, []*analyzer.PartitionSpec{
+		{Values: []*analyzer.PartitionValue{value("hr", "1")}},
+		{Values: []*analyzer.PartitionValue{value("hr", "2")}},
+	}, results[2].Read[0].Partitions)
+
+	// 没有 Catalog 时不推断读表的分区
+	results, err = NewDependencyAnalyzer().Analyze(&analyzer.DependencyAnalyzeReq{
+		DefaultCluster:  "default_cluster",
+		DefaultDatabase: "default_db",
+		Type:            analyzer.EngineSpark,
+		SQL:             "SELECT * FROM ods.events WHERE dt = '2024-01-01'",
+	})
+	if assert.NoError(t, err) && assert.Len(t, results, 1) {
+		assert.Nil(t, results[0].Read[0].Partitions)
+	}
+}
+
+func TestSparkDependencyAnalyzer_Spans(t *testing.T) {
+	sql := "SET dt = 2024-01-01;\nSELECT * FROM ods.orders; -- check source\n\n-- load orders\nINSERT INTO dwd.orders SELECT * FROM ods.orders WHERE dt = '${dt}';"
+	results, err := NewDependencyAnalyzer().Analyze(&analyzer.DependencyAnalyzeReq{
+		DefaultCluster:  "default_cluster",
+		DefaultDatabase: "default_db",
+		Type:            analyzer.EngineSpark,
+		SQL:             sql,
+	})
+	if !assert.NoError(t, err) || !assert.Len(t, results, 3) {
+		return
+	}
+
+	// 语句结尾同一行的注释属于该语句，不属于下一条语句
+	assert.Equal(t, "SELECT * FROM ods.orders;", results[1].Stmt)
+	assert.Equal(t, &analyzer.StatementSpan{
+		Start: 21, End: 46, Line: 2, Column: 0,
+		Raw: "SELECT * FROM ods.orders;", Text: "SELECT * FROM ods.orders;",
+		Comments: []string{"-- check source"},
+	}, results[1].Span)
+
+	// 位置对应替换变量前的脚本
+	insert := results[2]
+	assert.Equal(t, "-- load orders\nINSERT INTO dwd.orders SELECT * FROM ods.orders WHERE dt = '2024-01-01';", insert.Stmt)
+	assert.Equal(t, "INSERT INTO dwd.orders SELECT * FROM ods.orders WHERE dt = '2024-01-01';", insert.Span.Text)
+	assert.Equal(t, []string{"-- load orders"}, insert.Span.Comments)
+	assert.Equal(t, 64, insert.Span.Start)
+	assert.Equal(t, len(sql), insert.Span.End)
+	assert.Equal(t, 4, insert.Span.Line)
+	assert.Equal(t, 0, insert.Span.Column)
+}
+
+func TestSparkDependencyAnalyzer_Set(t *testing.T) {
+	sql := "SET x = 1;\nSET spark.sql.shuffle.partitions=10;\nSET VAR n = (SELECT max(id) FROM ods.events);"
+	results, err := NewDependencyAnalyzer().Analyze(&analyzer.DependencyAnalyzeReq{
+		DefaultCluster:  "default_cluster",
+		DefaultDatabase: "default_db",
+		Type:            analyzer.EngineSpark,
+		SQL:             sql,
+	})
+	if !assert.NoError(t, err) || !assert.Len(t, results, 3) {
+		return
+	}
+	for _, result := range results {
+		assert.Equal(t, analyzer.StmtTypeSet, result.StmtType)
+	}
+	assert.Empty(t, results[1].Read)
+	// 变量赋值的查询中读取的表作为读表
+	if assert.Len(t, results[2].Read, 1) {
+		assert.Equal(t, "default_cluster.ods.events", results[2].Read[0].String())
+	}
+}
+
+func TestSparkDependencyAnalyzer_UnclosedComment(t *testing.T) {
+	_, err := NewDependencyAnalyzer().Analyze(&analyzer.DependencyAnalyzeReq{
+		DefaultDatabase: "default_db",
+		SQL:             "SELECT 1;\nSELECT 2 /* oops;\nINSERT INTO t SELECT 3;",
+	})
+	assert.EqualError(t, err, "line:1 column:9 Unclosed bracketed comment")
+}
@@ -0,0 +1,316 @@
+package spark
+
+import (
+	"strings"
+
+	"github.com/Edsuns/sql-parser/analyzer"
+	"github.com/Edsuns/sql-parser/internal/spark/parser"
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// 只读取 table 参数的 Iceberg 系统过程，其余过程的 table 参数作为写表
+var readOnlyProcedures = map[string]bool{
+	"ancestors_of":          true,
+	"create_changelog_view": true,
+}
+
+// procedurePositionalArgs Iceberg 系统过程按位置传入的表参数名，未列出的过程第一个参数为 table
+var procedurePositionalArgs = map[string][]string{
+	"snapshot":  {"source_table", "table"},
+	"add_files": {"table", "source_table"},
+}
+
+// parseLakehouse 识别语法文件不支持的 Delta 和 Iceberg 语句，不是这些语句时返回nil：
+// OPTIMIZE、REORG、VACUUM、RESTORE TABLE，以及 ALTER TABLE 的分支、标签、分区字段、写入顺序和标识字段
+func parseLakehouse(sql, defaultCluster, defaultDatabase string, catalog analyzer.Catalog) *analyzer.DependencyResult {
+	c := newTokenCursor(sql)
+	var stmtType analyzer.StmtType
+	switch c.keyword() {
+	case "OPTIMIZE":
+		c.next()
+		stmtType = analyzer.StmtTypeOptimize
+	case "REORG":
+		c.next()
+		if !c.accept("TABLE") {
+			return nil
+		}
+		stmtType = analyzer.StmtTypeOptimize
+	case "VACUUM":
+		c.next()
+		stmtType = analyzer.StmtTypeVacuum
+	case "RESTORE":
+		c.next()
+		c.accept("TABLE")
+		stmtType = analyzer.StmtTypeRestore
+	case "ALTER":
+		c.next()
+		if !c.accept("TABLE") {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	table, external := c.target(catalog, defaultCluster)
+	if table == nil && external == nil {
+		return nil
+	}
+	switch stmtType {
+	case analyzer.StmtTypeRestore:
+		c.accept("TO")
+		kind := analyzer.TimeTravelKind(c.keyword())
+		if kind != analyzer.TimeTravelVersion && kind != analyzer.TimeTravelTimestamp {
+			return nil
+		}
+		c.next()
+		if !c.accept("AS") || !c.accept("OF") || c.done() {
+			return nil
+		}
+		if table != nil {
+			table.AsOf = &analyzer.TimeTravel{Kind: kind, Value: unquote(c.rest())}
+		}
+	case "":
+		stmtType = alterTableType(c)
+		if stmtType == "" {
+			return nil
+		}
+	}
+
+	l := newDependencyListener(defaultCluster, defaultDatabase)
+	if table != nil {
+		l.addWriteTable(table.Cluster, table.Database, table.Table)
+		l.dependencies.Write[0].AsOf = table.AsOf
+	}
+	if external != nil {
+		l.dependencies.ExternalWrite = append(l.dependencies.ExternalWrite, external)
+	}
+	l.dependencies.Stmt = sql
+	l.dependencies.StmtType = stmtType
+	return l.dependencies
+}
+
+// alterTableType 识别语法文件不支持的 Iceberg ALTER TABLE 子句，不支持时返回空
+func alterTableType(c *tokenCursor) analyzer.StmtType {
+	switch c.keyword() {
+	case "CREATE", "REPLACE", "DROP":
+		op := c.keyword()
+		c.next()
+		if op == "CREATE" && c.accept("OR") && !c.accept("REPLACE") {
+			return ""
+		}
+		switch c.keyword() {
+		case "BRANCH", "TAG":
+			return analyzer.StmtTypeSnapshotRef
+		case "PARTITION":
+			c.next()
+			if op != "CREATE" && c.keyword() == "FIELD" {
+				return analyzer.StmtTypeAlterTable
+			}
+		case "IDENTIFIER":
+			if op == "DROP" {
+				return analyzer.StmtTypeAlterTable
+			}
+		}
+	case "ADD":
+		c.next()
+		if c.accept("PARTITION") && c.keyword() == "FIELD" {
+			return analyzer.StmtTypeAlterTable
+		}
+	case "WRITE":
+		return analyzer.StmtTypeAlterTable
+	case "SET":
+		c.next()
+		if c.keyword() == "IDENTIFIER" {
+			return analyzer.StmtTypeAlterTable
+		}
+	}
+	return ""
+}
+
+// tokenCursor 按顺序读取语句中默认通道的token
+type tokenCursor struct {
+	sql    string
+	tokens []antlr.Token
+	pos    int
+}
+
+func newTokenCursor(sql string) *tokenCursor {
+	c := &tokenCursor{sql: sql}
+	for _, token := range makeLexer(sql).GetAllTokens() {
+		if token.GetChannel() == antlr.TokenDefaultChannel && token.GetTokenType() != parser.SqlBaseLexerSEMICOLON {
+			c.tokens = append(c.tokens, token)
+		}
+	}
+	return c
+}
+
+func (c *tokenCursor) done() bool {
+	return c.pos >= len(c.tokens)
+}
+
+// keyword 当前token的大写文本，读完时返回空
+func (c *tokenCursor) keyword() string {
+	if c.done() {
+		return ""
+	}
+	return strings.ToUpper(c.tokens[c.pos].GetText())
+}
+
+func (c *tokenCursor) next() antlr.Token {
+	if c.done() {
+		return nil
+	}
+	c.pos++
+	return c.tokens[c.pos-1]
+}
+
+// accept 当前token是指定关键字时跳过它
+func (c *tokenCursor) accept(keyword string) bool {
+	if c.keyword() != keyword {
+		return false
+	}
+	c.pos++
+	return true
+}
+
+// rest 剩余token对应的原始文本
+func (c *tokenCursor) rest() string {
+	if c.done() {
+		return ""
+	}
+	start, stop := c.tokens[c.pos].GetStart(), c.tokens[len(c.tokens)-1].GetStop()
+	c.pos = len(c.tokens)
+	return c.sql[start : stop+1]
+}
+
+// target 读取语句操作的表，可以是表名、'path' 或 format.`path`，catalog 用于区分 format.`path` 和表
+func (c *tokenCursor) target(catalog analyzer.Catalog, cluster string) (*analyzer.DependencyTable, *analyzer.DependencyExternal) {
+	if c.done() {
+		return nil, nil
+	}
+	switch c.tokens[c.pos].GetTokenType() {
+	case parser.SqlBaseLexerSTRING_LITERAL, parser.SqlBaseLexerDOUBLEQUOTED_STRING:
+		return nil, &analyzer.DependencyExternal{
+			Type:     analyzer.ExternalTypePath,
+			Location: unquote(c.next().GetText()),
+		}
+	}
+	parts := []string{c.next().GetText()}
+	for !c.done() && c.tokens[c.pos].GetTokenType() == parser.SqlBaseLexerDOT {
+		c.next()
+		if c.done() {
+			return nil, nil
+		}
+		parts = append(parts, c.next().GetText())
+	}
+	return tableFromParts(catalog, cluster, parts)
+}
+
+// tableFromParts 根据名称的各部分构建表或文件路径，表名缺少的集群和数据库为空，
+// catalog 和 cluster 用于判断 format.`path` 是否为表
+func tableFromParts(catalog analyzer.Catalog, cluster string, parts []string) (*analyzer.DependencyTable, *analyzer.DependencyExternal) {
+	if len(parts) == 2 {
+		if external := pathExternal(catalog, cluster, parts[0], parts[1]); external != nil {
+			return nil, external
+		}
+	}
+	table := &analyzer.DependencyTable{}
+	switch len(parts) {
+	case 0:
+		return nil, nil
+	case 1:
+		table.Table = parts[0]
+	case 2:
+		table.Database, table.Table = parts[0], parts[1]
+	default:
+		table.Cluster, table.Database, table.Table = parts[0], parts[1], parts[2]
+	}
+	return table, nil
+}
+
+// splitQualifiedName 按点拆分限定名，反引号内的点不拆分，反引号保留
+func splitQualifiedName(name string) []string {
+	var parts []string
+	var sb strings.Builder
+	quoted := false
+	for _, r := range name {
+		switch {
+		case r == '`':
+			quoted = !quoted
+		case r == '.' && !quoted:
+			parts = append(parts, sb.String())
+			sb.Reset()
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return append(parts, sb.String())
+}
+
+// EnterCall 进入CALL语句时调用，Iceberg系统过程按表参数确定读写表
+func (l *dependencyListener) EnterCall(ctx *parser.CallContext) {
+	l.curOpType = analyzer.StmtTypeCall
+	l.isOnlyComment = false
+	if l.firstOpType == "" {
+		l.firstOpType = analyzer.StmtTypeCall
+	}
+	name := ctx.IdentifierReference().GetText()
+	procedure := strings.ToLower(name[strings.LastIndexByte(name, '.')+1:])
+	positional, ok := procedurePositionalArgs[procedure]
+	if !ok {
+		positional = []string{"table"}
+	}
+	for i, arg := range ctx.AllFunctionArgument() {
+		key, value := "", antlr.ParserRuleContext(arg.Expression())
+		if named := arg.NamedArgumentExpression(); named != nil {
+			key, value = strings.ToLower(named.GetKey().GetText()), named.GetValue()
+		} else if i < len(positional) {
+			key = positional[i]
+		}
+		if key != "table" && key != "source_table" {
+			continue
+		}
+		text := value.GetText()
+		if unquote(text) == text {
+			// 只处理字符串字面量形式的表名
+			continue
+		}
+		table, external := tableFromParts(l.catalog, l.defaultCluster, splitQualifiedName(unquote(text)))
+		isRead := key == "source_table" || readOnlyProcedures[procedure]
+		switch {
+		case external != nil && isRead:
+			l.dependencies.ExternalRead = append(l.dependencies.ExternalRead, external)
+		case external != nil:
+			l.dependencies.ExternalWrite = append(l.dependencies.ExternalWrite, external)
+		case isRead:
+			l.addReadTable(table.Cluster, table.Database, table.Table)
+		default:
+			l.isWriteOp = true
+			l.addWriteTable(table.Cluster, table.Database, table.Table)
+		}
+	}
+}
+
+// EnterTableName 进入表关系时调用，记录 VERSION AS OF、TIMESTAMP AS OF 限定
+func (l *dependencyListener) EnterTableName(ctx *parser.TableNameContext) {
+	l.lastTable = nil
+	if temporal := ctx.TemporalClause(); temporal != nil {
+		l.asOf = timeTravel(temporal)
+	}
+}
+
+// ExitTableName 退出表关系时调用，时间旅行限定只作用于该表，同时记录表关系对应的读表
+func (l *dependencyListener) ExitTableName(ctx *parser.TableNameContext) {
+	l.asOf = nil
+	if l.lastTable != nil {
+		l.relations[ctx] = l.lastTable
+	}
+}
+
+// timeTravel 解析时间旅行子句
+func timeTravel(ctx parser.ITemporalClauseContext) *analyzer.TimeTravel {
+	if ctx.Version() != nil {
+		return &analyzer.TimeTravel{Kind: analyzer.TimeTravelVersion, Value: unquote(ctx.Version().GetText())}
+	}
+	return &analyzer.TimeTravel{Kind: analyzer.TimeTravelTimestamp, Value: unquote(analyzer.OriginalText(ctx.GetTimestamp()))}
+}
@@ -2,6 +2,7 @@ package spark
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/Edsuns/sql-parser/analyzer"
 	"github.com/Edsuns/sql-parser/internal/spark/parser"
@@ -15,12 +16,19 @@ type dependencyListener struct {
 	dependencies    *analyzer.DependencyResult
 	defaultCluster  string
 	defaultDatabase string
-	curOpType       analyzer.StmtType // 当前操作类型：SELECT, INSERT, UPDATE, DELETE, MERGE, CREATE_TABLE, CREATE_VIEW, ALTER_TABLE, REPLACE_TABLE, DROP_TABLE, CREATE_LIKE
-	firstOpType     analyzer.StmtType // 第一个操作类型，根据规则：第一个写入表的OpType，若没有写入则取第一个读取表的OpType
-	comments        []string          // 存储解析到的注释
-	isOnlyComment   bool              // 标记当前SQL是否只包含注释
-	isWriteOp       bool              // 是否已遇到写入操作
-	cteNames        map[string]bool   // 存储CTE名称，避免将CTE作为表依赖
+	curOpType       analyzer.StmtType                  // 当前操作类型：SELECT, INSERT, UPDATE, DELETE, MERGE, CREATE_TABLE, CREATE_VIEW, ALTER_TABLE, REPLACE_TABLE, DROP_TABLE, CREATE_LIKE
+	firstOpType     analyzer.StmtType                  // 第一个操作类型，根据规则：第一个写入表的OpType，若没有写入则取第一个读取表的OpType
+	comments        []string                           // 存储解析到的注释
+	isOnlyComment   bool                               // 标记当前SQL是否只包含注释
+	isWriteOp       bool                               // 是否已遇到写入操作
+	cteNames        map[string]bool                    // 存储CTE名称，避免将CTE作为表依赖
+	isTemporary     bool                               // 是否在创建临时表或临时视图
+	location        string                             // 建表或修改表时显式指定的LOCATION
+	asOf            *analyzer.TimeTravel               // 当前表关系的时间旅行限定
+	functionRef     parser.IIdentifierReferenceContext // 创建或删除的函数名，不作为表
+	lastTable       *analyzer.DependencyTable          // 最近添加的表，之后的分区限定属于该表
+	relations       relationTables                     // 表关系对应的读表，用于将WHERE条件关联到读表
+	catalog         analyzer.Catalog                   // 提供时根据其中的分区键得到读表读取的分区
 }
 
 // newDependencyListener 创建新的监听器实例
@@ -37,6 +45,7 @@ func newDependencyListener(defaultCluster, defaultDatabase string) *dependencyLi
 		isOnlyComment:   true, // 默认认为是只有注释，遇到非注释内容时设置为false
 		isWriteOp:       false,
 		cteNames:        make(map[string]bool),
+		relations:       make(relationTables),
 	}
 }
 
@@ -113,6 +122,46 @@ func (l *dependencyListener) EnterInsertInto(ctx *parser.InsertIntoContext) {
 	l.onWriteStmt()
 }
 
+// EnterInsertOverwriteDir 进入INSERT OVERWRITE DIRECTORY ... USING语句时调用，目录路径也可以在OPTIONS中指定
+func (l *dependencyListener) EnterInsertOverwriteDir(ctx *parser.InsertOverwriteDirContext) {
+	l.curOpType = analyzer.StmtTypeInsert
+	l.onWriteStmt()
+	external := &analyzer.DependencyExternal{
+		Type:   analyzer.ExternalTypePath,
+		Format: ctx.TableProvider().MultipartIdentifier().GetText(),
+	}
+	if ctx.GetOptions() != nil {
+		external.Properties = propertyMap(ctx.GetOptions())
+		external.Location = external.Properties["path"]
+	}
+	if ctx.GetPath() != nil {
+		external.Location = stringValue(ctx.GetPath())
+	}
+	if ctx.LOCAL() != nil {
+		external.Properties = setProperty(external.Properties, "local", "true")
+	}
+	l.dependencies.ExternalWrite = append(l.dependencies.ExternalWrite, external)
+}
+
+// EnterInsertOverwriteHiveDir 进入Hive格式的INSERT OVERWRITE DIRECTORY语句时调用
+func (l *dependencyListener) EnterInsertOverwriteHiveDir(ctx *parser.InsertOverwriteHiveDirContext) {
+	l.curOpType = analyzer.StmtTypeInsert
+	l.onWriteStmt()
+	external := &analyzer.DependencyExternal{
+		Type:     analyzer.ExternalTypePath,
+		Location: stringValue(ctx.GetPath()),
+	}
+	if ctx.CreateFileFormat() != nil {
+		if format, ok := ctx.CreateFileFormat().FileFormat().(*parser.GenericFileFormatContext); ok {
+			external.Format = strings.ToLower(format.GetText())
+		}
+	}
+	if ctx.LOCAL() != nil {
+		external.Properties = setProperty(external.Properties, "local", "true")
+	}
+	l.dependencies.ExternalWrite = append(l.dependencies.ExternalWrite, external)
+}
+
 // EnterDeleteFromTable 进入删除语句时调用
 func (l *dependencyListener) EnterDeleteFromTable(ctx *parser.DeleteFromTableContext) {
 	l.curOpType = analyzer.StmtTypeDelete
@@ -137,9 +186,16 @@ func (l *dependencyListener) EnterMergeIntoTable(ctx *parser.MergeIntoTableConte
 	l.onWriteStmt()
 }
 
+// EnterDropView 进入删除视图语句时调用
+func (l *dependencyListener) EnterDropView(ctx *parser.DropViewContext) {
+	l.curOpType = analyzer.StmtTypeDropTable
+	l.onWriteStmt()
+}
+
 // EnterCreateView 进入创建视图语句时调用
 func (l *dependencyListener) EnterCreateView(ctx *parser.CreateViewContext) {
 	l.curOpType = analyzer.StmtTypeCreateView
+	l.isTemporary = ctx.TEMPORARY() != nil
 	l.onWriteStmt()
 }
 
@@ -224,6 +280,10 @@ func (l *dependencyListener) EnterDropTablePartitions(ctx *parser.DropTableParti
 // EnterSetTableLocation 进入设置表位置语句时调用
 func (l *dependencyListener) EnterSetTableLocation(ctx *parser.SetTableLocationContext) {
 	l.curOpType = analyzer.StmtTypeAlterTable
+	// 修改分区的位置不影响表的位置
+	if ctx.PartitionSpec() == nil {
+		l.location = locationValue([]parser.ILocationSpecContext{ctx.LocationSpec()})
+	}
 	l.onWriteStmt()
 }
 
@@ -266,21 +326,42 @@ func (l *dependencyListener) EnterDropTable(ctx *parser.DropTableContext) {
 // EnterCreateTable 进入创建表语句时调用
 func (l *dependencyListener) EnterCreateTable(ctx *parser.CreateTableContext) {
 	l.curOpType = analyzer.StmtTypeCreateTable
+	l.isTemporary = ctx.CreateTableHeader().TEMPORARY() != nil
+	l.location = locationValue(ctx.CreateTableClauses().AllLocationSpec())
 	l.onWriteStmt()
 }
 
 // EnterCreateTableLike 进入创建表（LIKE）语句时调用
 func (l *dependencyListener) EnterCreateTableLike(ctx *parser.CreateTableLikeContext) {
 	l.curOpType = analyzer.StmtTypeCreateLike
+	l.location = locationValue(ctx.AllLocationSpec())
 	l.onWriteStmt()
 }
 
 // EnterReplaceTable 进入替换表语句时调用
 func (l *dependencyListener) EnterReplaceTable(ctx *parser.ReplaceTableContext) {
 	l.curOpType = analyzer.StmtTypeReplaceTable
+	l.location = locationValue(ctx.CreateTableClauses().AllLocationSpec())
 	l.onWriteStmt()
 }
 
+// EnterCreateVariable 进入声明变量语句时调用
+func (l *dependencyListener) EnterCreateVariable(ctx *parser.CreateVariableContext) {
+	l.curOpType = analyzer.StmtTypeDeclare
+	l.isOnlyComment = false
+	if l.firstOpType == "" {
+		l.firstOpType = analyzer.StmtTypeDeclare
+	}
+}
+
+// EnterSetVariable 进入变量赋值语句时调用，赋值的查询中读取的表作为读表
+func (l *dependencyListener) EnterSetVariable(ctx *parser.SetVariableContext) {
+	l.isOnlyComment = false
+	if l.firstOpType == "" {
+		l.firstOpType = analyzer.StmtTypeSet
+	}
+}
+
 // EnterComment 进入注释语句时调用
 func (l *dependencyListener) EnterComment(ctx *parser.CommentContext) {
 	// 记录注释内容
@@ -308,13 +389,26 @@ func (l *dependencyListener) EnterUseNamespace(ctx *parser.UseNamespaceContext)
 
 // EnterIdentifierReference 进入标识符引用时调用，用于提取数据库名和表名
 func (l *dependencyListener) EnterIdentifierReference(ctx *parser.IdentifierReferenceContext) {
-	// USE语句不应该添加表依赖
-	if l.curOpType == analyzer.StmtTypeUseDatabase || l.curOpType == analyzer.StmtTypeUseCatalog {
+	// USE语句和声明的变量名不应该添加表依赖
+	// CALL语句的过程名不是表，表参数在 EnterCall 中处理
+	if l.curOpType == analyzer.StmtTypeUseDatabase || l.curOpType == analyzer.StmtTypeUseCatalog || l.curOpType == analyzer.StmtTypeDeclare ||
+		l.curOpType == analyzer.StmtTypeCall || parser.IIdentifierReferenceContext(ctx) == l.functionRef {
 		return
 	}
 
 	if ctx.MultipartIdentifier() != nil {
 		parts := ctx.MultipartIdentifier().AllErrorCapturingIdentifier()
+		// format.`path` 形式直接读写文件
+		if external := l.pathRelation(parts); external != nil {
+			if l.curOpType == "" || l.curOpType == analyzer.StmtTypeSelect {
+				external.AsOf = l.asOf
+				l.asOf = nil
+				l.dependencies.ExternalRead = append(l.dependencies.ExternalRead, external)
+			} else {
+				l.dependencies.ExternalWrite = append(l.dependencies.ExternalWrite, external)
+			}
+			return
+		}
 		if len(parts) > 0 {
 			cluster, database, tableName := l.extractTableInfo(parts)
 			// 检查是否是CTE名称，如果是则跳过，CTE不是实际的表依赖
@@ -365,11 +459,14 @@ func (l *dependencyListener) addReadTable(cluster, database, table string) {
 	if database == "" {
 		database = l.defaultDatabase
 	}
-	l.dependencies.Read = append(l.dependencies.Read, &analyzer.DependencyTable{
+	l.lastTable = &analyzer.DependencyTable{
 		Cluster:  cluster,
 		Database: database,
 		Table:    table,
-	})
+		AsOf:     l.asOf,
+	}
+	l.dependencies.Read = append(l.dependencies.Read, l.lastTable)
+	l.asOf = nil
 }
 
 // addWriteTable 添加写表信息
@@ -380,11 +477,113 @@ func (l *dependencyListener) addWriteTable(cluster, database, table string) {
 	if database == "" {
 		database = l.defaultDatabase
 	}
-	l.dependencies.Write = append(l.dependencies.Write, &analyzer.DependencyTable{
-		Cluster:  cluster,
-		Database: database,
-		Table:    table,
-	})
+	l.lastTable = &analyzer.DependencyTable{
+		Cluster:   cluster,
+		Database:  database,
+		Table:     table,
+		Temporary: l.isTemporary,
+		Location:  l.location,
+	}
+	l.dependencies.Write = append(l.dependencies.Write, l.lastTable)
+	// LOCATION 只属于语句的第一个写表
+	l.location = ""
+}
+
+// pathRelation 识别 format.`path` 形式的文件关系，例如 parquet.`/data/events`
+func (l *dependencyListener) pathRelation(parts []parser.IErrorCapturingIdentifierContext) *analyzer.DependencyExternal {
+	if len(parts) != 2 {
+		return nil
+	}
+	return pathExternal(l.catalog, l.defaultCluster, parts[0].GetText(), parts[1].GetText())
+}
+
+// fileFormats Spark 内置的文件数据源格式，catalog 中没有 format.`path` 这张表时 path 可以是相对路径
+var fileFormats = map[string]bool{
+	"parquet": true, "csv": true, "json": true, "orc": true, "text": true,
+	"avro": true, "delta": true, "iceberg": true, "binaryfile": true,
+}
+
+// pathExternal 识别 format 和反引号括起的路径。提供 catalog 时先查找 format 库中的这张表，存在时不是路径；
+// 否则路径以 / 开头或带有 scheme 时是路径，catalog 中没有这张表且 format 为内置文件格式时相对路径也是路径，
+// 以免没有 catalog 时把 db.`table` 当作路径
+func pathExternal(catalog analyzer.Catalog, cluster, format, text string) *analyzer.DependencyExternal {
+	if len(text) < 2 || text[0] != '`' || text[len(text)-1] != '`' {
+		return nil
+	}
+	path := strings.ReplaceAll(text[1:len(text)-1], "``", "`")
+	format = strings.ToLower(strings.Trim(format, "`"))
+	missing := false
+	if catalog != nil {
+		exists, err := catalog.TableExists(cluster, format, path)
+		if err == nil && exists {
+			return nil
+		}
+		missing = err == nil
+	}
+	if !strings.HasPrefix(path, "/") && !strings.Contains(path, ":/") && !(missing && fileFormats[format]) {
+		return nil
+	}
+	return &analyzer.DependencyExternal{
+		Type:     analyzer.ExternalTypePath,
+		Format:   format,
+		Location: path,
+	}
+}
+
+// locationValue 获取LOCATION子句的路径，没有时返回空字符串
+func locationValue(specs []parser.ILocationSpecContext) string {
+	if len(specs) == 0 || specs[0] == nil {
+		return ""
+	}
+	return stringValue(specs[len(specs)-1].StringLit())
+}
+
+// stringValue 获取字符串字面量的值，相邻的字面量会被拼接
+func stringValue(ctx parser.IStringLitContext) string {
+	var sb strings.Builder
+	for _, lit := range ctx.AllSingleStringLit() {
+		sb.WriteString(unquote(lit.GetText()))
+	}
+	return sb.String()
+}
+
+// propertyMap 将属性列表转换为map，键和值去掉引号
+func propertyMap(ctx parser.IPropertyListContext) map[string]string {
+	properties := make(map[string]string)
+	for _, property := range ctx.AllProperty() {
+		var key, value antlr.ParserRuleContext
+		switch p := property.(type) {
+		case *parser.PropertyWithKeyAndEqualsContext:
+			key, value = p.GetKey(), p.GetValue()
+		case *parser.PropertyWithKeyNoEqualsContext:
+			key, value = p.GetKey(), p.GetValue()
+		}
+		if key == nil {
+			continue
+		}
+		v := ""
+		if value != nil && !value.IsEmpty() {
+			v = unquote(value.GetText())
+		}
+		properties[unquote(key.GetText())] = v
+	}
+	return properties
+}
+
+func setProperty(properties map[string]string, key, value string) map[string]string {
+	if properties == nil {
+		properties = make(map[string]string)
+	}
+	properties[key] = value
+	return properties
+}
+
+// unquote 去掉字符串字面量的引号
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
 }
 
 func (l *dependencyListener) onWriteStmt() {
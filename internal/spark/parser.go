@@ -6,6 +6,9 @@ import (
 	"github.com/antlr4-go/antlr/v4"
 )
 
+// dialect 词法分析出错时无语法拆分SQL语句使用的词法规则，块注释可以嵌套，$$ 用于 Python 函数体
+var dialect = analyzer.Dialect{NestedComments: true, DollarQuotes: true, BackslashEscapes: true}
+
 func makeLexer(sql string) *parser.SqlBaseLexer {
 	// 创建字符流
 	input := analyzer.NewCaseInsensitiveInputStream(sql)
@@ -0,0 +1,68 @@
+package spark
+
+import (
+	"strings"
+
+	"github.com/Edsuns/sql-parser/analyzer"
+	"github.com/Edsuns/sql-parser/internal/spark/parser"
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// relationTables 表关系对应的读表
+type relationTables map[*parser.TableNameContext]*analyzer.DependencyTable
+
+// EnterPartitionSpec 进入分区限定时调用，例如 INSERT OVERWRITE TABLE t PARTITION (dt='2024-01-01', hr)，分区属于前面的表
+func (l *dependencyListener) EnterPartitionSpec(ctx *parser.PartitionSpecContext) {
+	if l.lastTable == nil {
+		return
+	}
+	spec := &analyzer.PartitionSpec{}
+	for _, val := range ctx.AllPartitionVal() {
+		value := &analyzer.PartitionValue{Key: strings.Trim(val.Identifier().GetText(), "`")}
+		switch {
+		case val.Constant() != nil:
+			value.Value = unquote(val.Constant().GetText())
+		case val.DEFAULT() != nil:
+			value.Value = val.DEFAULT().GetText()
+		default:
+			// 没有取值的分区键为动态分区
+			value.Dynamic = true
+		}
+		spec.Values = append(spec.Values, value)
+	}
+	l.lastTable.Partitions = append(l.lastTable.Partitions, spec)
+}
+
+// EnterWhereClause 进入WHERE子句时调用，根据 Catalog 中的分区键得到同一查询中读表读取的分区
+func (l *dependencyListener) EnterWhereClause(ctx *parser.WhereClauseContext) {
+	query, ok := ctx.GetParent().(*parser.RegularQuerySpecificationContext)
+	if l.catalog == nil || !ok || query.FromClause() == nil {
+		return
+	}
+	filters := analyzer.ParseFilters(analyzer.ContextTokens(ctx.GetParser().GetTokenStream(), ctx.BooleanExpression()))
+	for _, relation := range tableRelations(query.FromClause(), nil) {
+		table := l.relations[relation]
+		if table == nil {
+			continue
+		}
+		alias := ""
+		if relation.TableAlias().StrictIdentifier() != nil {
+			alias = strings.Trim(relation.TableAlias().StrictIdentifier().GetText(), "`")
+		}
+		analyzer.ApplyFilters(l.catalog, table, alias, filters)
+	}
+}
+
+// tableRelations 查找FROM子句中的表关系，不包括子查询中的表
+func tableRelations(tree antlr.Tree, relations []*parser.TableNameContext) []*parser.TableNameContext {
+	switch node := tree.(type) {
+	case *parser.TableNameContext:
+		return append(relations, node)
+	case *parser.QueryContext:
+		return relations
+	}
+	for _, child := range tree.GetChildren() {
+		relations = tableRelations(child, relations)
+	}
+	return relations
+}
@@ -0,0 +1,127 @@
+package spark
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Edsuns/sql-parser/analyzer"
+	"github.com/Edsuns/sql-parser/internal/spark/parser"
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// splitSQL 拆分SQL语句，保留原始缩进和换行，BEGIN ... END 复合语句内部的分号不作为语句结束
+func splitSQL(sql string) []string {
+	lexer := makeLexer(sql)
+	errs := analyzer.WatchLexerErrors(lexer)
+	var tokens []antlr.Token
+	for {
+		token := lexer.NextToken()
+		if token.GetTokenType() == antlr.TokenEOF {
+			break
+		}
+		tokens = append(tokens, token)
+		if isUnrecognized(token) {
+			errs.Count++
+		}
+	}
+	// 词法分析出错时按token拆分的结果不可靠，改为无语法拆分
+	if errs.Count > 0 {
+		return analyzer.SplitText(sql, dialect)
+	}
+
+	var result []string
+	var s strings.Builder
+	first := true     // 是否还没遇到当前语句的第一个有效token
+	compound := false // 当前语句是否为复合语句
+	depth := 0        // 复合语句中 BEGIN/CASE 与 END 的嵌套深度
+	prev := antlr.TokenInvalidType
+	for i, token := range tokens {
+		s.WriteString(token.GetText())
+		if token.GetChannel() != antlr.TokenDefaultChannel {
+			continue
+		}
+		if first {
+			first = false
+			compound = isCompoundStart(tokens, i)
+		}
+		if compound {
+			switch token.GetTokenType() {
+			case parser.SqlBaseLexerBEGIN:
+				depth++
+			case parser.SqlBaseLexerCASE:
+				// END CASE 中的 CASE 不是新的 CASE
+				if prev != parser.SqlBaseLexerEND {
+					depth++
+				}
+			case parser.SqlBaseLexerEND:
+				// END IF、END WHILE 等没有对应的 BEGIN，END CASE 与 CASE 对应
+				switch nextTokenType(tokens, i) {
+				case parser.SqlBaseLexerIF, parser.SqlBaseLexerWHILE, parser.SqlBaseLexerLOOP,
+					parser.SqlBaseLexerREPEAT, parser.SqlBaseLexerFOR:
+				default:
+					depth--
+				}
+			}
+		}
+		prev = token.GetTokenType()
+		// 分号则是语句结束
+		if token.GetTokenType() == parser.SqlBaseLexerSEMICOLON && depth <= 0 {
+			result = append(result, strings.TrimSpace(s.String()))
+			s.Reset()
+			first, compound, depth = true, false, 0
+		}
+	}
+	// 处理最后一条可能没有分号结束的语句
+	if s.Len() > 0 {
+		result = append(result, strings.TrimSpace(s.String()))
+	}
+	return result
+}
+
+// isUnrecognized 判断token是否为词法分析器无法识别的字符，例如未闭合的引号、$$ 字符串。
+// 未闭合的块注释一直延续到脚本结尾，之后的内容都属于同一条语句，由 unclosedComment 报错
+func isUnrecognized(token antlr.Token) bool {
+	return token.GetTokenType() == parser.SqlBaseLexerUNRECOGNIZED
+}
+
+// unclosedComment 与 Spark 一样把未闭合的块注释作为语法错误
+func unclosedComment(stream antlr.TokenStream) error {
+	tokens, ok := stream.(*antlr.CommonTokenStream)
+	if !ok {
+		return nil
+	}
+	for _, token := range tokens.GetAllTokens() {
+		if token.GetTokenType() == parser.SqlBaseLexerBRACKETED_COMMENT && !strings.HasSuffix(token.GetText(), "*/") {
+			return fmt.Errorf("line:%d column:%d Unclosed bracketed comment", token.GetLine(), token.GetColumn())
+		}
+	}
+	return nil
+}
+
+// isCompoundStart 判断语句是否以 BEGIN 或 label: BEGIN 开始
+func isCompoundStart(tokens []antlr.Token, i int) bool {
+	if tokens[i].GetTokenType() == parser.SqlBaseLexerBEGIN {
+		return true
+	}
+	j := nextTokenIndex(tokens, i)
+	return j >= 0 && tokens[j].GetTokenType() == parser.SqlBaseLexerCOLON &&
+		nextTokenType(tokens, j) == parser.SqlBaseLexerBEGIN
+}
+
+// nextTokenIndex 返回下一个有效token的下标，没有时返回-1
+func nextTokenIndex(tokens []antlr.Token, i int) int {
+	for j := i + 1; j < len(tokens); j++ {
+		if tokens[j].GetChannel() == antlr.TokenDefaultChannel {
+			return j
+		}
+	}
+	return -1
+}
+
+// nextTokenType 返回下一个有效token的类型
+func nextTokenType(tokens []antlr.Token, i int) int {
+	if j := nextTokenIndex(tokens, i); j >= 0 {
+		return tokens[j].GetTokenType()
+	}
+	return antlr.TokenEOF
+}
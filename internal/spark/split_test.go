@@ -2,8 +2,6 @@ package spark
 
 import (
 	"testing"
-
-	"github.com/Edsuns/sql-parser/analyzer"
 )
 
 func TestSplitSQL(t *testing.T) {
@@ -185,11 +183,67 @@ FROM
 				`WITH orders_2023 AS (SELECT order_id, customer_id, order_date, total_amount FROM orders WHERE YEAR(order_date) = 2023) SELECT * FROM orders_2023;`,
 			},
 		},
+		{
+			name: "compound statement",
+			sql: `BEGIN
+  DECLARE n INT DEFAULT 0;
+  IF n > 0 THEN
+    INSERT INTO t1 SELECT * FROM t2;
+  END IF;
+  WHILE n < 3 DO
+    SET n = n + 1;
+  END WHILE;
+  SELECT CASE WHEN n > 1 THEN 'a' ELSE 'b' END;
+  CASE n WHEN 3 THEN SELECT 1; END CASE;
+  inner: BEGIN
+    LEAVE inner;
+  END inner;
+END;
+SELECT * FROM t3;`,
+			expected: []string{
+				`BEGIN
+  DECLARE n INT DEFAULT 0;
+  IF n > 0 THEN
+    INSERT INTO t1 SELECT * FROM t2;
+  END IF;
+  WHILE n < 3 DO
+    SET n = n + 1;
+  END WHILE;
+  SELECT CASE WHEN n > 1 THEN 'a' ELSE 'b' END;
+  CASE n WHEN 3 THEN SELECT 1; END CASE;
+  inner: BEGIN
+    LEAVE inner;
+  END inner;
+END;`,
+				"SELECT * FROM t3;",
+			},
+		},
+		{
+			name:     "labeled compound statement without trailing semicolon",
+			sql:      "SELECT 1; lbl: BEGIN SELECT 2; END lbl",
+			expected: []string{"SELECT 1;", "lbl: BEGIN SELECT 2; END lbl"},
+		},
+		{
+			name:     "begin as identifier",
+			sql:      "SELECT begin FROM t; SELECT 2;",
+			expected: []string{"SELECT begin FROM t;", "SELECT 2;"},
+		},
+		// 词法分析器无法识别时改为无语法拆分
+		{
+			name:     "dollar quoted function body",
+			sql:      "CREATE FUNCTION f(x INT) RETURNS INT LANGUAGE PYTHON AS $$\ny = x; return y\n$$;\nSELECT 2;",
+			expected: []string{"CREATE FUNCTION f(x INT) RETURNS INT LANGUAGE PYTHON AS $$\ny = x; return y\n$$;", "SELECT 2;"},
+		},
+		{
+			name:     "unclosed bracketed comment runs to the end",
+			sql:      "SELECT 1 /* oops;\nSELECT 2;",
+			expected: []string{"SELECT 1 /* oops;\nSELECT 2;"},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := analyzer.SplitSQL(makeLexer(tt.sql))
+			result := splitSQL(tt.sql)
 			if len(result) != len(tt.expected) {
 				t.Fatalf("Expected %d statements, got %d\nExpected: %v\nGot: %v", len(tt.expected), len(result), tt.expected, result)
 			}
@@ -0,0 +1,79 @@
+package starrocks
+
+import (
+	"strings"
+
+	"github.com/Edsuns/sql-parser/analyzer"
+	"github.com/Edsuns/sql-parser/internal/starrocks/parser"
+)
+
+// EnterBackupStatement 进入备份语句时调用，备份的表是读表，仓库是外部写依赖
+func (l *dependencyListener) EnterBackupStatement(ctx *parser.BackupStatementContext) {
+	l.curOpType = analyzer.StmtTypeBackup
+	l.isOnlyComment = false
+	if l.firstOpType == "" {
+		l.firstOpType = analyzer.StmtTypeBackup
+	}
+	external := l.snapshot(ctx.QualifiedName(), ctx.GetDbName())
+	external.Location = unquote(ctx.GetRepoName().GetText())
+	l.dependencies.ExternalWrite = append(l.dependencies.ExternalWrite, external)
+}
+
+// EnterRestoreStatement 进入从快照恢复的语句时调用，恢复的表是写表，仓库是外部读依赖
+func (l *dependencyListener) EnterRestoreStatement(ctx *parser.RestoreStatementContext) {
+	l.curOpType = analyzer.StmtTypeRestore
+	l.onWriteStmt()
+	external := l.snapshot(ctx.QualifiedName(), ctx.GetDbName())
+	if ctx.GetDbAlias() != nil {
+		// DATABASE db AS alias 将库中的表恢复到另一个库
+		l.defaultDatabase = unquote(ctx.GetDbAlias().GetText())
+	}
+	external.Location = unquote(ctx.GetRepoName().GetText())
+	l.dependencies.ExternalRead = append(l.dependencies.ExternalRead, external)
+}
+
+// snapshot 记录快照名称，快照名限定的库或 DATABASE 指定的库作为之后未限定库名的表所在的库
+func (l *dependencyListener) snapshot(name parser.IQualifiedNameContext, db parser.IIdentifierContext) *analyzer.DependencyExternal {
+	parts := strings.Split(name.GetText(), ".")
+	if db != nil {
+		l.defaultDatabase = unquote(db.GetText())
+	} else if len(parts) > 1 {
+		l.defaultDatabase = unquote(parts[len(parts)-2])
+	}
+	l.dependencies.Label = unquote(parts[len(parts)-1])
+	return &analyzer.DependencyExternal{
+		Type:       analyzer.ExternalTypeRepository,
+		Properties: map[string]string{"snapshot": l.dependencies.Label},
+	}
+}
+
+// EnterBackupRestoreTableDesc 进入备份或恢复的表时调用，恢复时 AS 指定的新表名是写表
+func (l *dependencyListener) EnterBackupRestoreTableDesc(ctx *parser.BackupRestoreTableDescContext) {
+	name := ctx.QualifiedName().GetText()
+	if ctx.Identifier() != nil && l.curOpType == analyzer.StmtTypeRestore {
+		name = ctx.Identifier().GetText()
+	}
+	l.addBackupRestoreTable(name)
+}
+
+// EnterBackupRestoreObjectDesc 进入备份或恢复的视图、物化视图时调用，函数不作为表
+func (l *dependencyListener) EnterBackupRestoreObjectDesc(ctx *parser.BackupRestoreObjectDescContext) {
+	if ctx.QualifiedName() == nil || ctx.FUNCTION() != nil || ctx.FUNCTIONS() != nil {
+		return
+	}
+	name := ctx.QualifiedName().GetText()
+	if ctx.Identifier() != nil && l.curOpType == analyzer.StmtTypeRestore {
+		name = ctx.Identifier().GetText()
+	}
+	l.addBackupRestoreTable(name)
+}
+
+// addBackupRestoreTable 备份时添加读表，恢复时添加写表
+func (l *dependencyListener) addBackupRestoreTable(name string) {
+	cluster, database, table := l.parseTableName(name)
+	if l.curOpType == analyzer.StmtTypeRestore {
+		l.addWriteTable(cluster, database, table)
+	} else {
+		l.addReadTable(cluster, database, table)
+	}
+}
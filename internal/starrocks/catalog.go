@@ -0,0 +1,97 @@
+package starrocks
+
+import (
+	"strings"
+
+	"github.com/Edsuns/sql-parser/analyzer"
+	"github.com/Edsuns/sql-parser/internal/starrocks/parser"
+)
+
+// session 脚本执行过程中的当前 catalog 和数据库，catalog 对应 Cluster，
+// USE 和 SET CATALOG 语句会切换它们，之后的语句中未限定的名称相对于它们解析
+type session struct {
+	cluster  string
+	database string
+	internal string // 内部 catalog default_catalog 对应的 Cluster，即请求的默认 Cluster
+}
+
+// internalCatalog StarRocks 内部 catalog 的名称
+const internalCatalog = "default_catalog"
+
+func newSession(defaultCluster, defaultDatabase string) *session {
+	return &session{cluster: defaultCluster, database: defaultDatabase, internal: defaultCluster}
+}
+
+// resolveCatalog 将 catalog 名称转换为 Cluster，内部 catalog 对应默认 Cluster
+func (s *session) resolveCatalog(name string) string {
+	if s != nil && strings.EqualFold(name, internalCatalog) {
+		return s.internal
+	}
+	return name
+}
+
+// sensitiveProperties 属性名包含这些词时视为认证信息，不对外暴露，不区分大小写，
+// 例如 aws.s3.secret_key、gcp.gcs.service_account_private_key、azure.blob.shared_key、azure.blob.sas_token
+var sensitiveProperties = []string{"password", "secret", "access_key", "private_key", "shared_key", "sas", "token", "credential"}
+
+// EnterSetCatalogStatement 进入 SET CATALOG 语句时调用
+func (l *dependencyListener) EnterSetCatalogStatement(ctx *parser.SetCatalogStatementContext) {
+	l.curOpType = analyzer.StmtTypeUseCatalog
+	l.isOnlyComment = false
+	l.firstOpType = analyzer.StmtTypeUseCatalog
+	l.switchCatalog(unquote(ctx.IdentifierOrString().GetText()))
+}
+
+// EnterCreateExternalCatalogStatement 进入创建外部数据目录语句时调用，记录目录的类型和属性
+func (l *dependencyListener) EnterCreateExternalCatalogStatement(ctx *parser.CreateExternalCatalogStatementContext) {
+	l.catalogStmt(analyzer.StmtTypeCreateCatalog, ctx.GetCatalogName())
+	properties := publicProperties(propertyMap(ctx.Properties().AllProperty()))
+	l.dependencies.ExternalCatalog.Type = strings.ToLower(properties["type"])
+	delete(properties, "type")
+	l.dependencies.ExternalCatalog.Properties = properties
+}
+
+// EnterAlterCatalogStatement 进入修改外部数据目录语句时调用，记录修改的属性
+func (l *dependencyListener) EnterAlterCatalogStatement(ctx *parser.AlterCatalogStatementContext) {
+	l.catalogStmt(analyzer.StmtTypeAlterCatalog, ctx.GetCatalogName())
+	l.dependencies.ExternalCatalog.Properties = publicProperties(propertyMap(ctx.ModifyPropertiesClause().PropertyList().AllProperty()))
+}
+
+// EnterDropExternalCatalogStatement 进入删除外部数据目录语句时调用
+func (l *dependencyListener) EnterDropExternalCatalogStatement(ctx *parser.DropExternalCatalogStatementContext) {
+	l.catalogStmt(analyzer.StmtTypeDropCatalog, ctx.GetCatalogName())
+}
+
+// catalogStmt 记录外部数据目录语句的类型和目录名
+func (l *dependencyListener) catalogStmt(stmtType analyzer.StmtType, name parser.IIdentifierOrStringContext) {
+	l.curOpType = stmtType
+	l.isOnlyComment = false
+	if l.firstOpType == "" {
+		l.firstOpType = stmtType
+	}
+	l.dependencies.ExternalCatalog = &analyzer.ExternalCatalog{Name: unquote(name.GetText())}
+}
+
+// switchCatalog 切换当前 catalog，之后的语句中未限定 catalog 的表属于该 catalog
+func (l *dependencyListener) switchCatalog(name string) {
+	l.dependencies.ExternalCatalog = &analyzer.ExternalCatalog{Name: name}
+	if l.session != nil {
+		l.session.cluster = l.session.resolveCatalog(name)
+	}
+}
+
+// publicProperties 去掉属性中的认证信息
+func publicProperties(properties map[string]string) map[string]string {
+	for key := range properties {
+		for _, word := range sensitiveProperties {
+			if strings.Contains(strings.ToLower(key), word) {
+				delete(properties, key)
+				break
+			}
+		}
+	}
+	if len(properties) == 0 {
+		return nil
+	}
+	return properties
+}
@@ -0,0 +1,79 @@
+package starrocks
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Edsuns/sql-parser/analyzer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStarRocksDDLExtractor(t *testing.T) {
+	sql := `CREATE TABLE ods.orders (
+    id BIGINT COMMENT '订单ID',
+    amount DECIMAL(10, 2),
+    dt DATE
+) DUPLICATE KEY(id)
+PARTITION BY RANGE(dt) (
+    PARTITION p20240101 VALUES LESS THAN ('2024-01-02')
+)
+DISTRIBUTED BY HASH(id);
+ALTER TABLE ods.orders ADD COLUMN status INT;
+ALTER TABLE ods.orders DROP COLUMN amount;
+ALTER TABLE ods.orders RENAME COLUMN status TO order_status;
+ALTER TABLE ods.orders ADD PARTITION p20240102 VALUES LESS THAN ('2024-01-03');
+ALTER TABLE ods.orders DROP PARTITION p20240101;
+CREATE VIEW ads.orders_v AS SELECT id FROM ods.orders;
+CREATE TABLE ods.orders_bak LIKE ods.orders;
+ALTER TABLE ods.orders_bak RENAME orders_old;
+DROP TABLE ods.tmp;
+ALTER TABLE ods.missing ADD COLUMN c INT;
+INSERT INTO ods.orders SELECT * FROM ods.orders_old;`
+
+	model := analyzer.NewSchemaModel()
+	err := model.Replay(NewDDLExtractor(), &analyzer.DependencyAnalyzeReq{
+		SQL:             sql,
+		DefaultCluster:  "sr",
+		DefaultDatabase: "ods",
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	orders, _ := model.LookupTable("sr", "ods", "orders")
+	if assert.NotNil(t, orders) {
+		assert.Equal(t, []*analyzer.CatalogColumn{
+			{Name: "id", Type: "BIGINT", Comment: "订单ID"},
+			{Name: "dt", Type: "DATE"},
+			{Name: "order_status", Type: "INT"},
+		}, orders.Columns)
+		assert.Equal(t, []string{"dt"}, orders.PartitionKeys)
+		assert.Equal(t, []string{"p20240102"}, orders.Partitions)
+	}
+
+	def, _ := model.GetViewDefinition("sr", "ads", "orders_v")
+	assert.Equal(t, "CREATE VIEW ads.orders_v AS SELECT id FROM ods.orders;", def)
+
+	exists, _ := model.TableExists("sr", "ods", "orders_bak")
+	assert.False(t, exists)
+	old, _ := model.LookupTable("sr", "ods", "orders_old")
+	if assert.NotNil(t, old) {
+		assert.Len(t, old.Columns, 3)
+	}
+
+	if assert.Len(t, model.Conflicts, 2) {
+		assert.Equal(t, analyzer.DDLOpDropTable, model.Conflicts[0].Op)
+		assert.Equal(t, "sr.ods.tmp", model.Conflicts[0].Table)
+		assert.Equal(t, analyzer.DDLOpAddColumn, model.Conflicts[1].Op)
+		assert.Equal(t, "table not found", model.Conflicts[1].Message)
+	}
+
+	data, err := json.Marshal(model)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"conflicts":[{"stmt":"DROP TABLE ods.tmp;"`)
+}
+
+func TestStarRocksDDLExtractor_SyntaxError(t *testing.T) {
+	_, err := NewDDLExtractor().ExtractDDL("CREATE TABLE t (id INT,,) DISTRIBUTED BY HASH(id)", "sr", "ods")
+	assert.Error(t, err)
+}
@@ -0,0 +1,307 @@
+package starrocks
+
+import (
+	"strings"
+
+	"github.com/Edsuns/sql-parser/analyzer"
+	"github.com/Edsuns/sql-parser/internal/starrocks/parser"
+)
+
+// ddlListener 提取DDL语句中的表结构变更
+type ddlListener struct {
+	*parser.BaseStarRocksListener
+
+	stmt            string
+	defaultCluster  string
+	defaultDatabase string
+	events          []*analyzer.DDLEvent
+	alterTable      *analyzer.DependencyTable // 当前ALTER TABLE的表
+	partitionEvent  *analyzer.DDLEvent        // 当前接收分区名的变更
+}
+
+// newDDLListener 创建新的DDL监听器实例
+func newDDLListener(stmt, defaultCluster, defaultDatabase string) *ddlListener {
+	return &ddlListener{
+		stmt:            stmt,
+		defaultCluster:  defaultCluster,
+		defaultDatabase: defaultDatabase,
+		events:          []*analyzer.DDLEvent{},
+	}
+}
+
+// EnterCreateTableStatement 进入创建表语句时调用
+func (l *ddlListener) EnterCreateTableStatement(ctx *parser.CreateTableStatementContext) {
+	e := l.addEvent(analyzer.DDLOpCreateTable, ctx.QualifiedName())
+	e.IfNotExists = ctx.EXISTS() != nil
+	for _, column := range ctx.AllColumnDesc() {
+		e.Columns = append(e.Columns, l.column(column))
+	}
+	l.partitionEvent = e
+}
+
+// EnterCreateTableAsSelectStatement 进入CTAS语句时调用，只能获取显式声明的列名
+func (l *ddlListener) EnterCreateTableAsSelectStatement(ctx *parser.CreateTableAsSelectStatementContext) {
+	e := l.addEvent(analyzer.DDLOpCreateTable, ctx.QualifiedName())
+	e.IfNotExists = ctx.EXISTS() != nil
+	for _, column := range ctx.AllIdentifier() {
+		e.Columns = append(e.Columns, &analyzer.CatalogColumn{Name: unquote(column.GetText())})
+	}
+	l.partitionEvent = e
+}
+
+// EnterCreateTableLikeStatement 进入CREATE TABLE LIKE语句时调用
+func (l *ddlListener) EnterCreateTableLikeStatement(ctx *parser.CreateTableLikeStatementContext) {
+	names := ctx.AllQualifiedName()
+	e := l.addEvent(analyzer.DDLOpCreateLike, names[0])
+	e.IfNotExists = ctx.EXISTS() != nil
+	if len(names) > 1 {
+		e.Source = l.table(names[1])
+	}
+}
+
+// EnterCreateViewStatement 进入创建视图语句时调用
+func (l *ddlListener) EnterCreateViewStatement(ctx *parser.CreateViewStatementContext) {
+	e := l.addEvent(analyzer.DDLOpCreateView, ctx.QualifiedName())
+	e.IfNotExists = ctx.EXISTS() != nil
+	e.OrReplace = ctx.REPLACE() != nil
+	e.ViewDefinition = l.stmt
+	for _, column := range ctx.AllColumnNameWithComment() {
+		c := &analyzer.CatalogColumn{Name: unquote(column.GetColumnName().GetText())}
+		if column.Comment() != nil {
+			c.Comment = unquote(column.Comment().String_().GetText())
+		}
+		e.Columns = append(e.Columns, c)
+	}
+}
+
+// EnterDropTableStatement 进入删除表语句时调用
+func (l *ddlListener) EnterDropTableStatement(ctx *parser.DropTableStatementContext) {
+	e := l.addEvent(analyzer.DDLOpDropTable, ctx.QualifiedName())
+	e.IfExists = ctx.EXISTS() != nil
+}
+
+// EnterDropViewStatement 进入删除视图语句时调用
+func (l *ddlListener) EnterDropViewStatement(ctx *parser.DropViewStatementContext) {
+	e := l.addEvent(analyzer.DDLOpDropView, ctx.QualifiedName())
+	e.IfExists = ctx.EXISTS() != nil
+}
+
+// EnterAlterTableStatement 进入修改表语句时调用，具体变更由各个子句处理
+func (l *ddlListener) EnterAlterTableStatement(ctx *parser.AlterTableStatementContext) {
+	l.alterTable = l.table(ctx.QualifiedName())
+}
+
+// ExitAlterTableStatement 离开修改表语句时调用
+func (l *ddlListener) ExitAlterTableStatement(ctx *parser.AlterTableStatementContext) {
+	l.alterTable = nil
+}
+
+// EnterAddColumnClause 进入添加列子句时调用
+func (l *ddlListener) EnterAddColumnClause(ctx *parser.AddColumnClauseContext) {
+	if e := l.addAlterEvent(analyzer.DDLOpAddColumn); e != nil {
+		e.Columns = append(e.Columns, l.column(ctx.ColumnDesc()))
+	}
+}
+
+// EnterAddColumnsClause 进入添加多列子句时调用
+func (l *ddlListener) EnterAddColumnsClause(ctx *parser.AddColumnsClauseContext) {
+	if e := l.addAlterEvent(analyzer.DDLOpAddColumn); e != nil {
+		for _, column := range ctx.AllColumnDesc() {
+			e.Columns = append(e.Columns, l.column(column))
+		}
+	}
+}
+
+// EnterDropColumnClause 进入删除列子句时调用
+func (l *ddlListener) EnterDropColumnClause(ctx *parser.DropColumnClauseContext) {
+	if e := l.addAlterEvent(analyzer.DDLOpDropColumn); e != nil {
+		e.Column = unquote(ctx.Identifier(0).GetText())
+	}
+}
+
+// EnterModifyColumnClause 进入修改列子句时调用
+func (l *ddlListener) EnterModifyColumnClause(ctx *parser.ModifyColumnClauseContext) {
+	if e := l.addAlterEvent(analyzer.DDLOpModifyColumn); e != nil {
+		e.Columns = append(e.Columns, l.column(ctx.ColumnDesc()))
+	}
+}
+
+// EnterColumnRenameClause 进入重命名列子句时调用
+func (l *ddlListener) EnterColumnRenameClause(ctx *parser.ColumnRenameClauseContext) {
+	if e := l.addAlterEvent(analyzer.DDLOpRenameColumn); e != nil {
+		e.Column = unquote(ctx.GetOldColumn().GetText())
+		e.NewColumn = unquote(ctx.GetNewColumn().GetText())
+	}
+}
+
+// EnterTableRenameClause 进入重命名表子句时调用，新表名与原表在同一个库
+func (l *ddlListener) EnterTableRenameClause(ctx *parser.TableRenameClauseContext) {
+	if e := l.addAlterEvent(analyzer.DDLOpRenameTable); e != nil {
+		e.NewTable = &analyzer.DependencyTable{
+			Cluster:  e.Table.Cluster,
+			Database: e.Table.Database,
+			Table:    unquote(ctx.Identifier().GetText()),
+		}
+	}
+}
+
+// EnterAddPartitionClause 进入添加分区子句时调用
+func (l *ddlListener) EnterAddPartitionClause(ctx *parser.AddPartitionClauseContext) {
+	// 临时分区不属于表的正式分区
+	if ctx.TEMPORARY() != nil {
+		return
+	}
+	l.partitionEvent = l.addAlterEvent(analyzer.DDLOpAddPartition)
+}
+
+// ExitAddPartitionClause 离开添加分区子句时调用
+func (l *ddlListener) ExitAddPartitionClause(ctx *parser.AddPartitionClauseContext) {
+	l.partitionEvent = nil
+}
+
+// EnterPartitionDesc 进入建表语句的 PARTITION BY 子句时调用，分区表达式只有一个列名时才作为分区键，
+// 例如 PARTITION BY date_trunc('day', dt) 没有分区键
+func (l *ddlListener) EnterPartitionDesc(ctx *parser.PartitionDescContext) {
+	if l.partitionEvent == nil || l.partitionEvent.Op != analyzer.DDLOpCreateTable {
+		return
+	}
+	e := l.partitionEvent
+	if ctx.IdentifierList() != nil {
+		for _, id := range ctx.IdentifierList().AllIdentifier() {
+			e.PartitionKeys = append(e.PartitionKeys, unquote(id.GetText()))
+		}
+	}
+	if expr := ctx.PrimaryExpression(); expr != nil && expr.GetStart() == expr.GetStop() {
+		e.PartitionKeys = append(e.PartitionKeys, unquote(expr.GetText()))
+	}
+	for _, expr := range ctx.AllPartitionExpr() {
+		if expr.Identifier() != nil {
+			e.PartitionKeys = append(e.PartitionKeys, unquote(expr.Identifier().GetText()))
+		}
+	}
+}
+
+// EnterDropPartitionClause 进入删除分区子句时调用
+func (l *ddlListener) EnterDropPartitionClause(ctx *parser.DropPartitionClauseContext) {
+	if ctx.TEMPORARY() != nil {
+		return
+	}
+	var partitions []string
+	if ctx.Identifier() != nil {
+		partitions = append(partitions, unquote(ctx.Identifier().GetText()))
+	}
+	if ctx.IdentifierList() != nil {
+		for _, id := range ctx.IdentifierList().AllIdentifier() {
+			partitions = append(partitions, unquote(id.GetText()))
+		}
+	}
+	// 按范围或条件删除分区时无法确定分区名
+	if len(partitions) == 0 {
+		return
+	}
+	if e := l.addAlterEvent(analyzer.DDLOpDropPartition); e != nil {
+		e.IfExists = ctx.EXISTS() != nil
+		e.Partitions = partitions
+	}
+}
+
+// EnterSingleRangePartition 进入范围分区定义时调用
+func (l *ddlListener) EnterSingleRangePartition(ctx *parser.SingleRangePartitionContext) {
+	l.addPartition(ctx.Identifier(), ctx.EXISTS() != nil)
+}
+
+// EnterSingleItemListPartitionDesc 进入单列LIST分区定义时调用
+func (l *ddlListener) EnterSingleItemListPartitionDesc(ctx *parser.SingleItemListPartitionDescContext) {
+	l.addPartition(ctx.Identifier(), ctx.EXISTS() != nil)
+}
+
+// EnterMultiItemListPartitionDesc 进入多列LIST分区定义时调用
+func (l *ddlListener) EnterMultiItemListPartitionDesc(ctx *parser.MultiItemListPartitionDescContext) {
+	l.addPartition(ctx.Identifier(), ctx.EXISTS() != nil)
+}
+
+// addPartition 记录分区名
+func (l *ddlListener) addPartition(id parser.IIdentifierContext, ifNotExists bool) {
+	if l.partitionEvent == nil || id == nil {
+		return
+	}
+	l.partitionEvent.Partitions = append(l.partitionEvent.Partitions, unquote(id.GetText()))
+	if ifNotExists {
+		l.partitionEvent.IfNotExists = true
+	}
+}
+
+// addEvent 添加一个表结构变更
+func (l *ddlListener) addEvent(op analyzer.DDLOpType, name parser.IQualifiedNameContext) *analyzer.DDLEvent {
+	e := &analyzer.DDLEvent{
+		Op:    op,
+		Stmt:  l.stmt,
+		Table: l.table(name),
+	}
+	l.events = append(l.events, e)
+	return e
+}
+
+// addAlterEvent 添加一个ALTER TABLE的子句变更
+func (l *ddlListener) addAlterEvent(op analyzer.DDLOpType) *analyzer.DDLEvent {
+	if l.alterTable == nil {
+		return nil
+	}
+	e := &analyzer.DDLEvent{
+		Op:    op,
+		Stmt:  l.stmt,
+		Table: l.alterTable,
+	}
+	l.events = append(l.events, e)
+	return e
+}
+
+// table 解析表名
+func (l *ddlListener) table(name parser.IQualifiedNameContext) *analyzer.DependencyTable {
+	cluster, database, table := "", "", ""
+	parts := strings.Split(name.GetText(), ".")
+	switch len(parts) {
+	case 1:
+		table = parts[0]
+	case 2:
+		database, table = parts[0], parts[1]
+	default:
+		cluster, database, table = parts[0], parts[1], parts[2]
+	}
+	if cluster == "" {
+		cluster = l.defaultCluster
+	}
+	if database == "" {
+		database = l.defaultDatabase
+	}
+	return &analyzer.DependencyTable{
+		Cluster:  unquote(cluster),
+		Database: unquote(database),
+		Table:    unquote(table),
+	}
+}
+
+// column 解析列定义
+func (l *ddlListener) column(ctx parser.IColumnDescContext) *analyzer.CatalogColumn {
+	c := &analyzer.CatalogColumn{Name: unquote(ctx.Identifier().GetText())}
+	if ctx.Type_() != nil {
+		c.Type = analyzer.OriginalText(ctx.Type_())
+	}
+	if ctx.Comment() != nil {
+		c.Comment = unquote(ctx.Comment().String_().GetText())
+	}
+	return c
+}
+
+// unquote 去掉标识符的反引号或字符串的引号
+func unquote(s string) string {
+	if len(s) >= 2 {
+		switch s[0] {
+		case '`', '\'', '"':
+			if s[len(s)-1] == s[0] {
+				return s[1 : len(s)-1]
+			}
+		}
+	}
+	return s
+}
@@ -18,28 +18,45 @@ func NewDependencyAnalyzer() analyzer.DependencyAnalyzer {
 }
 
 func (a *dependencyAnalyzer) Analyze(req *analyzer.DependencyAnalyzeReq) ([]*analyzer.DependencyResult, error) {
-	// 使用SplitSQL函数拆分SQL语句
-	statements := analyzer.SplitSQL(makeLexer(req.SQL))
+	// 使用SplitSQL函数拆分SQL语句，词法分析出错时无语法拆分
+	spans := analyzer.LocateSpans(req.SQL, analyzer.SplitSQLOrText(req.SQL, makeLexer(req.SQL), dialect))
 	var result []*analyzer.DependencyResult
-	for _, stmt := range statements {
-		ddl, err := a.ParseOne(stmt, req.DefaultCluster, req.DefaultDatabase)
+	s := newSession(req.DefaultCluster, req.DefaultDatabase)
+	for _, span := range spans {
+		// 过滤掉只有注释的语句
+		if span.Text == "" {
+			continue
+		}
+		ddl, err := a.parse(span.Raw, s, req.Catalog)
 		if err != nil {
 			return nil, err
 		}
 		if ddl != nil {
+			ddl.Span = span
 			result = append(result, ddl)
 		}
 	}
+	if err := analyzer.PostProcess(a, result, req); err != nil {
+		return nil, err
+	}
 	return result, nil
 }
 
 // ParseOne 解析SQL语句并返回Dependencies列表
 func (a *dependencyAnalyzer) ParseOne(sql, defaultCluster, defaultDatabase string) (*analyzer.DependencyResult, error) {
+	return a.parse(sql, newSession(defaultCluster, defaultDatabase), nil)
+}
+
+// parse 解析单句SQL，未限定的名称相对于 s 中的当前 catalog 和数据库解析，USE 语句会修改 s，
+// 提供 catalog 时根据其中的分区键得到读表读取的分区
+func (a *dependencyAnalyzer) parse(sql string, s *session, catalog analyzer.Catalog) (*analyzer.DependencyResult, error) {
 	// 创建语法分析器
 	p := makeParser(makeLexer(sql))
 
 	// 创建自定义监听器
-	listener := newDependencyListener(defaultCluster, defaultDatabase)
+	listener := newDependencyListener(s.cluster, s.database)
+	listener.catalog = catalog
+	listener.session = s
 
 	// 创建自定义错误监听器
 	errListener := newSyntaxErrorListener(listener)
@@ -0,0 +1,61 @@
+package starrocks
+
+import (
+	"strings"
+
+	"github.com/Edsuns/sql-parser/analyzer"
+	"github.com/Edsuns/sql-parser/internal/starrocks/parser"
+)
+
+// EnterCreateDictionaryStatement 进入创建字典语句时调用，字典是写表，USING 的源表是读表
+func (l *dependencyListener) EnterCreateDictionaryStatement(ctx *parser.CreateDictionaryStatementContext) {
+	l.dictionaryStmt(analyzer.StmtTypeCreateDictionary, ctx.DictionaryName().QualifiedName())
+	cluster, database, table := l.parseTableName(ctx.QualifiedName().GetText())
+	l.addReadTable(cluster, database, table)
+}
+
+// EnterRefreshDictionaryStatement 进入刷新字典语句时调用
+func (l *dependencyListener) EnterRefreshDictionaryStatement(ctx *parser.RefreshDictionaryStatementContext) {
+	l.dictionaryStmt(analyzer.StmtTypeRefreshDictionary, ctx.QualifiedName())
+}
+
+// EnterCancelRefreshDictionaryStatement 进入取消刷新字典语句时调用
+func (l *dependencyListener) EnterCancelRefreshDictionaryStatement(ctx *parser.CancelRefreshDictionaryStatementContext) {
+	l.dictionaryStmt(analyzer.StmtTypeCancelRefresh, ctx.QualifiedName())
+}
+
+// EnterDropDictionaryStatement 进入删除字典语句时调用
+func (l *dependencyListener) EnterDropDictionaryStatement(ctx *parser.DropDictionaryStatementContext) {
+	l.dictionaryStmt(analyzer.StmtTypeDropDictionary, ctx.QualifiedName())
+}
+
+// EnterDictionaryGetExpr 进入 DICTIONARY_GET(dict, key) 时调用，读取的字典是读表
+func (l *dependencyListener) EnterDictionaryGetExpr(ctx *parser.DictionaryGetExprContext) {
+	if args := ctx.ExpressionList().AllExpression(); len(args) > 0 {
+		l.addDictionaryRead(args[0].GetText())
+	}
+}
+
+// dictionaryStmt 记录字典语句的类型，字典作为写表
+func (l *dependencyListener) dictionaryStmt(stmtType analyzer.StmtType, name parser.IQualifiedNameContext) {
+	l.curOpType = stmtType
+	l.onWriteStmt()
+	l.addWriteTable(l.parseTableName(name.GetText()))
+	l.lastTable.Kind = analyzer.TableKindDictionary
+}
+
+// addDictionaryRead 添加读取的字典，例如 dict_mapping('db.dict', key) 的第一个参数
+func (l *dependencyListener) addDictionaryRead(arg string) {
+	name := unquote(arg)
+	if name == arg || name == "" {
+		// 字典名不是字符串字面量时无法确定
+		return
+	}
+	l.addReadTable(l.parseTableName(name))
+	l.lastTable.Kind = analyzer.TableKindDictionary
+}
+
+// isDictionaryFunction 判断是否为以第一个参数指定字典的函数
+func isDictionaryFunction(name string) bool {
+	return strings.EqualFold(name, "dict_mapping")
+}
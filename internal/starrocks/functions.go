@@ -0,0 +1,131 @@
+package starrocks
+
+import (
+	"strings"
+
+	"github.com/Edsuns/sql-parser/analyzer"
+	"github.com/Edsuns/sql-parser/internal/starrocks/parser"
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// builtinFunctions StarRocks 内置函数，用于区分内置函数和用户自定义函数
+var builtinFunctions = analyzer.NewNameSet(`
+abs acos add aes_decrypt aes_encrypt any_match any_value append_trailing_char_if_absent approx_count_distinct
+approx_top_k array_agg array_append array_avg array_concat array_contains array_contains_all
+array_cum_sum array_difference array_distinct array_filter array_generate array_intersect array_join
+array_length array_map array_max array_min array_position array_remove array_slice array_sort
+array_sortby array_sum array_to_bitmap arrays_overlap ascii asin atan atan2 avg bar base64 bin
+bitand bitmap_agg bitmap_and bitmap_andnot bitmap_contains bitmap_count bitmap_empty bitmap_from_string
+bitmap_has_any bitmap_hash bitmap_intersect bitmap_max bitmap_min bitmap_or bitmap_remove bitmap_to_array
+bitmap_to_base64 bitmap_to_string bitmap_union bitmap_union_count bitmap_union_int bitmap_xor bitnot
+bitor bitshiftleft bitshiftright bitxor bool_or cardinality cast cbrt ceil ceiling char char_length
+character_length coalesce concat concat_ws conv convert_tz corr cos cosh cosine_similarity cot count
+count_if covar_pop covar_samp crc32 cume_dist curdate current_date current_role current_time
+current_timestamp current_user curtime database date date_add date_diff date_format date_slice date_sub
+date_trunc datediff day dayname dayofmonth dayofweek dayofweek_iso dayofyear days_add days_diff days_sub
+decode_sort_key degrees dict_mapping dense_rank divide e element_at ends_with exp explode floor format_bytes
+from_base64 from_days from_unixtime get_json_bool get_json_double get_json_int get_json_object
+get_json_string greatest group_concat grouping grouping_id hex hll_cardinality hll_empty hll_hash
+hll_raw_agg hll_union hll_union_agg hour hours_add hours_diff hours_sub if ifnull instr intersect_count
+json_array json_each json_exists json_keys json_length json_object json_query json_string lag last_day
+last_query_id last_value lcase lead least left length like ln locate log log10 log2 lower lpad ltrim
+makedate map map_apply map_concat map_filter map_from_arrays map_keys map_size map_values max max_by md5
+md5sum md5sum_numeric min min_by minute minutes_add minutes_diff minutes_sub mod money_format month
+monthname months_add months_diff months_sub multi_distinct_count multi_distinct_sum murmur_hash3_32
+named_struct negative now ntile null_or_empty nullif nvl parse_json parse_url percent_rank percentile_approx
+percentile_cont percentile_disc percentile_empty percentile_hash percentile_union pi pmod positive pow
+power quarter quarters_add quarters_sub radians rand random rank regexp regexp_extract
+regexp_extract_all regexp_replace repeat replace retention reverse right rlike round row row_number rpad
+rtrim sec_to_time second seconds_add seconds_diff seconds_sub sha2 sign sin sinh sleep space split
+split_part sqrt square st_astext st_circle st_contains st_distance_sphere st_geometryfromtext
+st_linefromtext st_point st_polygon st_x st_y starts_with std stddev stddev_pop stddev_samp str2date
+str_to_date str_to_map strleft strright struct sub_bitmap substr substring substring_index sum tan tanh
+time_slice time_to_sec timediff timestamp timestampadd timestampdiff to_base64 to_bitmap to_date to_days
+to_iso8601 to_json to_tera_date to_tera_timestamp translate trim truncate ucase unhex unix_timestamp
+unnest upper url_decode url_encode url_extract_host url_extract_parameter user utc_time utc_timestamp
+uuid uuid_numeric var_pop var_samp variance version week week_iso weekofyear weeks_add weeks_diff
+weeks_sub window_funnel xx_hash3_64 year years_add years_diff years_sub
+`)
+
+// EnterSimpleFunctionCall 进入普通函数调用时调用，记录调用的函数
+func (l *dependencyListener) EnterSimpleFunctionCall(ctx *parser.SimpleFunctionCallContext) {
+	name := ctx.QualifiedName().GetText()
+	l.addFunctionRead(strings.Split(name, "."))
+	// dict_mapping 读取第一个参数指定的字典
+	if args := ctx.AllExpression(); isDictionaryFunction(name) && len(args) > 0 {
+		l.addDictionaryRead(args[0].GetText())
+	}
+}
+
+// EnterAggregationFunction 进入 COUNT、SUM 等特殊语法的聚合函数时调用
+func (l *dependencyListener) EnterAggregationFunction(ctx *parser.AggregationFunctionContext) {
+	l.addFunctionRead([]string{ctx.GetStart().GetText()})
+}
+
+// EnterWindowFunction 进入 ROW_NUMBER、LAG 等窗口函数时调用
+func (l *dependencyListener) EnterWindowFunction(ctx *parser.WindowFunctionContext) {
+	l.addFunctionRead([]string{ctx.GetName().GetText()})
+}
+
+// EnterCreateFunctionStatement 进入创建函数语句时调用，PROPERTIES 中的 file 作为外部读依赖
+func (l *dependencyListener) EnterCreateFunctionStatement(ctx *parser.CreateFunctionStatementContext) {
+	f := l.functionWrite(analyzer.StmtTypeCreateFunction, ctx.QualifiedName())
+	properties := make(map[string]string)
+	if ctx.Properties() != nil {
+		for _, property := range ctx.Properties().AllProperty() {
+			properties[strings.ToLower(unquote(property.GetKey().GetText()))] = unquote(property.GetValue().GetText())
+		}
+	}
+	if ctx.InlineProperties() != nil {
+		for _, property := range ctx.InlineProperties().AllInlineProperty() {
+			properties[strings.ToLower(unquote(property.GetKey().GetText()))] = unquote(property.GetValue().GetText())
+		}
+	}
+	f.ClassName = properties["symbol"]
+	if file := properties["file"]; file != "" {
+		external := &analyzer.DependencyExternal{Type: analyzer.ExternalTypeFile, Location: file}
+		if strings.EqualFold(properties["type"], "StarrocksJar") {
+			external.Type = analyzer.ExternalTypeJar
+		}
+		f.Resources = append(f.Resources, external)
+		l.dependencies.ExternalRead = append(l.dependencies.ExternalRead, external)
+	}
+}
+
+// EnterDropFunctionStatement 进入删除函数语句时调用
+func (l *dependencyListener) EnterDropFunctionStatement(ctx *parser.DropFunctionStatementContext) {
+	l.functionWrite(analyzer.StmtTypeDropFunction, ctx.QualifiedName())
+}
+
+// functionWrite 记录创建或删除的函数
+func (l *dependencyListener) functionWrite(stmtType analyzer.StmtType, name parser.IQualifiedNameContext) *analyzer.DependencyFunction {
+	l.curOpType = stmtType
+	l.isOnlyComment = false
+	if l.firstOpType == "" {
+		l.firstOpType = stmtType
+	}
+	parts := strings.Split(name.GetText(), ".")
+	f := &analyzer.DependencyFunction{Name: unquote(parts[len(parts)-1])}
+	if len(parts) > 1 {
+		f.Database = unquote(parts[len(parts)-2])
+	}
+	l.dependencies.FunctionWrite = append(l.dependencies.FunctionWrite, f)
+	return f
+}
+
+// addFunctionRead 记录调用的函数
+func (l *dependencyListener) addFunctionRead(parts []string) {
+	for i := range parts {
+		parts[i] = unquote(parts[i])
+	}
+	l.dependencies.FunctionRead = analyzer.AppendFunction(l.dependencies.FunctionRead, analyzer.NewFunction(parts, builtinFunctions))
+}
+
+// isFunctionName 判断限定名是否为函数名，函数名不作为表
+func isFunctionName(ctx antlr.Tree) bool {
+	switch ctx.GetParent().(type) {
+	case *parser.SimpleFunctionCallContext, *parser.CreateFunctionStatementContext, *parser.DropFunctionStatementContext:
+		return true
+	}
+	return false
+}
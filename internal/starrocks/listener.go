@@ -22,6 +22,10 @@ type dependencyListener struct {
 	isOnlyComment   bool
 	isWriteOp       bool
 	cteNames        map[string]bool
+	lastTable       *analyzer.DependencyTable // 最近添加的表，之后的分区限定属于该表
+	relations       relationTables            // 表关系对应的读表，用于将WHERE条件关联到读表
+	catalog         analyzer.Catalog          // 提供时根据其中的分区键得到读表读取的分区
+	session         *session                  // 脚本的当前 catalog 和数据库，USE 语句会修改它
 }
 
 // newDependencyListener 创建新的监听器实例
@@ -38,6 +42,7 @@ func newDependencyListener(defaultCluster, defaultDatabase string) *dependencyLi
 		isOnlyComment:   true,
 		isWriteOp:       false,
 		cteNames:        make(map[string]bool),
+		relations:       make(relationTables),
 	}
 }
 
@@ -52,6 +57,12 @@ func (l *dependencyListener) EnterCreateTableStatement(ctx *parser.CreateTableSt
 	l.onWriteStmt()
 }
 
+// EnterCreateTableAsSelectStatement 进入CTAS语句时调用，创建的表是写表，查询中的表是读表
+func (l *dependencyListener) EnterCreateTableAsSelectStatement(ctx *parser.CreateTableAsSelectStatementContext) {
+	l.curOpType = analyzer.StmtTypeCreateTable
+	l.onWriteStmt()
+}
+
 // EnterCreateViewStatement 进入创建视图语句时调用
 func (l *dependencyListener) EnterCreateViewStatement(ctx *parser.CreateViewStatementContext) {
 	l.curOpType = analyzer.StmtTypeCreateView
@@ -70,10 +81,20 @@ func (l *dependencyListener) EnterDropTableStatement(ctx *parser.DropTableStatem
 	l.onWriteStmt()
 }
 
+// EnterTruncateTableStatement 进入清空表语句时调用
+func (l *dependencyListener) EnterTruncateTableStatement(ctx *parser.TruncateTableStatementContext) {
+	l.curOpType = analyzer.StmtTypeTruncate
+	l.onWriteStmt()
+}
+
 // EnterInsertStatement 进入插入语句时调用
 func (l *dependencyListener) EnterInsertStatement(ctx *parser.InsertStatementContext) {
 	l.curOpType = analyzer.StmtTypeInsert
 	l.onWriteStmt()
+	// INSERT INTO FILES() 将数据导出到文件
+	if ctx.FILES() != nil {
+		l.dependencies.ExternalWrite = append(l.dependencies.ExternalWrite, filesExternal(ctx.PropertyList()))
+	}
 }
 
 // EnterUpdateStatement 进入更新语句时调用
@@ -97,27 +118,37 @@ func (l *dependencyListener) EnterQueryStatement(ctx *parser.QueryStatementConte
 	}
 }
 
-// EnterUseDatabaseStatement 进入USE DATABASE语句时调用
+// EnterUseDatabaseStatement 进入USE DATABASE语句时调用，USE catalog.db 同时切换 catalog
 func (l *dependencyListener) EnterUseDatabaseStatement(ctx *parser.UseDatabaseStatementContext) {
 	l.curOpType = analyzer.StmtTypeUseDatabase
 	l.isOnlyComment = false
 	// 设置为firstOpType，但不进行读写表操作
 	l.firstOpType = analyzer.StmtTypeUseDatabase
+	parts := strings.Split(ctx.QualifiedName().GetText(), ".")
+	if len(parts) > 1 {
+		l.switchCatalog(unquote(parts[0]))
+	}
+	if l.session != nil {
+		l.session.database = unquote(parts[len(parts)-1])
+	}
 }
 
-// EnterUseCatalogStatement 进入USE CATALOG语句时调用
+// EnterUseCatalogStatement 进入 USE 'CATALOG name' 语句时调用
 func (l *dependencyListener) EnterUseCatalogStatement(ctx *parser.UseCatalogStatementContext) {
 	l.curOpType = analyzer.StmtTypeUseCatalog
 	l.isOnlyComment = false
 	// 设置为firstOpType，但不进行读写表操作
 	l.firstOpType = analyzer.StmtTypeUseCatalog
+	if fields := strings.Fields(unquote(ctx.String_().GetText())); len(fields) == 2 && strings.EqualFold(fields[0], "CATALOG") {
+		l.switchCatalog(unquote(fields[1]))
+	}
 }
 
 // EnterQualifiedName 进入表名节点时调用
 func (l *dependencyListener) EnterQualifiedName(ctx *parser.QualifiedNameContext) {
 	if ctx != nil {
-		// USE语句不进行读写表操作
-		if l.curOpType == analyzer.StmtTypeUseDatabase || l.curOpType == analyzer.StmtTypeUseCatalog {
+		// USE语句不进行读写表操作，函数名、作业名不是表
+		if l.curOpType == analyzer.StmtTypeUseDatabase || l.curOpType == analyzer.StmtTypeUseCatalog || isFunctionName(ctx) || isJobName(ctx) {
 			return
 		}
 		// 直接获取表名文本
@@ -175,9 +206,15 @@ func (l *dependencyListener) isWriteOperation() bool {
 		l.curOpType == analyzer.StmtTypeCreateView ||
 		l.curOpType == analyzer.StmtTypeAlterTable ||
 		l.curOpType == analyzer.StmtTypeDropTable ||
+		l.curOpType == analyzer.StmtTypeTruncate ||
 		l.curOpType == analyzer.StmtTypeInsert ||
 		l.curOpType == analyzer.StmtTypeUpdate ||
-		l.curOpType == analyzer.StmtTypeDelete
+		l.curOpType == analyzer.StmtTypeDelete ||
+		l.curOpType == analyzer.StmtTypeCreateMaterializedView ||
+		l.curOpType == analyzer.StmtTypeAlterMaterializedView ||
+		l.curOpType == analyzer.StmtTypeRefreshMaterializedView ||
+		l.curOpType == analyzer.StmtTypeCancelRefresh ||
+		l.curOpType == analyzer.StmtTypeDropMaterializedView
 }
 
 // parseTableName 解析表名，支持 cluster.db.table 格式
@@ -193,8 +230,8 @@ func (l *dependencyListener) parseTableName(tableName string) (cluster, database
 		database = parts[0]
 		table = parts[1]
 	case 3:
-		// 集群名、数据库名和表名
-		cluster = parts[0]
+		// catalog名、数据库名和表名，catalog 对应集群
+		cluster = l.session.resolveCatalog(parts[0])
 		database = parts[1]
 		table = parts[2]
 	}
@@ -212,20 +249,22 @@ func (l *dependencyListener) parseTableName(tableName string) (cluster, database
 
 // addReadTable 添加读表信息
 func (l *dependencyListener) addReadTable(cluster, database, table string) {
-	l.dependencies.Read = append(l.dependencies.Read, &analyzer.DependencyTable{
+	l.lastTable = &analyzer.DependencyTable{
 		Cluster:  cluster,
 		Database: database,
 		Table:    table,
-	})
+	}
+	l.dependencies.Read = append(l.dependencies.Read, l.lastTable)
 }
 
 // addWriteTable 添加写表信息
 func (l *dependencyListener) addWriteTable(cluster, database, table string) {
-	l.dependencies.Write = append(l.dependencies.Write, &analyzer.DependencyTable{
+	l.lastTable = &analyzer.DependencyTable{
 		Cluster:  cluster,
 		Database: database,
 		Table:    table,
-	})
+	}
+	l.dependencies.Write = append(l.dependencies.Write, l.lastTable)
 }
 
 // onWriteStmt 处理写操作语句
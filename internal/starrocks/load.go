@@ -0,0 +1,164 @@
+package starrocks
+
+import (
+	"strings"
+
+	"github.com/Edsuns/sql-parser/analyzer"
+	"github.com/Edsuns/sql-parser/internal/starrocks/parser"
+)
+
+// EnterLoadStatement 进入 Broker Load 等导入语句时调用，导入的表在 EnterDataDesc 中添加
+func (l *dependencyListener) EnterLoadStatement(ctx *parser.LoadStatementContext) {
+	l.curOpType = analyzer.StmtTypeLoad
+	l.onWriteStmt()
+	l.dependencies.Label = unquote(ctx.GetLabel().GetLabel().GetText())
+}
+
+// EnterDataDesc 进入导入的数据描述时调用，源文件或源表是读依赖，目标表是写表，
+// 表名不能限定库名，属于标签所在的库
+func (l *dependencyListener) EnterDataDesc(ctx *parser.DataDescContext) {
+	load, _ := ctx.GetParent().GetParent().(*parser.LoadStatementContext)
+	database := l.defaultDatabase
+	if load != nil && load.GetLabel().GetDb() != nil {
+		database = unquote(load.GetLabel().GetDb().GetText())
+	}
+	if ctx.GetSrcTableName() != nil {
+		l.addReadTable(l.defaultCluster, database, unquote(ctx.GetSrcTableName().GetText()))
+	}
+	if ctx.GetSrcFiles() != nil {
+		format := ""
+		if f := ctx.GetFormat(); f != nil && f.Identifier() != nil {
+			format = strings.ToLower(unquote(f.Identifier().GetText()))
+		} else if f != nil {
+			format = strings.ToLower(unquote(f.String_().GetText()))
+		}
+		for _, file := range ctx.GetSrcFiles().AllString_() {
+			l.dependencies.ExternalRead = append(l.dependencies.ExternalRead, &analyzer.DependencyExternal{
+				Type:       analyzer.ExternalTypePath,
+				Format:     format,
+				Location:   unquote(file.GetText()),
+				Properties: loadProperties(load),
+			})
+		}
+	}
+	l.addWriteTable(l.defaultCluster, database, unquote(ctx.GetDstTableName().GetText()))
+}
+
+// loadProperties 导入作业使用的 Broker 或资源名称
+func loadProperties(ctx *parser.LoadStatementContext) map[string]string {
+	switch {
+	case ctx == nil:
+	case ctx.GetBroker() != nil && ctx.GetBroker().GetName() != nil:
+		return map[string]string{"broker": unquote(ctx.GetBroker().GetName().GetText())}
+	case ctx.GetResource() != nil:
+		return map[string]string{"resource": unquote(ctx.GetResource().GetName().GetText())}
+	}
+	return nil
+}
+
+// EnterCreateRoutineLoadStatement 进入创建 Routine Load 语句时调用，消息队列的主题是外部读依赖，
+// ON 指定的表是写表，未限定库名时属于作业所在的库
+func (l *dependencyListener) EnterCreateRoutineLoadStatement(ctx *parser.CreateRoutineLoadStatementContext) {
+	l.curOpType = analyzer.StmtTypeRoutineLoad
+	l.onWriteStmt()
+	l.dependencies.Label = unquote(ctx.GetName().GetText())
+	cluster, database, table := l.parseTableName(ctx.GetTable().GetText())
+	if ctx.GetDb() != nil && !strings.Contains(ctx.GetTable().GetText(), ".") {
+		database = ctx.GetDb().GetText()
+	}
+	l.addWriteTable(cluster, unquote(database), unquote(table))
+
+	// 数据源属性以数据源类型为前缀，例如 kafka_topic、kafka_broker_list
+	source := strings.ToLower(ctx.GetSource().GetText())
+	external := &analyzer.DependencyExternal{Type: analyzer.ExternalType(strings.ToUpper(source))}
+	if ctx.DataSourceProperties() != nil {
+		for key, value := range propertyMap(ctx.DataSourceProperties().PropertyList().AllProperty()) {
+			name, ok := strings.CutPrefix(key, source+"_")
+			switch {
+			case !ok:
+				// 不带前缀的属性为客户端配置，可能包含认证信息
+			case name == "topic":
+				external.Location = value
+			default:
+				if external.Properties == nil {
+					external.Properties = make(map[string]string)
+				}
+				external.Properties[name] = value
+			}
+		}
+	}
+	l.dependencies.ExternalRead = append(l.dependencies.ExternalRead, external)
+}
+
+// EnterCreatePipeStatement 进入创建管道语句时调用，读写由嵌入的 INSERT 语句决定
+func (l *dependencyListener) EnterCreatePipeStatement(ctx *parser.CreatePipeStatementContext) {
+	l.curOpType = analyzer.StmtTypeCreatePipe
+	l.onWriteStmt()
+	l.dependencies.Label = unquote(ctx.QualifiedName().GetText())
+}
+
+// EnterExportStatement 进入导出语句时调用，导出的表是读表，目标路径是外部写依赖
+func (l *dependencyListener) EnterExportStatement(ctx *parser.ExportStatementContext) {
+	l.curOpType = analyzer.StmtTypeExport
+	l.isOnlyComment = false
+	if l.firstOpType == "" {
+		l.firstOpType = analyzer.StmtTypeExport
+	}
+	external := &analyzer.DependencyExternal{
+		Type:     analyzer.ExternalTypePath,
+		Location: unquote(ctx.String_().GetText()),
+	}
+	if broker := ctx.BrokerDesc(); broker != nil && broker.GetName() != nil {
+		external.Properties = map[string]string{"broker": unquote(broker.GetName().GetText())}
+	}
+	if ctx.Properties() != nil {
+		l.dependencies.Label = propertyMap(ctx.Properties().AllProperty())["label"]
+	}
+	l.dependencies.ExternalWrite = append(l.dependencies.ExternalWrite, external)
+}
+
+// EnterInsertLabelOrColumnAliases 进入 INSERT 的 WITH LABEL 子句时调用
+func (l *dependencyListener) EnterInsertLabelOrColumnAliases(ctx *parser.InsertLabelOrColumnAliasesContext) {
+	if ctx.GetLabel() != nil {
+		l.dependencies.Label = unquote(ctx.GetLabel().GetText())
+	}
+}
+
+// EnterFileTableFunction 进入 FILES() 表函数时调用，读取的文件是外部读依赖
+func (l *dependencyListener) EnterFileTableFunction(ctx *parser.FileTableFunctionContext) {
+	l.dependencies.ExternalRead = append(l.dependencies.ExternalRead, filesExternal(ctx.PropertyList()))
+}
+
+// filesExternal 根据 FILES() 的 path 和 format 属性构建外部依赖，INSERT INTO FILES() 导出时同样适用
+func filesExternal(ctx parser.IPropertyListContext) *analyzer.DependencyExternal {
+	properties := propertyMap(ctx.AllProperty())
+	return &analyzer.DependencyExternal{
+		Type:     analyzer.ExternalTypePath,
+		Format:   strings.ToLower(properties["format"]),
+		Location: properties["path"],
+	}
+}
+
+// propertyMap 将属性列表转换为键为小写的map
+func propertyMap(properties []parser.IPropertyContext) map[string]string {
+	m := make(map[string]string, len(properties))
+	for _, property := range properties {
+		m[strings.ToLower(unquote(property.GetKey().GetText()))] = unquote(property.GetValue().GetText())
+	}
+	return m
+}
+
+// isJobName 判断限定名是否为作业、管道、任务、快照的名称或其所在的库，或者是导入的列名、字典的列名，
+// 这些名称不作为表；备份恢复的表和字典在各自的语句中单独添加
+func isJobName(ctx *parser.QualifiedNameContext) bool {
+	switch ctx.GetParent().(type) {
+	case *parser.CreateRoutineLoadStatementContext, *parser.CreatePipeStatementContext, *parser.ColumnPropertiesContext,
+		*parser.SubmitTaskStatementContext, *parser.DropTaskStatementContext,
+		*parser.BackupStatementContext, *parser.RestoreStatementContext,
+		*parser.BackupRestoreTableDescContext, *parser.BackupRestoreObjectDescContext,
+		*parser.DictionaryNameContext, *parser.DictionaryColumnDescContext, *parser.CreateDictionaryStatementContext,
+		*parser.RefreshDictionaryStatementContext, *parser.CancelRefreshDictionaryStatementContext, *parser.DropDictionaryStatementContext:
+		return true
+	}
+	return false
+}
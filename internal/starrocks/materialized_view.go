@@ -0,0 +1,79 @@
+package starrocks
+
+import (
+	"strings"
+
+	"github.com/Edsuns/sql-parser/analyzer"
+	"github.com/Edsuns/sql-parser/internal/starrocks/parser"
+)
+
+// EnterCreateMaterializedViewStatement 进入创建物化视图语句时调用，物化视图是写表，查询中的基表是读表
+func (l *dependencyListener) EnterCreateMaterializedViewStatement(ctx *parser.CreateMaterializedViewStatementContext) {
+	l.curOpType = analyzer.StmtTypeCreateMaterializedView
+	l.onWriteStmt()
+	for _, desc := range ctx.AllMaterializedViewDesc() {
+		if desc.MvPartitionExprs() == nil {
+			continue
+		}
+		mv := l.materializedView()
+		for _, expr := range desc.MvPartitionExprs().AllPrimaryExpression() {
+			mv.PartitionBy = append(mv.PartitionBy, analyzer.OriginalText(expr))
+		}
+	}
+}
+
+// EnterAlterMaterializedViewStatement 进入修改物化视图语句时调用
+func (l *dependencyListener) EnterAlterMaterializedViewStatement(ctx *parser.AlterMaterializedViewStatementContext) {
+	l.curOpType = analyzer.StmtTypeAlterMaterializedView
+	l.onWriteStmt()
+}
+
+// EnterRefreshMaterializedViewStatement 进入刷新物化视图语句时调用，提供 Catalog 时由 analyzer.AddRefreshReads 补充读取的基表
+func (l *dependencyListener) EnterRefreshMaterializedViewStatement(ctx *parser.RefreshMaterializedViewStatementContext) {
+	l.curOpType = analyzer.StmtTypeRefreshMaterializedView
+	l.onWriteStmt()
+}
+
+// EnterCancelRefreshMaterializedViewStatement 进入取消刷新物化视图语句时调用
+func (l *dependencyListener) EnterCancelRefreshMaterializedViewStatement(ctx *parser.CancelRefreshMaterializedViewStatementContext) {
+	l.curOpType = analyzer.StmtTypeCancelRefresh
+	l.onWriteStmt()
+}
+
+// EnterDropMaterializedViewStatement 进入删除物化视图语句时调用
+func (l *dependencyListener) EnterDropMaterializedViewStatement(ctx *parser.DropMaterializedViewStatementContext) {
+	l.curOpType = analyzer.StmtTypeDropMaterializedView
+	l.onWriteStmt()
+}
+
+// EnterRefreshSchemeDesc 进入刷新方式时调用，例如 REFRESH DEFERRED ASYNC START('2024-01-01 00:00:00') EVERY(INTERVAL 1 DAY)
+func (l *dependencyListener) EnterRefreshSchemeDesc(ctx *parser.RefreshSchemeDescContext) {
+	mv := l.materializedView()
+	switch {
+	case ctx.ASYNC() != nil:
+		mv.RefreshMode = analyzer.RefreshModeAsync
+	case ctx.MANUAL() != nil:
+		mv.RefreshMode = analyzer.RefreshModeManual
+	case ctx.INCREMENTAL() != nil:
+		mv.RefreshMode = analyzer.RefreshModeIncremental
+	}
+	if ctx.IMMEDIATE() != nil {
+		mv.RefreshMoment = "IMMEDIATE"
+	} else if ctx.DEFERRED() != nil {
+		mv.RefreshMoment = "DEFERRED"
+	}
+	if ctx.String_() != nil {
+		mv.RefreshStart = unquote(ctx.String_().GetText())
+	}
+	if interval := ctx.Interval(); interval != nil {
+		mv.RefreshEvery = analyzer.OriginalText(interval.GetValue()) + " " + strings.ToUpper(interval.GetFrom().GetText())
+	}
+}
+
+// materializedView 返回语句中物化视图的刷新方式和分区表达式，不存在时创建
+func (l *dependencyListener) materializedView() *analyzer.MaterializedView {
+	if l.dependencies.MaterializedView == nil {
+		l.dependencies.MaterializedView = &analyzer.MaterializedView{}
+	}
+	return l.dependencies.MaterializedView
+}
@@ -6,6 +6,9 @@ import (
 	"github.com/antlr4-go/antlr/v4"
 )
 
+// dialect 词法分析出错时无语法拆分SQL语句使用的词法规则
+var dialect = analyzer.Dialect{BackslashEscapes: true}
+
 func makeLexer(sql string) *parser.StarRocksLexer {
 	// 创建字符流
 	input := analyzer.NewCaseInsensitiveInputStream(sql)
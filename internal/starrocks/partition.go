@@ -0,0 +1,107 @@
+package starrocks
+
+import (
+	"github.com/Edsuns/sql-parser/analyzer"
+	"github.com/Edsuns/sql-parser/internal/starrocks/parser"
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// relationTables 表关系对应的读表
+type relationTables map[*parser.TableAtomContext]*analyzer.DependencyTable
+
+// EnterPartitionNames 进入分区限定时调用，例如 PARTITION (p1, p2) 或 PARTITION (dt='2024-01-01')，分区属于前面的表
+func (l *dependencyListener) EnterPartitionNames(ctx *parser.PartitionNamesContext) {
+	if l.lastTable == nil {
+		return
+	}
+	for _, name := range ctx.AllIdentifierOrString() {
+		l.addPartitionName(name.GetText())
+	}
+	if keys, ok := ctx.KeyPartitions().(*parser.KeyPartitionListContext); ok {
+		spec := &analyzer.PartitionSpec{}
+		for _, key := range keys.AllKeyPartition() {
+			spec.Values = append(spec.Values, &analyzer.PartitionValue{
+				Key:   unquote(key.GetPartitionColName().GetText()),
+				Value: unquote(key.GetPartitionColValue().GetText()),
+			})
+		}
+		l.lastTable.Partitions = append(l.lastTable.Partitions, spec)
+	}
+}
+
+// EnterAddPartitionClause 进入添加分区子句时调用，记录添加的单个分区
+func (l *dependencyListener) EnterAddPartitionClause(ctx *parser.AddPartitionClauseContext) {
+	switch {
+	case ctx.SingleRangePartition() != nil:
+		l.addPartitionName(ctx.SingleRangePartition().Identifier().GetText())
+	case ctx.SingleItemListPartitionDesc() != nil:
+		l.addPartitionName(ctx.SingleItemListPartitionDesc().Identifier().GetText())
+	case ctx.MultiItemListPartitionDesc() != nil:
+		l.addPartitionName(ctx.MultiItemListPartitionDesc().Identifier().GetText())
+	}
+}
+
+// EnterDropPartitionClause 进入删除分区子句时调用，按范围或条件删除的分区无法确定
+func (l *dependencyListener) EnterDropPartitionClause(ctx *parser.DropPartitionClauseContext) {
+	if ctx.Identifier() != nil {
+		l.addPartitionName(ctx.Identifier().GetText())
+	}
+	if ctx.IdentifierList() != nil {
+		for _, name := range ctx.IdentifierList().AllIdentifier() {
+			l.addPartitionName(name.GetText())
+		}
+	}
+}
+
+// addPartitionName 为最近添加的表记录按名称指定的分区
+func (l *dependencyListener) addPartitionName(name string) {
+	if l.lastTable == nil {
+		return
+	}
+	l.lastTable.Partitions = append(l.lastTable.Partitions, &analyzer.PartitionSpec{Name: unquote(name)})
+}
+
+// EnterTableAtom 进入表关系时调用
+func (l *dependencyListener) EnterTableAtom(ctx *parser.TableAtomContext) {
+	l.lastTable = nil
+}
+
+// ExitTableAtom 退出表关系时调用，记录表关系对应的读表
+func (l *dependencyListener) ExitTableAtom(ctx *parser.TableAtomContext) {
+	if l.lastTable != nil {
+		l.relations[ctx] = l.lastTable
+	}
+}
+
+// ExitQuerySpecification 退出查询时调用，此时FROM中的表已添加，根据 Catalog 中的分区键从WHERE条件得到读表读取的分区
+func (l *dependencyListener) ExitQuerySpecification(ctx *parser.QuerySpecificationContext) {
+	if l.catalog == nil || ctx.GetWhere() == nil {
+		return
+	}
+	filters := analyzer.ParseFilters(analyzer.ContextTokens(ctx.GetParser().GetTokenStream(), ctx.GetWhere()))
+	for _, relation := range tableAtoms(ctx.FromClause(), nil) {
+		table := l.relations[relation]
+		if table == nil {
+			continue
+		}
+		alias := ""
+		if relation.GetAlias() != nil {
+			alias = unquote(relation.GetAlias().GetText())
+		}
+		analyzer.ApplyFilters(l.catalog, table, alias, filters)
+	}
+}
+
+// tableAtoms 查找FROM子句中的表关系，不包括子查询中的表
+func tableAtoms(tree antlr.Tree, atoms []*parser.TableAtomContext) []*parser.TableAtomContext {
+	switch node := tree.(type) {
+	case *parser.TableAtomContext:
+		return append(atoms, node)
+	case *parser.SubqueryContext:
+		return atoms
+	}
+	for _, child := range tree.GetChildren() {
+		atoms = tableAtoms(child, atoms)
+	}
+	return atoms
+}
@@ -0,0 +1,60 @@
+package starrocks
+
+import (
+	"strings"
+
+	"github.com/Edsuns/sql-parser/analyzer"
+	"github.com/Edsuns/sql-parser/internal/starrocks/parser"
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// EnterSubmitTaskStatement 进入提交任务语句时调用，嵌入语句的读写表同时作为该语句的读写表
+func (l *dependencyListener) EnterSubmitTaskStatement(ctx *parser.SubmitTaskStatementContext) {
+	l.curOpType = analyzer.StmtTypeSubmitTask
+	l.isOnlyComment = false
+	if l.firstOpType == "" {
+		l.firstOpType = analyzer.StmtTypeSubmitTask
+	}
+	l.dependencies.Schedule = &analyzer.Schedule{}
+	if ctx.QualifiedName() != nil {
+		l.dependencies.Schedule.Name = unquote(ctx.QualifiedName().GetText())
+	}
+}
+
+// ExitSubmitTaskStatement 退出提交任务语句时调用，嵌入的语句单独分析后作为子语句
+func (l *dependencyListener) ExitSubmitTaskStatement(ctx *parser.SubmitTaskStatementContext) {
+	stmt, ok := ctx.GetChild(ctx.GetChildCount() - 1).(antlr.ParserRuleContext)
+	if !ok {
+		return
+	}
+	child := newDependencyListener(l.defaultCluster, l.defaultDatabase)
+	child.catalog = l.catalog
+	child.session = l.session
+	antlr.ParseTreeWalkerDefault.Walk(child, stmt)
+	child.dependencies.Stmt = analyzer.OriginalText(stmt)
+	child.dependencies.StmtType = child.firstOpType
+	l.dependencies.Children = append(l.dependencies.Children, child.dependencies)
+}
+
+// EnterTaskScheduleDesc 进入任务的调度周期时调用，例如 SCHEDULE START('2024-01-01 02:00:00') EVERY(INTERVAL 1 DAY)
+func (l *dependencyListener) EnterTaskScheduleDesc(ctx *parser.TaskScheduleDescContext) {
+	schedule := l.dependencies.Schedule
+	if schedule == nil {
+		return
+	}
+	interval := ctx.TaskInterval()
+	schedule.Every = analyzer.OriginalText(interval.GetValue()) + " " + strings.ToUpper(interval.GetFrom().GetText())
+	if ctx.String_() != nil {
+		schedule.Offset = unquote(ctx.String_().GetText())
+	}
+}
+
+// EnterDropTaskStatement 进入删除任务语句时调用
+func (l *dependencyListener) EnterDropTaskStatement(ctx *parser.DropTaskStatementContext) {
+	l.curOpType = analyzer.StmtTypeDropTask
+	l.isOnlyComment = false
+	if l.firstOpType == "" {
+		l.firstOpType = analyzer.StmtTypeDropTask
+	}
+	l.dependencies.Schedule = &analyzer.Schedule{Name: unquote(ctx.QualifiedName().GetText())}
+}
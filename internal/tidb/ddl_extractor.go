@@ -0,0 +1,200 @@
+package tidb
+
+import (
+	"strings"
+
+	"github.com/Edsuns/sql-parser/analyzer"
+	"github.com/pingcap/tidb/pkg/parser"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+)
+
+// ddlExtractor 实现了 DDLExtractor 接口
+type ddlExtractor struct{}
+
+// NewDDLExtractor 创建一个新的 DDLExtractor 实例
+func NewDDLExtractor() analyzer.DDLExtractor {
+	return &ddlExtractor{}
+}
+
+// ExtractDDL 解析SQL语句并提取每条DDL语句的表结构变更
+func (e *ddlExtractor) ExtractDDL(sql, defaultCluster, defaultDatabase string) ([]*analyzer.DDLEvent, error) {
+	// 创建TiDB解析器
+	p := parser.New()
+
+	// 解析SQL语句
+	stmts, _, err := p.Parse(sql, "", "")
+	if err != nil {
+		return nil, err
+	}
+	var result []*analyzer.DDLEvent
+	for _, stmt := range stmts {
+		x := &ddlStmt{
+			stmt:            strings.TrimSpace(stmt.OriginalText()),
+			defaultCluster:  defaultCluster,
+			defaultDatabase: defaultDatabase,
+		}
+		x.extract(stmt)
+		result = append(result, x.events...)
+	}
+	return result, nil
+}
+
+// ddlStmt 提取单条DDL语句中的表结构变更
+type ddlStmt struct {
+	stmt            string
+	defaultCluster  string
+	defaultDatabase string
+	events          []*analyzer.DDLEvent
+}
+
+func (x *ddlStmt) extract(stmt ast.StmtNode) {
+	switch n := stmt.(type) {
+	// CREATE TABLE语句
+	case *ast.CreateTableStmt:
+		if n.ReferTable != nil {
+			e := x.addEvent(analyzer.DDLOpCreateLike, n.Table)
+			e.IfNotExists = n.IfNotExists
+			e.Source = x.table(n.ReferTable)
+			return
+		}
+		e := x.addEvent(analyzer.DDLOpCreateTable, n.Table)
+		e.IfNotExists = n.IfNotExists
+		e.Columns = columns(n.Cols)
+		if n.Partition != nil {
+			e.PartitionKeys = partitionKeys(&n.Partition.PartitionMethod)
+			for _, def := range n.Partition.Definitions {
+				e.Partitions = append(e.Partitions, def.Name.O)
+			}
+		}
+
+	// CREATE VIEW语句
+	case *ast.CreateViewStmt:
+		e := x.addEvent(analyzer.DDLOpCreateView, n.ViewName)
+		e.OrReplace = n.OrReplace
+		e.ViewDefinition = x.stmt
+		for _, col := range n.Cols {
+			e.Columns = append(e.Columns, &analyzer.CatalogColumn{Name: col.O})
+		}
+
+	// DROP TABLE/VIEW语句
+	case *ast.DropTableStmt:
+		op := analyzer.DDLOpDropTable
+		if n.IsView {
+			op = analyzer.DDLOpDropView
+		}
+		for _, table := range n.Tables {
+			e := x.addEvent(op, table)
+			e.IfExists = n.IfExists
+		}
+
+	// RENAME TABLE语句
+	case *ast.RenameTableStmt:
+		for _, t2t := range n.TableToTables {
+			e := x.addEvent(analyzer.DDLOpRenameTable, t2t.OldTable)
+			e.NewTable = x.table(t2t.NewTable)
+		}
+
+	// ALTER TABLE语句
+	case *ast.AlterTableStmt:
+		for _, spec := range n.Specs {
+			x.alter(n.Table, spec)
+		}
+	}
+}
+
+// alter 提取ALTER TABLE子句中的列和分区变更
+func (x *ddlStmt) alter(table *ast.TableName, spec *ast.AlterTableSpec) {
+	switch spec.Tp {
+	case ast.AlterTableAddColumns:
+		e := x.addEvent(analyzer.DDLOpAddColumn, table)
+		e.IfNotExists = spec.IfNotExists
+		e.Columns = columns(spec.NewColumns)
+	case ast.AlterTableDropColumn:
+		e := x.addEvent(analyzer.DDLOpDropColumn, table)
+		e.IfExists = spec.IfExists
+		e.Column = spec.OldColumnName.Name.O
+	case ast.AlterTableModifyColumn:
+		e := x.addEvent(analyzer.DDLOpModifyColumn, table)
+		e.Columns = columns(spec.NewColumns)
+	case ast.AlterTableChangeColumn:
+		e := x.addEvent(analyzer.DDLOpModifyColumn, table)
+		e.Column = spec.OldColumnName.Name.O
+		e.Columns = columns(spec.NewColumns)
+	case ast.AlterTableRenameColumn:
+		e := x.addEvent(analyzer.DDLOpRenameColumn, table)
+		e.Column = spec.OldColumnName.Name.O
+		e.NewColumn = spec.NewColumnName.Name.O
+	case ast.AlterTableRenameTable:
+		e := x.addEvent(analyzer.DDLOpRenameTable, table)
+		e.NewTable = x.table(spec.NewTable)
+	case ast.AlterTableAddPartitions:
+		e := x.addEvent(analyzer.DDLOpAddPartition, table)
+		e.IfNotExists = spec.IfNotExists
+		for _, def := range spec.PartDefinitions {
+			e.Partitions = append(e.Partitions, def.Name.O)
+		}
+	case ast.AlterTableDropPartition:
+		e := x.addEvent(analyzer.DDLOpDropPartition, table)
+		e.IfExists = spec.IfExists
+		for _, name := range spec.PartitionNames {
+			e.Partitions = append(e.Partitions, name.O)
+		}
+	}
+}
+
+// addEvent 添加一个表结构变更
+func (x *ddlStmt) addEvent(op analyzer.DDLOpType, table *ast.TableName) *analyzer.DDLEvent {
+	e := &analyzer.DDLEvent{
+		Op:    op,
+		Stmt:  x.stmt,
+		Table: x.table(table),
+	}
+	x.events = append(x.events, e)
+	return e
+}
+
+// table 转换表名，未指定库名时使用默认库
+func (x *ddlStmt) table(t *ast.TableName) *analyzer.DependencyTable {
+	db := x.defaultDatabase
+	if t.Schema.O != "" {
+		db = t.Schema.O
+	}
+	return &analyzer.DependencyTable{
+		Cluster:  x.defaultCluster,
+		Database: db,
+		Table:    t.Name.O,
+	}
+}
+
+// columns 转换列定义
+func columns(defs []*ast.ColumnDef) []*analyzer.CatalogColumn {
+	var result []*analyzer.CatalogColumn
+	for _, def := range defs {
+		c := &analyzer.CatalogColumn{Name: def.Name.Name.O}
+		if def.Tp != nil {
+			c.Type = def.Tp.String()
+		}
+		for _, opt := range def.Options {
+			if opt.Tp != ast.ColumnOptionComment {
+				continue
+			}
+			if v, ok := opt.Expr.(ast.ValueExpr); ok {
+				c.Comment = v.GetString()
+			}
+		}
+		result = append(result, c)
+	}
+	return result
+}
+
+// partitionKeys 获取分区键，分区表达式只有一个列名时才作为分区键，例如 RANGE (YEAR(dt)) 没有分区键
+func partitionKeys(method *ast.PartitionMethod) []string {
+	var keys []string
+	for _, c := range method.ColumnNames {
+		keys = append(keys, c.Name.O)
+	}
+	if c, ok := method.Expr.(*ast.ColumnNameExpr); ok {
+		keys = append(keys, c.Name.Name.O)
+	}
+	return keys
+}
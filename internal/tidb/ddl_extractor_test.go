@@ -0,0 +1,53 @@
+package tidb
+
+import (
+	"testing"
+
+	"github.com/Edsuns/sql-parser/analyzer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTiDBDDLExtractor(t *testing.T) {
+	sql := `CREATE TABLE orders (id BIGINT COMMENT '订单ID', amount INT, status INT)
+PARTITION BY RANGE (id) (PARTITION p0 VALUES LESS THAN (100));
+ALTER TABLE orders ADD COLUMN note VARCHAR(64), DROP COLUMN amount;
+ALTER TABLE orders CHANGE status state INT;
+ALTER TABLE orders ADD PARTITION (PARTITION p1 VALUES LESS THAN (200));
+ALTER TABLE orders DROP PARTITION p0;
+CREATE TABLE orders_bak LIKE orders;
+RENAME TABLE orders_bak TO orders_old;
+CREATE VIEW orders_v AS SELECT id FROM orders;
+DROP VIEW orders_v;
+ALTER TABLE missing ADD COLUMN c INT;`
+
+	model := analyzer.NewSchemaModel()
+	err := model.Replay(NewDDLExtractor(), &analyzer.DependencyAnalyzeReq{
+		SQL:             sql,
+		DefaultCluster:  "tidb",
+		DefaultDatabase: "shop",
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	orders, _ := model.LookupTable("tidb", "shop", "orders")
+	if assert.NotNil(t, orders) {
+		var names []string
+		for _, c := range orders.Columns {
+			names = append(names, c.Name)
+		}
+		assert.Equal(t, []string{"id", "state", "note"}, names)
+		assert.Equal(t, "订单ID", orders.Columns[0].Comment)
+		assert.Equal(t, []string{"id"}, orders.PartitionKeys)
+		assert.Equal(t, []string{"p1"}, orders.Partitions)
+	}
+
+	exists, _ := model.TableExists("tidb", "shop", "orders_old")
+	assert.True(t, exists)
+	exists, _ = model.TableExists("tidb", "shop", "orders_v")
+	assert.False(t, exists)
+
+	if assert.Len(t, model.Conflicts, 1) {
+		assert.Equal(t, "tidb.shop.missing", model.Conflicts[0].Table)
+	}
+}
@@ -1,6 +1,7 @@
 package tidb
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/Edsuns/sql-parser/analyzer"
@@ -21,21 +22,54 @@ func (a *dependencyAnalyzer) Analyze(req *analyzer.DependencyAnalyzeReq) ([]*ana
 	// 创建TiDB解析器
 	p := parser.New()
 
-	// 解析SQL语句
-	stmts, _, err := p.Parse(req.SQL, "", "")
-	if err != nil {
-		return nil, err
+	// 按客户端的 DELIMITER 拆分脚本后逐段解析SQL语句
+	var stmts []ast.StmtNode
+	for _, piece := range analyzer.SplitClientScript(req.SQL) {
+		nodes, _, err := p.Parse(piece, "", "")
+		if err != nil {
+			// 整段解析失败时无语法拆分后逐条解析，找出出错的语句
+			if nodes, err = parseEach(p, piece); err != nil {
+				return nil, err
+			}
+		}
+		stmts = append(stmts, nodes...)
+	}
+	// 语句的原文可能包含上一条语句结尾同一行的注释，按拆分出的位置重新确定原文
+	texts := make([]string, len(stmts))
+	for i, stmt := range stmts {
+		texts[i] = strings.TrimSpace(stmt.OriginalText())
 	}
+	spans := analyzer.LocateSpans(req.SQL, texts)
 	var result []*analyzer.DependencyResult
-	for _, stmt := range stmts {
+	for i, stmt := range stmts {
 		deps := a.parseOneStmt(stmt, req.DefaultCluster, req.DefaultDatabase)
 		if deps != nil {
+			deps.Stmt, deps.Span = spans[i].Raw, spans[i]
 			result = append(result, deps)
 		}
 	}
+	if err := analyzer.PostProcess(a, result, req); err != nil {
+		return nil, err
+	}
 	return result, nil
 }
 
+// dialect 无语法拆分SQL语句使用的词法规则，# 开始单行注释，-- 之后必须是空白
+var dialect = analyzer.Dialect{HashComments: true, DashSpace: true, BackslashEscapes: true}
+
+// parseEach 按 dialect 拆分SQL语句后逐条解析，返回第一条解析失败的语句的错误
+func parseEach(p *parser.Parser, sql string) ([]ast.StmtNode, error) {
+	var stmts []ast.StmtNode
+	for _, stmt := range analyzer.SplitText(sql, dialect) {
+		nodes, _, err := p.Parse(stmt, "", "")
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", err, stmt)
+		}
+		stmts = append(stmts, nodes...)
+	}
+	return stmts, nil
+}
+
 func (a *dependencyAnalyzer) ParseOne(sql, defaultCluster, defaultDatabase string) (*analyzer.DependencyResult, error) {
 	// 创建TiDB解析器
 	p := parser.New()
@@ -247,6 +247,24 @@ func TestTiDBDependencyAnalyzer(t *testing.T) {
 				Write: []*analyzer.DependencyTable{},
 			}},
 		},
+		{
+			name: "Client script with DELIMITER and \\G",
+			sql:  "DELIMITER //\nINSERT INTO t1 SELECT * FROM t2 WHERE c = ';'//\nDELIMITER ;\nSELECT * FROM t1\\G",
+			expected: []*analyzer.DependencyResult{{
+				StmtType: analyzer.StmtTypeInsert,
+				// 与 INSERT SELECT statement 相同，INSERT ... SELECT 的来源表不作为读表
+				Read: []*analyzer.DependencyTable{},
+				Write: []*analyzer.DependencyTable{
+					{Cluster: "default_cluster", Database: "default_db", Table: "t1"},
+				},
+			}, {
+				StmtType: analyzer.StmtTypeSelect,
+				Read: []*analyzer.DependencyTable{
+					{Cluster: "default_cluster", Database: "default_db", Table: "t1"},
+				},
+				Write: []*analyzer.DependencyTable{},
+			}},
+		},
 		// USE语句测试
 		{
 			name: "USE database statement",
@@ -299,3 +317,54 @@ func TestTiDBDependencyAnalyzer(t *testing.T) {
 		})
 	}
 }
+
+func TestTiDBDependencyAnalyzer_ClientScript(t *testing.T) {
+	sql := "DELIMITER //\nINSERT INTO t1 SELECT * FROM t2 WHERE c = ';'//\nDELIMITER ;\nSELECT * FROM t1\\G"
+	results, err := NewDependencyAnalyzer().Analyze(&analyzer.DependencyAnalyzeReq{
+		DefaultCluster:  "default_cluster",
+		DefaultDatabase: "default_db",
+		Type:            analyzer.EngineTiDB,
+		SQL:             sql,
+	})
+	if !assert.NoError(t, err) || !assert.Len(t, results, 2) {
+		return
+	}
+	// 字符串中的分号不拆分语句，自定义分隔符和 \G 不属于语句
+	assert.Equal(t, "INSERT INTO t1 SELECT * FROM t2 WHERE c = ';'", results[0].Stmt)
+	assert.Equal(t, "SELECT * FROM t1", results[1].Stmt)
+}
+
+func TestTiDBDependencyAnalyzer_Spans(t *testing.T) {
+	sql := "SELECT * FROM t1; -- This is a comment\n-- load t2\nINSERT INTO t2 VALUES (1, 'test')"
+	results, err := NewDependencyAnalyzer().Analyze(&analyzer.DependencyAnalyzeReq{
+		DefaultCluster:  "default_cluster",
+		DefaultDatabase: "default_db",
+		Type:            analyzer.EngineTiDB,
+		SQL:             sql,
+	})
+	if !assert.NoError(t, err) || !assert.Len(t, results, 2) {
+		return
+	}
+	assert.Equal(t, "SELECT * FROM t1;", results[0].Stmt)
+	assert.Equal(t, []string{"-- This is a comment"}, results[0].Span.Comments)
+
+	// 单独成行的注释属于下一条语句
+	assert.Equal(t, "-- load t2\nINSERT INTO t2 VALUES (1, 'test')", results[1].Stmt)
+	assert.Equal(t, "INSERT INTO t2 VALUES (1, 'test')", results[1].Span.Text)
+	assert.Equal(t, []string{"-- load t2"}, results[1].Span.Comments)
+	assert.Equal(t, 39, results[1].Span.Start)
+	assert.Equal(t, 2, results[1].Span.Line)
+}
+
+func TestTiDBDependencyAnalyzer_SyntaxErrorStatement(t *testing.T) {
+	_, err := NewDependencyAnalyzer().Analyze(&analyzer.DependencyAnalyzeReq{
+		DefaultCluster:  "default_cluster",
+		DefaultDatabase: "default_db",
+		Type:            analyzer.EngineTiDB,
+		SQL:             "SELECT * FROM t1; # it's fine\nSELEC * FROM t2;\nSELECT 3",
+	})
+	// 错误信息包含出错的语句
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "SELEC * FROM t2;")
+	}
+}
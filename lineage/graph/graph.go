@@ -0,0 +1,186 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Edsuns/sql-parser/analyzer"
+)
+
+type (
+	// Script 一个脚本的依赖分析结果
+	Script struct {
+		Name    string                       `json:"name"`
+		Results []*analyzer.DependencyResult `json:"results"`
+	}
+	// Options 构建依赖图的选项
+	Options struct {
+		Databases        []string `json:"databases"`        // 只保留这些数据库中的表，为空则不过滤
+		CollapseClusters bool     `json:"collapseClusters"` // 忽略集群，不同集群的同名表合并为一个节点
+	}
+)
+
+type (
+	// Node 表或视图节点
+	Node struct {
+		ID       string `json:"id"`
+		Cluster  string `json:"cluster,omitempty"`
+		Database string `json:"database"`
+		Table    string `json:"table"`
+	}
+	// Edge 语句产生的依赖边：读表 From 流向写表 To
+	Edge struct {
+		From     string            `json:"from"`
+		To       string            `json:"to"`
+		StmtType analyzer.StmtType `json:"stmtType"`
+		Script   string            `json:"script,omitempty"`
+		Stmt     int               `json:"stmt"` // 语句在脚本中的下标
+	}
+	// Graph 表级依赖图
+	Graph struct {
+		Nodes []*Node `json:"nodes"`
+		Edges []*Edge `json:"edges"`
+	}
+)
+
+// Build 根据一个或多个脚本的分析结果构建表级依赖图
+func Build(scripts []*Script, opts *Options) *Graph {
+	if opts == nil {
+		opts = &Options{}
+	}
+	databases := make(map[string]bool)
+	for _, db := range opts.Databases {
+		databases[strings.ToLower(db)] = true
+	}
+
+	g := &Graph{
+		Nodes: []*Node{},
+		Edges: []*Edge{},
+	}
+	nodes := make(map[string]*Node)
+	edges := make(map[string]bool)
+
+	addNode := func(t *analyzer.DependencyTable) *Node {
+		if len(databases) > 0 && !databases[strings.ToLower(t.Database)] {
+			return nil
+		}
+		node := &Node{Cluster: t.Cluster, Database: t.Database, Table: t.Table}
+		if opts.CollapseClusters {
+			node.Cluster = ""
+		}
+		node.ID = nodeID(node)
+		if existing, ok := nodes[node.ID]; ok {
+			return existing
+		}
+		nodes[node.ID] = node
+		g.Nodes = append(g.Nodes, node)
+		return node
+	}
+
+	for _, script := range scripts {
+		for i, result := range script.Results {
+			var targets []*Node
+			for _, write := range result.Write {
+				if node := addNode(write); node != nil {
+					targets = append(targets, node)
+				}
+			}
+			for _, read := range result.Read {
+				source := addNode(read)
+				if source == nil {
+					continue
+				}
+				for _, target := range targets {
+					if source == target {
+						continue
+					}
+					key := fmt.Sprintf("%s|%s|%s|%s|%d", source.ID, target.ID, result.StmtType, script.Name, i)
+					if edges[key] {
+						continue
+					}
+					edges[key] = true
+					g.Edges = append(g.Edges, &Edge{
+						From:     source.ID,
+						To:       target.ID,
+						StmtType: result.StmtType,
+						Script:   script.Name,
+						Stmt:     i,
+					})
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(g.Nodes, func(i, j int) bool {
+		return g.Nodes[i].ID < g.Nodes[j].ID
+	})
+	return g
+}
+
+// DOT 输出Graphviz DOT格式
+func (g *Graph) DOT() string {
+	var s strings.Builder
+	s.WriteString("digraph lineage {\n")
+	s.WriteString("  rankdir=LR;\n")
+	s.WriteString("  node [shape=box];\n")
+	for _, node := range g.Nodes {
+		s.WriteString(fmt.Sprintf("  %s;\n", dotQuote(node.ID)))
+	}
+	for _, edge := range g.Edges {
+		s.WriteString(fmt.Sprintf("  %s -> %s [label=%s];\n", dotQuote(edge.From), dotQuote(edge.To), dotQuote(edgeLabel(edge))))
+	}
+	s.WriteString("}\n")
+	return s.String()
+}
+
+// Mermaid 输出Mermaid flowchart格式
+func (g *Graph) Mermaid() string {
+	ids := make(map[string]string, len(g.Nodes))
+	var s strings.Builder
+	s.WriteString("flowchart LR\n")
+	for i, node := range g.Nodes {
+		// Mermaid节点ID不能包含点号等字符，使用序号作为ID，表名作为标签
+		ids[node.ID] = fmt.Sprintf("n%d", i)
+		s.WriteString(fmt.Sprintf("  %s[\"%s\"]\n", ids[node.ID], mermaidEscape(node.ID)))
+	}
+	for _, edge := range g.Edges {
+		s.WriteString(fmt.Sprintf("  %s -->|\"%s\"| %s\n", ids[edge.From], mermaidEscape(edgeLabel(edge)), ids[edge.To]))
+	}
+	return s.String()
+}
+
+// JSON 输出节点/边格式的JSON
+func (g *Graph) JSON() ([]byte, error) {
+	return json.Marshal(g)
+}
+
+// nodeID 节点的唯一标识，折叠集群时不包含集群名
+func nodeID(node *Node) string {
+	var parts []string
+	if node.Cluster != "" {
+		parts = append(parts, node.Cluster)
+	}
+	if node.Database != "" {
+		parts = append(parts, node.Database)
+	}
+	parts = append(parts, node.Table)
+	return strings.Join(parts, ".")
+}
+
+// edgeLabel 边的标签，多脚本时带上脚本名
+func edgeLabel(edge *Edge) string {
+	if edge.Script == "" {
+		return string(edge.StmtType)
+	}
+	return fmt.Sprintf("%s (%s#%d)", edge.StmtType, edge.Script, edge.Stmt)
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func mermaidEscape(s string) string {
+	return strings.ReplaceAll(s, `"`, "#quot;")
+}
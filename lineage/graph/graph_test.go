@@ -0,0 +1,127 @@
+package graph
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Edsuns/sql-parser/analyzer"
+	"github.com/stretchr/testify/assert"
+)
+
+func table(cluster, database, name string) *analyzer.DependencyTable {
+	return &analyzer.DependencyTable{Cluster: cluster, Database: database, Table: name}
+}
+
+var scripts = []*Script{
+	{
+		Name: "load",
+		Results: []*analyzer.DependencyResult{
+			{
+				StmtType: analyzer.StmtTypeInsert,
+				Read:     []*analyzer.DependencyTable{table("c1", "ods", "orders"), table("c1", "dim", "users")},
+				Write:    []*analyzer.DependencyTable{table("c1", "dw", "orders")},
+			},
+		},
+	},
+	{
+		Name: "report",
+		Results: []*analyzer.DependencyResult{
+			{
+				StmtType: analyzer.StmtTypeCreateView,
+				Read:     []*analyzer.DependencyTable{table("c2", "dw", "orders")},
+				Write:    []*analyzer.DependencyTable{table("c2", "ads", "report_v")},
+			},
+			{
+				StmtType: analyzer.StmtTypeSelect,
+				Read:     []*analyzer.DependencyTable{table("c2", "ads", "report_v")},
+				Write:    []*analyzer.DependencyTable{},
+			},
+		},
+	},
+}
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  *Options
+		nodes []string
+		edges [][2]string
+	}{
+		{
+			name:  "all tables",
+			nodes: []string{"c1.dim.users", "c1.dw.orders", "c1.ods.orders", "c2.ads.report_v", "c2.dw.orders"},
+			edges: [][2]string{
+				{"c1.ods.orders", "c1.dw.orders"},
+				{"c1.dim.users", "c1.dw.orders"},
+				{"c2.dw.orders", "c2.ads.report_v"},
+			},
+		},
+		{
+			name:  "collapse clusters",
+			opts:  &Options{CollapseClusters: true},
+			nodes: []string{"ads.report_v", "dim.users", "dw.orders", "ods.orders"},
+			edges: [][2]string{
+				{"ods.orders", "dw.orders"},
+				{"dim.users", "dw.orders"},
+				{"dw.orders", "ads.report_v"},
+			},
+		},
+		{
+			name:  "filter by database",
+			opts:  &Options{Databases: []string{"ODS", "dw"}, CollapseClusters: true},
+			nodes: []string{"dw.orders", "ods.orders"},
+			edges: [][2]string{
+				{"ods.orders", "dw.orders"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := Build(scripts, tt.opts)
+			var nodes []string
+			for _, node := range g.Nodes {
+				nodes = append(nodes, node.ID)
+			}
+			var edges [][2]string
+			for _, edge := range g.Edges {
+				edges = append(edges, [2]string{edge.From, edge.To})
+			}
+			assert.Equal(t, tt.nodes, nodes)
+			assert.Equal(t, tt.edges, edges)
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	g := Build([]*Script{{Results: scripts[0].Results}}, &Options{CollapseClusters: true})
+
+	assert.Equal(t, `digraph lineage {
+  rankdir=LR;
+  node [shape=box];
+  "dim.users";
+  "dw.orders";
+  "ods.orders";
+  "ods.orders" -> "dw.orders" [label="INSERT"];
+  "dim.users" -> "dw.orders" [label="INSERT"];
+}
+`, g.DOT())
+
+	assert.Equal(t, `flowchart LR
+  n0["dim.users"]
+  n1["dw.orders"]
+  n2["ods.orders"]
+  n2 -->|"INSERT"| n1
+  n0 -->|"INSERT"| n1
+`, g.Mermaid())
+
+	data, err := g.JSON()
+	if assert.NoError(t, err) {
+		var decoded Graph
+		assert.NoError(t, json.Unmarshal(data, &decoded))
+		assert.Equal(t, g, &decoded)
+	}
+
+	labelled := Build(scripts[1:], nil)
+	assert.Contains(t, labelled.DOT(), `"c2.dw.orders" -> "c2.ads.report_v" [label="CREATE_VIEW (report#0)"];`)
+}
@@ -0,0 +1,408 @@
+package impact
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/Edsuns/sql-parser/analyzer"
+)
+
+type (
+	// StatementRef 语句在语料中的位置
+	StatementRef struct {
+		Script   string                     `json:"script"`
+		Stmt     int                        `json:"stmt"` // 语句在脚本中的下标
+		StmtType analyzer.StmtType          `json:"stmtType"`
+		Result   *analyzer.DependencyResult `json:"-"`
+	}
+	// Closure 上游或下游闭包：涉及的表及产生依赖的语句
+	Closure struct {
+		Tables     []*analyzer.DependencyTable `json:"tables"`
+		Statements []*StatementRef             `json:"statements"`
+	}
+	// Script 一个已分析的脚本
+	Script struct {
+		Name    string                       `json:"name"`
+		Results []*analyzer.DependencyResult `json:"results"`
+	}
+)
+
+// Index 基于分析结果的影响分析索引，支持增量添加和删除脚本，可以并发查询
+//
+// 查询时表的集群为空表示匹配任意集群，库名和表名不区分大小写。
+type Index struct {
+	mu      sync.RWMutex
+	scripts map[string]*Script
+	order   []string
+	readers map[string][]*StatementRef // 库名.表名 -> 读取该表的语句
+	writers map[string][]*StatementRef // 库名.表名 -> 写入该表的语句
+}
+
+// NewIndex 创建一个空的索引
+func NewIndex() *Index {
+	return &Index{
+		scripts: make(map[string]*Script),
+		readers: make(map[string][]*StatementRef),
+		writers: make(map[string][]*StatementRef),
+	}
+}
+
+// AddScript 分析脚本并加入索引
+func (x *Index) AddScript(a analyzer.DependencyAnalyzer, name string, req *analyzer.DependencyAnalyzeReq) error {
+	results, err := a.Analyze(req)
+	if err != nil {
+		return err
+	}
+	x.Add(name, results)
+	return nil
+}
+
+// Add 加入脚本的分析结果，同名脚本会被替换
+func (x *Index) Add(name string, results []*analyzer.DependencyResult) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if _, ok := x.scripts[name]; ok {
+		x.remove(name)
+	}
+	x.scripts[name] = &Script{Name: name, Results: results}
+	x.order = append(x.order, name)
+	for i, result := range results {
+		ref := &StatementRef{Script: name, Stmt: i, StmtType: result.StmtType, Result: result}
+		for _, t := range result.Read {
+			x.readers[indexKey(t)] = appendRef(x.readers[indexKey(t)], ref)
+		}
+		for _, t := range result.Write {
+			x.writers[indexKey(t)] = appendRef(x.writers[indexKey(t)], ref)
+		}
+	}
+}
+
+// Remove 从索引中删除脚本，返回脚本是否存在
+func (x *Index) Remove(name string) bool {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if _, ok := x.scripts[name]; !ok {
+		return false
+	}
+	x.remove(name)
+	return true
+}
+
+func (x *Index) remove(name string) {
+	delete(x.scripts, name)
+	for i, n := range x.order {
+		if n == name {
+			x.order = append(x.order[:i], x.order[i+1:]...)
+			break
+		}
+	}
+	for _, index := range []map[string][]*StatementRef{x.readers, x.writers} {
+		for key, refs := range index {
+			var kept []*StatementRef
+			for _, ref := range refs {
+				if ref.Script != name {
+					kept = append(kept, ref)
+				}
+			}
+			if len(kept) == 0 {
+				delete(index, key)
+			} else {
+				index[key] = kept
+			}
+		}
+	}
+}
+
+// Scripts 按添加顺序返回所有脚本名
+func (x *Index) Scripts() []string {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return append([]string{}, x.order...)
+}
+
+// Readers 返回读取表的语句
+func (x *Index) Readers(table *analyzer.DependencyTable) []*StatementRef {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return matchRefs(x.readers[indexKey(table)], table, readSide)
+}
+
+// ColumnReaders 返回可能读取表中某一列的语句
+//
+// 分析器只提取表级依赖，这里按语句文本近似判断，结果是启发式的，可能有误报和漏报：
+//   - 用表名或别名限定的列 t.col、t.* 总是计入，限定了其他表或别名的同名列不计入
+//   - 没有限定的列只在语句只读取这一张表时计入，读取多张表时无法确定列属于哪张表
+//   - * 只在 SELECT * 这样的投影位置、且这个 SELECT 的 FROM 子句直接读取这张表时计入，
+//     FROM 中子查询读取的表不计入，count(*)、乘号等也不计入
+//
+// 字符串和注释中的内容被忽略。
+func (x *Index) ColumnReaders(table *analyzer.DependencyTable, column string) []*StatementRef {
+	unqualified := regexp.MustCompile(`(?i)(^|[^\w$.])` + regexp.QuoteMeta(column) + `($|[^\w$])`)
+	refs := []*StatementRef{}
+	for _, ref := range x.Readers(table) {
+		stmt := stripLiterals(ref.Result.Stmt)
+		qualifiers := tableQualifiers(stmt, table)
+		qualified := regexp.MustCompile(`(?i)(^|[^\w$.])(` + strings.Join(qualifiers, "|") + `)\s*\.\s*(` + regexp.QuoteMeta(column) + `|\*)($|[^\w$])`)
+		switch {
+		case qualified.MatchString(stmt), starReads(stmt, table),
+			onlyReads(ref.Result, table) && unqualified.MatchString(stmt):
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+var (
+	// literalPattern 字符串和注释
+	literalPattern = regexp.MustCompile(`'(?:[^'\\]|\\.)*'|"(?:[^"\\]|\\.)*"|--[^\n]*|/\*[\s\S]*?\*/`)
+	// selectStar 投影位置的 *，例如 SELECT *、SELECT DISTINCT *
+	selectStar = regexp.MustCompile(`(?i)(^|[^\w$])SELECT\s+((ALL|DISTINCT)\s+)?\*`)
+	// fromClauseEnd 结束 FROM 子句的关键字
+	fromClauseEnd = map[string]bool{
+		"WHERE": true, "GROUP": true, "HAVING": true, "ORDER": true, "SORT": true, "CLUSTER": true,
+		"DISTRIBUTE": true, "LIMIT": true, "WINDOW": true, "QUALIFY": true, "UNION": true, "EXCEPT": true,
+		"INTERSECT": true, "MINUS": true,
+	}
+	// notAliases 表名之后可能出现的关键字，不是表的别名
+	notAliases = map[string]bool{
+		"AS": true, "ON": true, "USING": true, "WHERE": true, "GROUP": true, "ORDER": true, "SORT": true,
+		"CLUSTER": true, "DISTRIBUTE": true, "HAVING": true, "LIMIT": true, "WINDOW": true, "QUALIFY": true,
+		"JOIN": true, "INNER": true, "LEFT": true, "RIGHT": true, "FULL": true, "CROSS": true, "NATURAL": true,
+		"SEMI": true, "ANTI": true, "LATERAL": true, "UNION": true, "EXCEPT": true, "INTERSECT": true, "MINUS": true,
+		"SELECT": true, "SET": true, "VALUES": true, "PARTITION": true, "TABLESAMPLE": true, "VERSION": true,
+		"TIMESTAMP": true, "FOR": true, "WITH": true, "IF": true, "OVERWRITE": true, "INTO": true, "TABLE": true,
+	}
+)
+
+// stripLiterals 把字符串和注释替换为空格，避免其中的文本被当作列名
+func stripLiterals(stmt string) string {
+	return literalPattern.ReplaceAllString(stmt, " ")
+}
+
+// tableQualifiers 返回语句中可以限定该表的列的前缀：表名、库名.表名以及表在语句中的别名
+func tableQualifiers(stmt string, table *analyzer.DependencyTable) []string {
+	name := "`?" + regexp.QuoteMeta(table.Table) + "`?"
+	qualifiers := []string{name, "`?" + regexp.QuoteMeta(table.Database) + "`?\\s*\\.\\s*" + name}
+	ref := regexp.MustCompile(`(?i)(?:^|[^\w$.])(?:` + "`?" + regexp.QuoteMeta(table.Database) + "`?" + `\s*\.\s*)?` + name +
+		`(?:\s+AS)?\s+([A-Za-z_][\w$]*)`)
+	for _, m := range ref.FindAllStringSubmatch(stmt, -1) {
+		if !notAliases[strings.ToUpper(m[1])] {
+			qualifiers = append(qualifiers, regexp.QuoteMeta(m[1]))
+		}
+	}
+	return qualifiers
+}
+
+// starReads 判断语句中是否有 SELECT * 的 FROM 子句直接读取这张表，FROM 中的子查询不算直接读取
+func starReads(stmt string, table *analyzer.DependencyTable) bool {
+	name := "`?" + regexp.QuoteMeta(table.Table) + "`?"
+	ref := regexp.MustCompile(`(?i)(^|[^\w$.])(` + "`?" + regexp.QuoteMeta(table.Database) + "`?" + `\s*\.\s*)?` + name + `($|[^\w$.])`)
+	for _, m := range selectStar.FindAllStringIndex(stmt, -1) {
+		if ref.MatchString(fromClause(stmt[m[1]:])) {
+			return true
+		}
+	}
+	return false
+}
+
+// fromClause 返回 SELECT 之后与它同一层括号的 FROM 子句，子查询等更深层括号中的内容替换为空格
+func fromClause(s string) string {
+	var sb strings.Builder
+	depth, inFrom := 0, false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth < 0 {
+				return sb.String()
+			}
+		case c == ';' && depth == 0:
+			return sb.String()
+		case depth == 0 && isWordChar(c) && (i == 0 || !isWordChar(s[i-1])):
+			j := i
+			for j < len(s) && isWordChar(s[j]) {
+				j++
+			}
+			word := strings.ToUpper(s[i:j])
+			if !inFrom {
+				inFrom = word == "FROM"
+				i = j - 1
+				continue
+			}
+			if fromClauseEnd[word] {
+				return sb.String()
+			}
+			sb.WriteString(s[i:j])
+			i = j - 1
+			continue
+		}
+		if inFrom {
+			if depth == 0 && c != ')' {
+				sb.WriteByte(c)
+			} else {
+				sb.WriteByte(' ')
+			}
+		}
+	}
+	return sb.String()
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// onlyReads 判断语句是否只读取这一张表
+func onlyReads(result *analyzer.DependencyResult, table *analyzer.DependencyTable) bool {
+	for _, t := range result.Read {
+		if !tableMatches(table, t) {
+			return false
+		}
+	}
+	return true
+}
+
+// Writers 返回写入表的语句
+func (x *Index) Writers(table *analyzer.DependencyTable) []*StatementRef {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return matchRefs(x.writers[indexKey(table)], table, writeSide)
+}
+
+// Upstream 返回表的完整上游闭包：直接或间接写入该表时读取的表，以及这些写入语句
+func (x *Index) Upstream(table *analyzer.DependencyTable) *Closure {
+	return x.closure(table, x.writers, writeSide, readSide)
+}
+
+// Downstream 返回表的完整下游闭包：删除该表后会受影响的表和语句
+func (x *Index) Downstream(table *analyzer.DependencyTable) *Closure {
+	return x.closure(table, x.readers, readSide, writeSide)
+}
+
+// closure 从表出发沿语句广度优先遍历，match 为语句中与当前表匹配的一侧，next 为继续遍历的一侧
+func (x *Index) closure(table *analyzer.DependencyTable, index map[string][]*StatementRef, match, next tableSide) *Closure {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	c := &Closure{Tables: []*analyzer.DependencyTable{}, Statements: []*StatementRef{}}
+	visitedTables := make(map[string]bool)
+	visitedStmts := make(map[*StatementRef]bool)
+	queue := []*analyzer.DependencyTable{table}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, ref := range matchRefs(index[indexKey(cur)], cur, match) {
+			if visitedStmts[ref] {
+				continue
+			}
+			visitedStmts[ref] = true
+			c.Statements = append(c.Statements, ref)
+			for _, t := range next(ref.Result) {
+				key := strings.ToLower(t.String())
+				if visitedTables[key] || tableMatches(table, t) {
+					continue
+				}
+				visitedTables[key] = true
+				c.Tables = append(c.Tables, t)
+				queue = append(queue, t)
+			}
+		}
+	}
+	return c
+}
+
+// Save 以JSON格式保存索引中的所有脚本
+func (x *Index) Save(w io.Writer) error {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	file := &indexFile{Scripts: make([]*Script, 0, len(x.order))}
+	for _, name := range x.order {
+		file.Scripts = append(file.Scripts, x.scripts[name])
+	}
+	return json.NewEncoder(w).Encode(file)
+}
+
+// SaveFile 保存索引到文件
+func (x *Index) SaveFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := x.Save(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// indexFile 索引文件格式
+type indexFile struct {
+	Scripts []*Script `json:"scripts"`
+}
+
+// Load 从 Save 保存的JSON中加载索引
+func Load(r io.Reader) (*Index, error) {
+	var file indexFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, err
+	}
+	x := NewIndex()
+	for _, s := range file.Scripts {
+		x.Add(s.Name, s.Results)
+	}
+	return x, nil
+}
+
+// LoadFile 从文件加载索引
+func LoadFile(path string) (*Index, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f)
+}
+
+// tableSide 返回语句的读表或写表
+type tableSide func(*analyzer.DependencyResult) []*analyzer.DependencyTable
+
+func readSide(r *analyzer.DependencyResult) []*analyzer.DependencyTable  { return r.Read }
+func writeSide(r *analyzer.DependencyResult) []*analyzer.DependencyTable { return r.Write }
+
+// matchRefs 过滤出 side 中包含该表的语句，用于区分不同集群的同名表
+func matchRefs(refs []*StatementRef, table *analyzer.DependencyTable, side tableSide) []*StatementRef {
+	result := []*StatementRef{}
+	for _, ref := range refs {
+		for _, t := range side(ref.Result) {
+			if tableMatches(table, t) {
+				result = append(result, ref)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// tableMatches 判断表是否匹配查询，查询的集群为空时匹配任意集群
+func tableMatches(query, t *analyzer.DependencyTable) bool {
+	return (query.Cluster == "" || strings.EqualFold(query.Cluster, t.Cluster)) &&
+		strings.EqualFold(query.Database, t.Database) &&
+		strings.EqualFold(query.Table, t.Table)
+}
+
+// appendRef 添加语句引用，同一语句读写多次同一张表时只记录一次
+func appendRef(refs []*StatementRef, ref *StatementRef) []*StatementRef {
+	if n := len(refs); n > 0 && refs[n-1] == ref {
+		return refs
+	}
+	return append(refs, ref)
+}
+
+func indexKey(t *analyzer.DependencyTable) string {
+	return strings.ToLower(t.Database + "." + t.Table)
+}
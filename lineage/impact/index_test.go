@@ -0,0 +1,128 @@
+package impact
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/Edsuns/sql-parser/analyzer"
+	"github.com/Edsuns/sql-parser/internal/spark"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestIndex(t *testing.T) *Index {
+	scripts := []struct {
+		name string
+		sql  string
+	}{
+		{"ods_to_dwd", "INSERT INTO dwd.orders SELECT id, user_id, amount FROM ods.orders"},
+		{"dwd_to_dws", `INSERT INTO dws.daily SELECT dt, sum(amount) FROM dwd.orders GROUP BY dt;
+INSERT INTO dws.users SELECT DISTINCT user_id FROM dwd.orders`},
+		{"report", "INSERT INTO ads.report SELECT * FROM dws.daily"},
+	}
+	x := NewIndex()
+	for _, s := range scripts {
+		err := x.AddScript(spark.NewDependencyAnalyzer(), s.name, &analyzer.DependencyAnalyzeReq{
+			DefaultCluster:  "c1",
+			DefaultDatabase: "default",
+			SQL:             s.sql,
+		})
+		assert.NoError(t, err)
+	}
+	return x
+}
+
+func refs(list []*StatementRef) [][2]any {
+	result := [][2]any{}
+	for _, ref := range list {
+		result = append(result, [2]any{ref.Script, ref.Stmt})
+	}
+	return result
+}
+
+func tables(list []*analyzer.DependencyTable) []string {
+	result := []string{}
+	for _, t := range list {
+		result = append(result, t.String())
+	}
+	return result
+}
+
+func TestIndex_Query(t *testing.T) {
+	x := newTestIndex(t)
+	orders := &analyzer.DependencyTable{Database: "DWD", Table: "orders"}
+
+	assert.Equal(t, [][2]any{{"dwd_to_dws", 0}, {"dwd_to_dws", 1}}, refs(x.Readers(orders)))
+	assert.Equal(t, [][2]any{{"dwd_to_dws", 0}}, refs(x.ColumnReaders(orders, "amount")))
+	assert.Equal(t, [][2]any{{"ods_to_dwd", 0}}, refs(x.Writers(orders)))
+	assert.Empty(t, x.Readers(&analyzer.DependencyTable{Cluster: "c2", Database: "dwd", Table: "orders"}))
+
+	down := x.Downstream(&analyzer.DependencyTable{Database: "ods", Table: "orders"})
+	assert.Equal(t, []string{"c1.dwd.orders", "c1.dws.daily", "c1.dws.users", "c1.ads.report"}, tables(down.Tables))
+	assert.Equal(t, [][2]any{{"ods_to_dwd", 0}, {"dwd_to_dws", 0}, {"dwd_to_dws", 1}, {"report", 0}}, refs(down.Statements))
+
+	up := x.Upstream(&analyzer.DependencyTable{Database: "ads", Table: "report"})
+	assert.Equal(t, []string{"c1.dws.daily", "c1.dwd.orders", "c1.ods.orders"}, tables(up.Tables))
+
+	// 删除脚本后索引增量更新
+	assert.True(t, x.Remove("dwd_to_dws"))
+	assert.False(t, x.Remove("dwd_to_dws"))
+	assert.Equal(t, []string{"ods_to_dwd", "report"}, x.Scripts())
+	assert.Empty(t, x.Readers(orders))
+	down = x.Downstream(&analyzer.DependencyTable{Database: "ods", Table: "orders"})
+	assert.Equal(t, []string{"c1.dwd.orders"}, tables(down.Tables))
+}
+
+func TestIndex_ColumnReaders(t *testing.T) {
+	scripts := []struct {
+		name string
+		sql  string
+	}{
+		{"plain", "SELECT amount FROM dwd.orders"},
+		{"star", "SELECT DISTINCT * FROM dwd.orders"},
+		{"alias", "SELECT o.amount FROM dwd.orders AS o JOIN dim.users u ON o.uid = u.id"},
+		{"alias_star", "SELECT u.name, o.* FROM dwd.orders o JOIN dim.users u ON o.uid = u.id"},
+		{"count", "SELECT count(*) FROM dwd.orders"},
+		{"multiply", "SELECT price * 2 FROM dwd.orders"},
+		{"other_table", "SELECT o.id, u.amount FROM dwd.orders o JOIN dim.users u ON o.uid = u.id"},
+		{"unqualified_join", "SELECT id, amount FROM dwd.orders o JOIN dim.users u ON o.uid = u.id"},
+		{"literal", "SELECT id FROM dwd.orders WHERE note = 'amount' -- amount"},
+		{"star_over_subquery", "SELECT * FROM (SELECT id FROM dwd.orders) t JOIN dim.users u ON t.id = u.id"},
+		{"star_in_subquery", "SELECT u.id FROM dim.users u WHERE u.id IN (SELECT * FROM dwd.orders)"},
+		{"star_in_exists", "SELECT * FROM dim.users u WHERE EXISTS (SELECT 1 FROM dwd.orders o WHERE o.uid = u.id)"},
+	}
+	x := NewIndex()
+	for _, s := range scripts {
+		err := x.AddScript(spark.NewDependencyAnalyzer(), s.name, &analyzer.DependencyAnalyzeReq{
+			DefaultCluster:  "c1",
+			DefaultDatabase: "default",
+			SQL:             s.sql,
+		})
+		assert.NoError(t, err)
+	}
+	orders := &analyzer.DependencyTable{Database: "dwd", Table: "orders"}
+	assert.Equal(t, [][2]any{{"plain", 0}, {"star", 0}, {"alias", 0}, {"alias_star", 0}, {"star_in_subquery", 0}}, refs(x.ColumnReaders(orders, "amount")))
+}
+
+func TestIndex_Persist(t *testing.T) {
+	x := newTestIndex(t)
+
+	var buf bytes.Buffer
+	if !assert.NoError(t, x.Save(&buf)) {
+		return
+	}
+	loaded, err := Load(&buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, x.Scripts(), loaded.Scripts())
+	orders := &analyzer.DependencyTable{Database: "dwd", Table: "orders"}
+	assert.Equal(t, refs(x.Readers(orders)), refs(loaded.Readers(orders)))
+
+	path := filepath.Join(t.TempDir(), "index.json")
+	if assert.NoError(t, x.SaveFile(path)) {
+		loaded, err = LoadFile(path)
+		assert.NoError(t, err)
+		assert.Equal(t, tables(x.Downstream(orders).Tables), tables(loaded.Downstream(orders).Tables))
+	}
+}
@@ -0,0 +1,321 @@
+package job
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Edsuns/sql-parser/analyzer"
+	"github.com/Edsuns/sql-parser/lineage"
+)
+
+// AnalyzerFactory 根据引擎类型创建依赖分析器
+type AnalyzerFactory func(engine analyzer.EngineType) (analyzer.DependencyAnalyzer, error)
+
+type (
+	// Job 一个ETL任务，对应一个SQL脚本
+	Job struct {
+		Name            string              `json:"name"`
+		Engine          analyzer.EngineType `json:"engine"`
+		DefaultCluster  string              `json:"defaultCluster"`
+		DefaultDatabase string              `json:"defaultDatabase"`
+		SQL             string              `json:"sql"`
+		Variables       map[string]string   `json:"variables,omitempty"`   // 脚本变量，替换SQL中的 ${var} 等占位符
+		Catalog         analyzer.Catalog    `json:"-"`                     // 可选，用于补充表的元数据和展开视图
+		ExpandViews     bool                `json:"expandViews,omitempty"` // 是否展开读取的视图，展开得到的表作为任务的输入
+	}
+	// JobIO 任务的外部输入和最终输出，脚本内的中间对象不计入
+	JobIO struct {
+		Name    string                      `json:"name"`
+		Inputs  []*analyzer.DependencyTable `json:"inputs"`
+		Outputs []*analyzer.DependencyTable `json:"outputs"`
+	}
+	// Dependency 任务间的依赖：To 读取了 From 写入的表
+	Dependency struct {
+		From   string                      `json:"from"`
+		To     string                      `json:"to"`
+		Tables []*analyzer.DependencyTable `json:"tables"`
+	}
+	// Cycle 存在循环依赖的一组任务及构成循环的表
+	Cycle struct {
+		Jobs   []string                    `json:"jobs"`
+		Tables []*analyzer.DependencyTable `json:"tables"`
+	}
+	// MultiWriter 被多个任务写入的表
+	MultiWriter struct {
+		Table *analyzer.DependencyTable `json:"table"`
+		Jobs  []string                  `json:"jobs"`
+	}
+	// DAG 任务级依赖图
+	DAG struct {
+		Jobs         []*JobIO       `json:"jobs"`
+		Dependencies []*Dependency  `json:"dependencies"`
+		Order        []string       `json:"order"` // 拓扑序，处于循环中或依赖循环的任务不在其中
+		Cycles       []*Cycle       `json:"cycles"`
+		MultiWriters []*MultiWriter `json:"multiWriters"`
+	}
+	// Diff 推导出的依赖与人工维护的依赖之间的差异，每一项为 [上游, 下游]
+	Diff struct {
+		Missing [][2]string `json:"missing"` // SQL中存在但没有声明的依赖
+		Extra   [][2]string `json:"extra"`   // 声明了但SQL中不存在的依赖
+	}
+)
+
+// Analyze 分析所有任务的SQL并构建任务级依赖图
+func Analyze(jobs []*Job, factory AnalyzerFactory) (*DAG, error) {
+	scripts := make([]*JobIO, 0, len(jobs))
+	for _, job := range jobs {
+		a, err := factory(job.Engine)
+		if err != nil {
+			return nil, fmt.Errorf("job %s: %w", job.Name, err)
+		}
+		script, err := lineage.AnalyzeScript(a, &analyzer.DependencyAnalyzeReq{
+			DefaultCluster:  job.DefaultCluster,
+			DefaultDatabase: job.DefaultDatabase,
+			Type:            job.Engine,
+			SQL:             job.SQL,
+			Catalog:         job.Catalog,
+			ExpandViews:     job.ExpandViews,
+			Variables:       job.Variables,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("job %s: %w", job.Name, err)
+		}
+		scripts = append(scripts, &JobIO{Name: job.Name, Inputs: script.Inputs, Outputs: script.Outputs})
+	}
+	return Build(scripts)
+}
+
+// Build 根据每个任务的输入输出构建任务级依赖图，任务名必须唯一
+func Build(jobs []*JobIO) (*DAG, error) {
+	dag := &DAG{
+		Jobs:         jobs,
+		Dependencies: []*Dependency{},
+		Order:        []string{},
+		Cycles:       []*Cycle{},
+		MultiWriters: []*MultiWriter{},
+	}
+
+	index := make(map[string]int, len(jobs))
+	for i, job := range jobs {
+		if _, ok := index[job.Name]; ok {
+			return nil, fmt.Errorf("duplicate job name: %s", job.Name)
+		}
+		index[job.Name] = i
+	}
+
+	// 表的所有写入任务
+	writers := make(map[string][]int)
+	var tables []string
+	for i, job := range jobs {
+		for _, t := range job.Outputs {
+			key := lineage.TableKey(t)
+			if len(writers[key]) == 0 {
+				tables = append(tables, key)
+			}
+			if n := len(writers[key]); n == 0 || writers[key][n-1] != i {
+				writers[key] = append(writers[key], i)
+			}
+		}
+	}
+	for _, key := range tables {
+		if len(writers[key]) < 2 {
+			continue
+		}
+		w := &MultiWriter{Jobs: []string{}}
+		for _, i := range writers[key] {
+			w.Jobs = append(w.Jobs, jobs[i].Name)
+			if w.Table == nil {
+				w.Table = findTable(jobs[i].Outputs, key)
+			}
+		}
+		dag.MultiWriters = append(dag.MultiWriters, w)
+	}
+
+	// 任务间的依赖边，按下游任务、上游任务的顺序排列
+	edges := make(map[[2]int]*Dependency)
+	successors := make([][]int, len(jobs))
+	for to, job := range jobs {
+		var froms []int
+		for _, t := range job.Inputs {
+			for _, from := range writers[lineage.TableKey(t)] {
+				if from == to {
+					continue
+				}
+				dep, ok := edges[[2]int{from, to}]
+				if !ok {
+					dep = &Dependency{From: jobs[from].Name, To: job.Name}
+					edges[[2]int{from, to}] = dep
+					froms = append(froms, from)
+				}
+				dep.Tables = append(dep.Tables, t)
+			}
+		}
+		sort.Ints(froms)
+		for _, from := range froms {
+			dag.Dependencies = append(dag.Dependencies, edges[[2]int{from, to}])
+			successors[from] = append(successors[from], to)
+		}
+	}
+
+	dag.Order = topoOrder(jobs, successors)
+	for _, scc := range stronglyConnected(len(jobs), successors) {
+		if len(scc) < 2 {
+			continue
+		}
+		in := make(map[int]bool, len(scc))
+		for _, i := range scc {
+			in[i] = true
+		}
+		cycle := &Cycle{Jobs: []string{}, Tables: []*analyzer.DependencyTable{}}
+		seen := make(map[string]bool)
+		for _, i := range scc {
+			cycle.Jobs = append(cycle.Jobs, jobs[i].Name)
+			for _, j := range successors[i] {
+				if !in[j] {
+					continue
+				}
+				for _, t := range edges[[2]int{i, j}].Tables {
+					if key := lineage.TableKey(t); !seen[key] {
+						seen[key] = true
+						cycle.Tables = append(cycle.Tables, t)
+					}
+				}
+			}
+		}
+		dag.Cycles = append(dag.Cycles, cycle)
+	}
+	return dag, nil
+}
+
+// Upstream 返回任务直接依赖的上游任务
+func (d *DAG) Upstream(name string) []string {
+	result := []string{}
+	for _, dep := range d.Dependencies {
+		if dep.To == name {
+			result = append(result, dep.From)
+		}
+	}
+	return result
+}
+
+// Compare 与人工维护的依赖比较，declared 的键为下游任务，值为其上游任务
+func (d *DAG) Compare(declared map[string][]string) *Diff {
+	diff := &Diff{Missing: [][2]string{}, Extra: [][2]string{}}
+	derived := make(map[[2]string]bool)
+	for _, dep := range d.Dependencies {
+		derived[[2]string{dep.From, dep.To}] = true
+	}
+	declaredEdges := make(map[[2]string]bool)
+	for to, froms := range declared {
+		for _, from := range froms {
+			declaredEdges[[2]string{from, to}] = true
+		}
+	}
+	for _, dep := range d.Dependencies {
+		if edge := [2]string{dep.From, dep.To}; !declaredEdges[edge] {
+			diff.Missing = append(diff.Missing, edge)
+		}
+	}
+	for edge := range declaredEdges {
+		if !derived[edge] {
+			diff.Extra = append(diff.Extra, edge)
+		}
+	}
+	sort.Slice(diff.Extra, func(i, j int) bool {
+		if diff.Extra[i][1] != diff.Extra[j][1] {
+			return diff.Extra[i][1] < diff.Extra[j][1]
+		}
+		return diff.Extra[i][0] < diff.Extra[j][0]
+	})
+	return diff
+}
+
+// topoOrder Kahn算法求拓扑序，入度相同时保持任务的原始顺序
+func topoOrder(jobs []*JobIO, successors [][]int) []string {
+	indegree := make([]int, len(jobs))
+	for _, tos := range successors {
+		for _, to := range tos {
+			indegree[to]++
+		}
+	}
+	order := []string{}
+	done := make([]bool, len(jobs))
+	for {
+		next := -1
+		for i := range jobs {
+			if !done[i] && indegree[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			return order
+		}
+		done[next] = true
+		order = append(order, jobs[next].Name)
+		for _, to := range successors[next] {
+			indegree[to]--
+		}
+	}
+}
+
+// stronglyConnected Tarjan算法求强连通分量
+func stronglyConnected(n int, successors [][]int) [][]int {
+	index := make([]int, n)
+	low := make([]int, n)
+	onStack := make([]bool, n)
+	for i := range index {
+		index[i] = -1
+	}
+	var (
+		stack  []int
+		result [][]int
+		next   int
+		visit  func(v int)
+	)
+	visit = func(v int) {
+		index[v], low[v] = next, next
+		next++
+		stack = append(stack, v)
+		onStack[v] = true
+		for _, w := range successors[v] {
+			if index[w] < 0 {
+				visit(w)
+				low[v] = min(low[v], low[w])
+			} else if onStack[w] {
+				low[v] = min(low[v], index[w])
+			}
+		}
+		if low[v] != index[v] {
+			return
+		}
+		var scc []int
+		for {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[w] = false
+			scc = append(scc, w)
+			if w == v {
+				break
+			}
+		}
+		sort.Ints(scc)
+		result = append(result, scc)
+	}
+	for v := 0; v < n; v++ {
+		if index[v] < 0 {
+			visit(v)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i][0] < result[j][0] })
+	return result
+}
+
+func findTable(tables []*analyzer.DependencyTable, key string) *analyzer.DependencyTable {
+	for _, t := range tables {
+		if lineage.TableKey(t) == key {
+			return t
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package job
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Edsuns/sql-parser/analyzer"
+	"github.com/Edsuns/sql-parser/internal/hive"
+	"github.com/Edsuns/sql-parser/internal/spark"
+	"github.com/stretchr/testify/assert"
+)
+
+func factory(engine analyzer.EngineType) (analyzer.DependencyAnalyzer, error) {
+	switch engine {
+	case analyzer.EngineSpark:
+		return spark.NewDependencyAnalyzer(), nil
+	case analyzer.EngineHive:
+		return hive.NewDependencyAnalyzer(), nil
+	}
+	return nil, fmt.Errorf("unsupported engine: %s", engine)
+}
+
+func TestAnalyze(t *testing.T) {
+	jobs := []*Job{
+		{Name: "report", Engine: analyzer.EngineHive, DefaultDatabase: "ads", SQL: "INSERT OVERWRITE TABLE report SELECT * FROM dws.daily"},
+		{Name: "daily", Engine: analyzer.EngineSpark, DefaultDatabase: "dws", SQL: `
+CREATE TEMPORARY VIEW tmp AS SELECT * FROM dwd.orders;
+INSERT OVERWRITE TABLE daily SELECT * FROM tmp JOIN dim.region ON tmp.region_id = region.id;`},
+		{Name: "orders", Engine: analyzer.EngineSpark, DefaultDatabase: "dwd", SQL: "INSERT INTO orders SELECT * FROM ods.orders"},
+		{Name: "orders_backfill", Engine: analyzer.EngineSpark, DefaultDatabase: "dwd", SQL: "INSERT INTO orders SELECT * FROM ods.orders_history"},
+	}
+	dag, err := Analyze(jobs, factory)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var deps []string
+	for _, dep := range dag.Dependencies {
+		deps = append(deps, fmt.Sprintf("%s -> %s %v", dep.From, dep.To, dep.Tables))
+	}
+	assert.Equal(t, []string{
+		"daily -> report [.dws.daily]",
+		"orders -> daily [.dwd.orders]",
+		"orders_backfill -> daily [.dwd.orders]",
+	}, deps)
+	assert.Equal(t, []string{"orders", "orders_backfill", "daily", "report"}, dag.Order)
+	assert.Empty(t, dag.Cycles)
+	if assert.Len(t, dag.MultiWriters, 1) {
+		assert.Equal(t, "dwd", dag.MultiWriters[0].Table.Database)
+		assert.Equal(t, []string{"orders", "orders_backfill"}, dag.MultiWriters[0].Jobs)
+	}
+	assert.Equal(t, []string{"orders", "orders_backfill"}, dag.Upstream("daily"))
+
+	diff := dag.Compare(map[string][]string{
+		"report": {"daily"},
+		"daily":  {"orders", "report"},
+	})
+	assert.Equal(t, [][2]string{{"orders_backfill", "daily"}}, diff.Missing)
+	assert.Equal(t, [][2]string{{"report", "daily"}}, diff.Extra)
+
+	_, err = Analyze([]*Job{{Name: "x", Engine: analyzer.EngineTiDB}}, factory)
+	assert.ErrorContains(t, err, "job x: unsupported engine")
+}
+
+func TestAnalyze_VariablesAndCatalog(t *testing.T) {
+	catalog, err := analyzer.NewViewCatalog(hive.NewDependencyAnalyzer(), map[string]string{
+		"orders_v": "CREATE VIEW ads.orders_v AS SELECT * FROM dwd.orders_cn",
+	}, "", "ads")
+	if !assert.NoError(t, err) {
+		return
+	}
+	jobs := []*Job{
+		{
+			Name:      "orders",
+			Engine:    analyzer.EngineSpark,
+			SQL:       "INSERT OVERWRITE TABLE dwd.orders_${region} SELECT * FROM ods.orders WHERE region = '${region}'",
+			Variables: map[string]string{"region": "cn"},
+		},
+		{
+			Name:        "report",
+			Engine:      analyzer.EngineHive,
+			SQL:         "INSERT OVERWRITE TABLE ads.report SELECT * FROM ads.orders_v",
+			Catalog:     catalog,
+			ExpandViews: true,
+		},
+	}
+	dag, err := Analyze(jobs, factory)
+	if !assert.NoError(t, err) {
+		return
+	}
+	// 替换变量后的写表与展开视图得到的读表相同
+	var deps []string
+	for _, dep := range dag.Dependencies {
+		deps = append(deps, fmt.Sprintf("%s -> %s %v", dep.From, dep.To, dep.Tables))
+	}
+	assert.Equal(t, []string{"orders -> report [.dwd.orders_cn]"}, deps)
+}
+
+func TestBuild_Cycle(t *testing.T) {
+	table := func(name string) *analyzer.DependencyTable {
+		return &analyzer.DependencyTable{Database: "db", Table: name}
+	}
+	dag, err := Build([]*JobIO{
+		{Name: "a", Inputs: []*analyzer.DependencyTable{table("c_out"), table("src")}, Outputs: []*analyzer.DependencyTable{table("a_out")}},
+		{Name: "b", Inputs: []*analyzer.DependencyTable{table("a_out")}, Outputs: []*analyzer.DependencyTable{table("b_out")}},
+		{Name: "c", Inputs: []*analyzer.DependencyTable{table("b_out")}, Outputs: []*analyzer.DependencyTable{table("c_out")}},
+		{Name: "d", Inputs: []*analyzer.DependencyTable{table("src")}, Outputs: []*analyzer.DependencyTable{table("src")}},
+		{Name: "e", Inputs: []*analyzer.DependencyTable{table("c_out")}},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	// d 读写同一张表不算循环，但 a 读取 d 的输出
+	assert.Equal(t, []string{"d"}, dag.Order)
+	if assert.Len(t, dag.Cycles, 1) {
+		assert.Equal(t, []string{"a", "b", "c"}, dag.Cycles[0].Jobs)
+		assert.Equal(t, []*analyzer.DependencyTable{table("a_out"), table("b_out"), table("c_out")}, dag.Cycles[0].Tables)
+	}
+
+	_, err = Build([]*JobIO{{Name: "a"}, {Name: "a"}})
+	assert.ErrorContains(t, err, "duplicate job name")
+}
@@ -0,0 +1,254 @@
+package openlineage
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Edsuns/sql-parser/analyzer"
+)
+
+const (
+	// DefaultProducer 默认的事件生产者标识
+	DefaultProducer = "https://github.com/Edsuns/sql-parser"
+
+	RunEventSchemaURL      = "https://openlineage.io/spec/2-0-2/OpenLineage.json#/$defs/RunEvent"
+	SQLJobFacetSchemaURL   = "https://openlineage.io/spec/facets/1-0-1/SQLJobFacet.json#/$defs/SQLJobFacet"
+	ColumnLineageSchemaURL = "https://openlineage.io/spec/facets/1-0-1/ColumnLineageDatasetFacet.json#/$defs/ColumnLineageDatasetFacet"
+)
+
+type EventType string
+
+const (
+	EventTypeStart    EventType = "START"
+	EventTypeRunning  EventType = "RUNNING"
+	EventTypeComplete EventType = "COMPLETE"
+	EventTypeAbort    EventType = "ABORT"
+	EventTypeFail     EventType = "FAIL"
+	EventTypeOther    EventType = "OTHER"
+)
+
+type (
+	// ScriptMeta 脚本元数据，用于生成Job和Run信息
+	ScriptMeta struct {
+		JobNamespace string              `json:"jobNamespace"`
+		JobName      string              `json:"jobName"`
+		RunID        string              `json:"runId"`     // 为空时自动生成UUID
+		EventType    EventType           `json:"eventType"` // 为空时为COMPLETE
+		EventTime    time.Time           `json:"eventTime"` // 为零值时取当前时间
+		Producer     string              `json:"producer"`  // 为空时取 DefaultProducer
+		Engine       analyzer.EngineType `json:"engine"`    // 非空时数据集namespace为 engine://cluster
+		SQL          string              `json:"sql"`       // 非空时作为Job的sql facet
+	}
+	// ColumnLineage 输出表的列级血缘
+	ColumnLineage struct {
+		Output *analyzer.DependencyTable `json:"output"`
+		Fields []*ColumnLineageField     `json:"fields"`
+	}
+	// ColumnLineageField 输出列及其来源列
+	ColumnLineageField struct {
+		Name                      string         `json:"name"`
+		Inputs                    []*ColumnInput `json:"inputs"`
+		TransformationDescription string         `json:"transformationDescription,omitempty"`
+		TransformationType        string         `json:"transformationType,omitempty"`
+	}
+	// ColumnInput 来源列
+	ColumnInput struct {
+		Table  *analyzer.DependencyTable `json:"table"`
+		Column string                    `json:"column"`
+	}
+)
+
+type (
+	// RunEvent OpenLineage RunEvent
+	RunEvent struct {
+		EventType EventType  `json:"eventType"`
+		EventTime string     `json:"eventTime"`
+		Producer  string     `json:"producer"`
+		SchemaURL string     `json:"schemaURL"`
+		Run       *Run       `json:"run"`
+		Job       *Job       `json:"job"`
+		Inputs    []*Dataset `json:"inputs"`
+		Outputs   []*Dataset `json:"outputs"`
+	}
+	Run struct {
+		RunID  string         `json:"runId"`
+		Facets map[string]any `json:"facets,omitempty"`
+	}
+	Job struct {
+		Namespace string         `json:"namespace"`
+		Name      string         `json:"name"`
+		Facets    map[string]any `json:"facets,omitempty"`
+	}
+	Dataset struct {
+		Namespace string         `json:"namespace"`
+		Name      string         `json:"name"`
+		Facets    map[string]any `json:"facets,omitempty"`
+	}
+	// BaseFacet 所有facet共有的字段
+	BaseFacet struct {
+		Producer  string `json:"_producer"`
+		SchemaURL string `json:"_schemaURL"`
+	}
+	SQLJobFacet struct {
+		BaseFacet
+		Query string `json:"query"`
+	}
+	ColumnLineageDatasetFacet struct {
+		BaseFacet
+		Fields map[string]*ColumnLineageFacetField `json:"fields"`
+	}
+	ColumnLineageFacetField struct {
+		InputFields               []*InputField `json:"inputFields"`
+		TransformationDescription string        `json:"transformationDescription,omitempty"`
+		TransformationType        string        `json:"transformationType,omitempty"`
+	}
+	InputField struct {
+		Namespace string `json:"namespace"`
+		Name      string `json:"name"`
+		Field     string `json:"field"`
+	}
+)
+
+// NewRunEvent 将依赖分析结果转换为OpenLineage RunEvent
+//
+// 所有语句的读表合并为inputs，写表合并为outputs，同一个数据集只出现一次。
+func NewRunEvent(meta *ScriptMeta, results []*analyzer.DependencyResult, columnLineage []*ColumnLineage) (*RunEvent, error) {
+	if meta.JobName == "" {
+		return nil, fmt.Errorf("job name is required")
+	}
+	producer := meta.Producer
+	if producer == "" {
+		producer = DefaultProducer
+	}
+	runID := meta.RunID
+	if runID == "" {
+		var err error
+		if runID, err = newUUID(); err != nil {
+			return nil, err
+		}
+	}
+	eventType := meta.EventType
+	if eventType == "" {
+		eventType = EventTypeComplete
+	}
+	eventTime := meta.EventTime
+	if eventTime.IsZero() {
+		eventTime = time.Now()
+	}
+
+	event := &RunEvent{
+		EventType: eventType,
+		EventTime: eventTime.UTC().Format(time.RFC3339Nano),
+		Producer:  producer,
+		SchemaURL: RunEventSchemaURL,
+		Run:       &Run{RunID: runID},
+		Job:       &Job{Namespace: meta.JobNamespace, Name: meta.JobName},
+		Inputs:    []*Dataset{},
+		Outputs:   []*Dataset{},
+	}
+	if meta.SQL != "" {
+		event.Job.Facets = map[string]any{
+			"sql": &SQLJobFacet{
+				BaseFacet: BaseFacet{Producer: producer, SchemaURL: SQLJobFacetSchemaURL},
+				Query:     meta.SQL,
+			},
+		}
+	}
+
+	inputs := make(map[string]*Dataset)
+	outputs := make(map[string]*Dataset)
+	for _, result := range results {
+		for _, table := range result.Read {
+			event.Inputs = appendDataset(event.Inputs, inputs, meta.Engine, table)
+		}
+		for _, table := range result.Write {
+			event.Outputs = appendDataset(event.Outputs, outputs, meta.Engine, table)
+		}
+	}
+
+	for _, lineage := range columnLineage {
+		output := datasetOf(meta.Engine, lineage.Output)
+		// 列级血缘的输出表不在写表中时也作为输出数据集
+		event.Outputs = appendDataset(event.Outputs, outputs, meta.Engine, lineage.Output)
+		dataset := outputs[output.Namespace+"/"+output.Name]
+		facet := &ColumnLineageDatasetFacet{
+			BaseFacet: BaseFacet{Producer: producer, SchemaURL: ColumnLineageSchemaURL},
+			Fields:    make(map[string]*ColumnLineageFacetField),
+		}
+		if existing, ok := dataset.Facets["columnLineage"].(*ColumnLineageDatasetFacet); ok {
+			facet = existing
+		}
+		for _, field := range lineage.Fields {
+			facetField := &ColumnLineageFacetField{
+				InputFields:               []*InputField{},
+				TransformationDescription: field.TransformationDescription,
+				TransformationType:        field.TransformationType,
+			}
+			for _, input := range field.Inputs {
+				source := datasetOf(meta.Engine, input.Table)
+				facetField.InputFields = append(facetField.InputFields, &InputField{
+					Namespace: source.Namespace,
+					Name:      source.Name,
+					Field:     input.Column,
+				})
+			}
+			facet.Fields[field.Name] = facetField
+		}
+		if dataset.Facets == nil {
+			dataset.Facets = make(map[string]any)
+		}
+		dataset.Facets["columnLineage"] = facet
+	}
+	return event, nil
+}
+
+// Marshal 将依赖分析结果序列化为OpenLineage RunEvent JSON
+func Marshal(meta *ScriptMeta, results []*analyzer.DependencyResult, columnLineage []*ColumnLineage) ([]byte, error) {
+	event, err := NewRunEvent(meta, results, columnLineage)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(event)
+}
+
+// appendDataset 去重后追加数据集
+func appendDataset(datasets []*Dataset, seen map[string]*Dataset, engine analyzer.EngineType, table *analyzer.DependencyTable) []*Dataset {
+	dataset := datasetOf(engine, table)
+	key := dataset.Namespace + "/" + dataset.Name
+	if _, ok := seen[key]; ok {
+		return datasets
+	}
+	seen[key] = dataset
+	return append(datasets, dataset)
+}
+
+// datasetOf 将表映射为数据集：Cluster映射为namespace，Database.Table映射为name
+func datasetOf(engine analyzer.EngineType, table *analyzer.DependencyTable) *Dataset {
+	namespace := table.Cluster
+	if engine != "" {
+		namespace = string(engine) + "://" + table.Cluster
+	}
+	var parts []string
+	if table.Database != "" {
+		parts = append(parts, table.Database)
+	}
+	parts = append(parts, table.Table)
+	return &Dataset{
+		Namespace: namespace,
+		Name:      strings.Join(parts, "."),
+	}
+}
+
+// newUUID 生成随机的UUID v4
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
@@ -0,0 +1,158 @@
+package openlineage
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Edsuns/sql-parser/analyzer"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// testdata 中的schema为 https://openlineage.io/spec 的精简版，只保留了RunEvent相关的定义
+var schemaFiles = map[string]string{
+	"https://openlineage.io/spec/2-0-2/OpenLineage.json":                      "OpenLineage.json",
+	"https://openlineage.io/spec/facets/1-0-1/SQLJobFacet.json":               "SQLJobFacet.json",
+	"https://openlineage.io/spec/facets/1-0-1/ColumnLineageDatasetFacet.json": "ColumnLineageDatasetFacet.json",
+}
+
+func compileSchema(t *testing.T, url string) *jsonschema.Schema {
+	c := jsonschema.NewCompiler()
+	c.Draft = jsonschema.Draft2020
+	c.AssertFormat = true
+	for id, file := range schemaFiles {
+		data, err := os.ReadFile(filepath.Join("testdata", file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := c.AddResource(id, bytes.NewReader(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	schema, err := c.Compile(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schema
+}
+
+func TestMarshal(t *testing.T) {
+	runEventSchema := compileSchema(t, RunEventSchemaURL)
+	sqlSchema := compileSchema(t, SQLJobFacetSchemaURL)
+	columnLineageSchema := compileSchema(t, ColumnLineageSchemaURL)
+
+	orders := &analyzer.DependencyTable{Cluster: "c1", Database: "ods", Table: "orders"}
+	users := &analyzer.DependencyTable{Cluster: "c1", Database: "ods", Table: "users"}
+	report := &analyzer.DependencyTable{Cluster: "c1", Database: "dw", Table: "report"}
+	results := []*analyzer.DependencyResult{
+		{
+			Stmt:     "INSERT INTO dw.report SELECT o.id, u.name FROM ods.orders o JOIN ods.users u ON o.uid = u.id",
+			StmtType: analyzer.StmtTypeInsert,
+			Read:     []*analyzer.DependencyTable{orders, users},
+			Write:    []*analyzer.DependencyTable{report},
+		},
+		{
+			Stmt:     "SELECT * FROM ods.orders",
+			StmtType: analyzer.StmtTypeSelect,
+			Read:     []*analyzer.DependencyTable{orders},
+			Write:    []*analyzer.DependencyTable{},
+		},
+	}
+	columnLineage := []*ColumnLineage{
+		{
+			Output: report,
+			Fields: []*ColumnLineageField{
+				{Name: "id", Inputs: []*ColumnInput{{Table: orders, Column: "id"}}, TransformationType: "IDENTITY"},
+				{Name: "name", Inputs: []*ColumnInput{{Table: users, Column: "name"}}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		meta          *ScriptMeta
+		columnLineage []*ColumnLineage
+	}{
+		{
+			name: "minimal metadata",
+			meta: &ScriptMeta{JobNamespace: "etl", JobName: "daily_report"},
+		},
+		{
+			name: "full metadata with column lineage",
+			meta: &ScriptMeta{
+				JobNamespace: "etl",
+				JobName:      "daily_report",
+				RunID:        "3f5e83fa-3480-44ff-99c5-ff943904e5e8",
+				EventType:    EventTypeStart,
+				EventTime:    time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC),
+				Engine:       analyzer.EngineSpark,
+				SQL:          results[0].Stmt,
+			},
+			columnLineage: columnLineage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := Marshal(tt.meta, results, tt.columnLineage)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			var event map[string]any
+			if !assert.NoError(t, json.Unmarshal(data, &event)) {
+				return
+			}
+			assert.NoError(t, runEventSchema.Validate(event))
+
+			job := event["job"].(map[string]any)
+			if facets, ok := job["facets"].(map[string]any); ok {
+				assert.NoError(t, sqlSchema.Validate(facets["sql"]))
+			}
+			for _, output := range event["outputs"].([]any) {
+				if facets, ok := output.(map[string]any)["facets"].(map[string]any); ok {
+					assert.NoError(t, columnLineageSchema.Validate(facets["columnLineage"]))
+				}
+			}
+
+			assert.Len(t, event["inputs"], 2)
+			assert.Len(t, event["outputs"], 1)
+		})
+	}
+}
+
+func TestNewRunEvent(t *testing.T) {
+	results := []*analyzer.DependencyResult{
+		{
+			StmtType: analyzer.StmtTypeInsert,
+			Read:     []*analyzer.DependencyTable{{Cluster: "c1", Database: "ods", Table: "orders"}},
+			Write:    []*analyzer.DependencyTable{{Cluster: "c1", Database: "dw", Table: "report"}},
+		},
+	}
+	event, err := NewRunEvent(&ScriptMeta{JobNamespace: "etl", JobName: "job", Engine: analyzer.EngineHive}, results, []*ColumnLineage{
+		{
+			Output: &analyzer.DependencyTable{Cluster: "c1", Database: "dw", Table: "report"},
+			Fields: []*ColumnLineageField{
+				{Name: "id", Inputs: []*ColumnInput{{Table: results[0].Read[0], Column: "order_id"}}},
+			},
+		},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, EventTypeComplete, event.EventType)
+	assert.Equal(t, &Dataset{Namespace: "hive://c1", Name: "ods.orders"}, event.Inputs[0])
+	if assert.Len(t, event.Outputs, 1) {
+		assert.Equal(t, "hive://c1", event.Outputs[0].Namespace)
+		assert.Equal(t, "dw.report", event.Outputs[0].Name)
+		facet := event.Outputs[0].Facets["columnLineage"].(*ColumnLineageDatasetFacet)
+		assert.Equal(t, []*InputField{{Namespace: "hive://c1", Name: "ods.orders", Field: "order_id"}}, facet.Fields["id"].InputFields)
+	}
+
+	_, err = NewRunEvent(&ScriptMeta{}, results, nil)
+	assert.Error(t, err)
+}
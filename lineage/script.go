@@ -0,0 +1,147 @@
+package lineage
+
+import (
+	"strings"
+
+	"github.com/Edsuns/sql-parser/analyzer"
+)
+
+type (
+	// ScriptEdge 脚本内语句之间的依赖边：From 语句写入的表被 To 语句读取
+	ScriptEdge struct {
+		From  int                       `json:"from"`
+		To    int                       `json:"to"`
+		Table *analyzer.DependencyTable `json:"table"`
+	}
+	// ScriptLineage 脚本级血缘，把同一脚本内的语句连接成DAG
+	ScriptLineage struct {
+		Statements    []*analyzer.DependencyResult   `json:"statements"`
+		Edges         []*ScriptEdge                  `json:"edges"`
+		Inputs        []*analyzer.DependencyTable    `json:"inputs"`              // 脚本真正的外部输入
+		Outputs       []*analyzer.DependencyTable    `json:"outputs"`             // 脚本最终的输出
+		Intermediates []*analyzer.DependencyTable    `json:"intermediates"`       // 脚本内创建且用完即弃的中间对象
+		Resources     []*analyzer.DependencyExternal `json:"resources,omitempty"` // 脚本依赖的JAR、文件等资源
+		Functions     []*analyzer.DependencyFunction `json:"functions,omitempty"` // 脚本调用的非内置函数
+	}
+)
+
+// scriptObject 脚本内被写过的对象的状态
+type scriptObject struct {
+	table     *analyzer.DependencyTable
+	producer  int  // 最后一次写入该对象的语句下标，-1表示没有可用的写入
+	created   bool // 是否在脚本内创建或写入过
+	dropped   bool // 当前是否已被删除
+	temporary bool // 是否为临时对象
+}
+
+// AnalyzeScript 分析脚本并构建脚本级血缘
+func AnalyzeScript(a analyzer.DependencyAnalyzer, req *analyzer.DependencyAnalyzeReq) (*ScriptLineage, error) {
+	results, err := a.Analyze(req)
+	if err != nil {
+		return nil, err
+	}
+	return BuildScriptLineage(results), nil
+}
+
+// BuildScriptLineage 根据按顺序排列的语句分析结果构建脚本级血缘
+//
+// 读取脚本内先前写入的对象会连成语句之间的边，而不是作为外部输入；
+// 在脚本内创建后又被删除的对象以及临时对象归为中间对象，不计入输出。
+func BuildScriptLineage(results []*analyzer.DependencyResult) *ScriptLineage {
+	lineage := &ScriptLineage{
+		Statements:    results,
+		Edges:         []*ScriptEdge{},
+		Inputs:        []*analyzer.DependencyTable{},
+		Outputs:       []*analyzer.DependencyTable{},
+		Intermediates: []*analyzer.DependencyTable{},
+	}
+
+	objects := make(map[string]*scriptObject)
+	var order []string // 对象首次被写入的顺序，保证输出稳定
+	inputs := make(map[string]bool)
+	edges := make(map[[2]int]map[string]bool)
+
+	resources := make(map[string]bool)
+	for i, result := range results {
+		for _, external := range result.ExternalRead {
+			if external.Type != analyzer.ExternalTypePath && !resources[external.String()] {
+				resources[external.String()] = true
+				lineage.Resources = append(lineage.Resources, external)
+			}
+		}
+		for _, f := range result.FunctionRead {
+			if !f.Builtin {
+				lineage.Functions = analyzer.AppendFunction(lineage.Functions, f)
+			}
+		}
+
+		// 先处理读表，INSERT INTO t SELECT * FROM t 读到的是语句执行前的t
+		for _, read := range result.Read {
+			key := TableKey(read)
+			if obj, ok := objects[key]; ok && !obj.dropped && obj.producer >= 0 {
+				if edges[[2]int{obj.producer, i}] == nil {
+					edges[[2]int{obj.producer, i}] = make(map[string]bool)
+				}
+				if !edges[[2]int{obj.producer, i}][key] {
+					edges[[2]int{obj.producer, i}][key] = true
+					lineage.Edges = append(lineage.Edges, &ScriptEdge{From: obj.producer, To: i, Table: obj.table})
+				}
+				continue
+			}
+			if !inputs[key] {
+				inputs[key] = true
+				lineage.Inputs = append(lineage.Inputs, read)
+			}
+		}
+
+		for _, write := range result.Write {
+			key := TableKey(write)
+			obj, ok := objects[key]
+			if !ok {
+				obj = &scriptObject{table: write, producer: -1}
+				objects[key] = obj
+				order = append(order, key)
+			}
+			if result.StmtType == analyzer.StmtTypeDropTable {
+				obj.dropped = true
+				obj.producer = -1
+				continue
+			}
+			obj.table = write
+			obj.producer = i
+			obj.created = true
+			obj.dropped = false
+			obj.temporary = obj.temporary || write.Temporary
+		}
+	}
+
+	for _, key := range order {
+		obj := objects[key]
+		switch {
+		case obj.created && (obj.dropped || obj.temporary):
+			lineage.Intermediates = append(lineage.Intermediates, obj.table)
+		default:
+			// 只被删除过的外部对象也属于脚本的输出
+			lineage.Outputs = append(lineage.Outputs, obj.table)
+		}
+	}
+	return lineage
+}
+
+// Upstream 返回脚本内第i条语句直接依赖的语句下标
+func (s *ScriptLineage) Upstream(i int) []int {
+	var result []int
+	seen := make(map[int]bool)
+	for _, edge := range s.Edges {
+		if edge.To == i && !seen[edge.From] {
+			seen[edge.From] = true
+			result = append(result, edge.From)
+		}
+	}
+	return result
+}
+
+// TableKey 返回表的规范化标识，忽略大小写
+func TableKey(t *analyzer.DependencyTable) string {
+	return strings.ToLower(t.String())
+}
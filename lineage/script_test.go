@@ -0,0 +1,123 @@
+package lineage
+
+import (
+	"testing"
+
+	"github.com/Edsuns/sql-parser/analyzer"
+	"github.com/Edsuns/sql-parser/internal/spark"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildScriptLineage(t *testing.T) {
+	tests := []struct {
+		name          string
+		sql           string
+		edges         [][2]int
+		inputs        []string
+		outputs       []string
+		intermediates []string
+	}{
+		{
+			name: "temporary view collapsed",
+			sql: `CREATE TEMPORARY VIEW tmp AS SELECT * FROM src;
+INSERT INTO final SELECT * FROM tmp;
+DROP VIEW tmp;`,
+			edges:         [][2]int{{0, 1}},
+			inputs:        []string{"c.db.src"},
+			outputs:       []string{"c.db.final"},
+			intermediates: []string{"c.db.tmp"},
+		},
+		{
+			name: "staging table dropped at the end",
+			sql: `DROP TABLE IF EXISTS stage.tmp;
+CREATE TABLE stage.tmp AS SELECT * FROM ods.orders;
+INSERT OVERWRITE TABLE dw.orders SELECT * FROM stage.tmp JOIN dim.users ON 1 = 1;
+DROP TABLE stage.tmp;`,
+			edges:         [][2]int{{1, 2}},
+			inputs:        []string{"c.ods.orders", "c.dim.users"},
+			outputs:       []string{"c.dw.orders"},
+			intermediates: []string{"c.stage.tmp"},
+		},
+		{
+			name: "table kept after script is an output",
+			sql: `CREATE TABLE t1 AS SELECT * FROM src;
+INSERT INTO t2 SELECT * FROM t1;`,
+			edges:   [][2]int{{0, 1}},
+			inputs:  []string{"c.db.src"},
+			outputs: []string{"c.db.t1", "c.db.t2"},
+		},
+		{
+			name: "self read resolves to the previous writer",
+			sql: `INSERT INTO t1 SELECT * FROM src;
+INSERT OVERWRITE TABLE t1 SELECT * FROM t1;`,
+			edges:   [][2]int{{0, 1}},
+			inputs:  []string{"c.db.src"},
+			outputs: []string{"c.db.t1"},
+		},
+		{
+			name: "read after drop is external",
+			sql: `CREATE TABLE t1 AS SELECT * FROM src;
+DROP TABLE t1;
+SELECT * FROM t1;`,
+			inputs:        []string{"c.db.src", "c.db.t1"},
+			intermediates: []string{"c.db.t1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &analyzer.DependencyAnalyzeReq{
+				DefaultCluster:  "c",
+				DefaultDatabase: "db",
+				Type:            analyzer.EngineSpark,
+				SQL:             tt.sql,
+			}
+			result, err := AnalyzeScript(spark.NewDependencyAnalyzer(), req)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			var edges [][2]int
+			for _, edge := range result.Edges {
+				edges = append(edges, [2]int{edge.From, edge.To})
+			}
+			assert.Equal(t, tt.edges, edges)
+			assert.Equal(t, tt.inputs, tableNames(result.Inputs))
+			assert.Equal(t, tt.outputs, tableNames(result.Outputs))
+			assert.Equal(t, tt.intermediates, tableNames(result.Intermediates))
+		})
+	}
+}
+
+func tableNames(tables []*analyzer.DependencyTable) []string {
+	var names []string
+	for _, table := range tables {
+		names = append(names, table.String())
+	}
+	return names
+}
+
+func TestBuildScriptLineage_Resources(t *testing.T) {
+	req := &analyzer.DependencyAnalyzeReq{
+		DefaultCluster:  "c",
+		DefaultDatabase: "db",
+		Type:            analyzer.EngineSpark,
+		SQL: `ADD JAR /libs/udf.jar;
+CREATE TEMPORARY FUNCTION f AS 'com.example.F' USING JAR '/libs/f.jar';
+INSERT INTO out SELECT f(a), udfs.g(b), upper(c) FROM parquet.` + "`/data/in`" + `;
+ADD JAR /libs/udf.jar;`,
+	}
+	result, err := AnalyzeScript(spark.NewDependencyAnalyzer(), req)
+	if !assert.NoError(t, err) {
+		return
+	}
+	var resources, functions []string
+	for _, r := range result.Resources {
+		resources = append(resources, string(r.Type)+":"+r.Location)
+	}
+	for _, f := range result.Functions {
+		functions = append(functions, f.String())
+	}
+	assert.Equal(t, []string{"JAR:/libs/udf.jar", "JAR:/libs/f.jar"}, resources)
+	assert.Equal(t, []string{"f", "udfs.g"}, functions)
+}